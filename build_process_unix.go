@@ -0,0 +1,18 @@
+//go:build !windows
+// +build !windows
+
+package hariti
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// configureBuildCmd runs a build step in its own process group, so that
+// cancelling it also stops the processes the shell has spawned.
+func configureBuildCmd(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build windows
+// +build windows
+
+package hariti
+
+import "os/exec"
+
+// configureBuildCmd leaves the build step as is; processes spawned by a
+// cancelled step are cut off from its output by buildWaitDelay.
+func configureBuildCmd(cmd *exec.Cmd) {}
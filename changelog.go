@@ -0,0 +1,186 @@
+package hariti
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kamichidu/go-hariti/graph"
+	"github.com/kamichidu/go-hariti/vcs"
+)
+
+type ChangeType string
+
+const (
+	ChangeAdded   ChangeType = "added"
+	ChangeRemoved ChangeType = "removed"
+	ChangeUpdated ChangeType = "updated"
+	// ChangeRewound marks a bundle whose new revision does not contain the old
+	// one, such as a branch reset to an older commit or a rewritten history.
+	ChangeRewound ChangeType = "rewound"
+)
+
+type ChangelogOptions struct {
+	// Only limits the changelog to the given bundle IDs or aliases.
+	Only []string
+}
+
+type BundleChange struct {
+	BundleID    string     `json:"id"`
+	Type        ChangeType `json:"type"`
+	OldRevision string     `json:"old_revision,omitempty"`
+	NewRevision string     `json:"new_revision,omitempty"`
+	// Commits lists the commits gained between the old and the new revision,
+	// newest first.
+	Commits []vcs.Commit `json:"commits,omitempty"`
+	// Dropped lists the commits of the old revision that the new revision no
+	// longer contains, newest first. It is only set for rewound bundles.
+	Dropped []vcs.Commit `json:"dropped,omitempty"`
+}
+
+// Changelog compares hariti.lock against the lockfile it replaced during the
+// latest sync and reports every bundle whose revision changed. Commit subjects
+// are read from the repository cache; bundles whose history is not cached are
+// reported without commits.
+func (h *Hariti) Changelog(ctx context.Context, g *graph.Graph, opts ChangelogOptions) ([]BundleChange, error) {
+	rg := h.newRuntimeGraph(g)
+
+	current, err := h.loadLockfile()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lockfile: %w", err)
+	}
+	previous, err := readLockfile(h.PreviousLockfilePath())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read previous lockfile: %w", err)
+		}
+		previous = &Lockfile{}
+	}
+
+	selected, err := selectChangelogBundles(rg.bundles, previous, opts.Only)
+	if err != nil {
+		return nil, err
+	}
+
+	bundles := make(map[string]graph.Bundle, len(rg.bundles))
+	for _, bundle := range rg.bundles {
+		bundles[bundle.ID] = bundle
+	}
+	oldEntries := make(map[string]LockfileEntry, len(previous.Bundles))
+	for _, entry := range previous.Bundles {
+		oldEntries[entry.ID] = entry
+	}
+	newEntries := make(map[string]bool, len(current.Bundles))
+
+	changes := []BundleChange{}
+	for _, entry := range current.Bundles {
+		newEntries[entry.ID] = true
+		if selected != nil && !selected[entry.ID] {
+			continue
+		}
+
+		oldEntry, exists := oldEntries[entry.ID]
+		if !exists {
+			changes = append(changes, BundleChange{
+				BundleID:    entry.ID,
+				Type:        ChangeAdded,
+				NewRevision: entry.Revision,
+			})
+			continue
+		}
+		if oldEntry.Revision == entry.Revision && oldEntry.Source == entry.Source {
+			continue
+		}
+
+		change := BundleChange{
+			BundleID:    entry.ID,
+			Type:        ChangeUpdated,
+			OldRevision: oldEntry.Revision,
+			NewRevision: entry.Revision,
+		}
+		if bundle, exists := bundles[entry.ID]; exists && bundle.Source.Type == graph.SourceTypeRemote {
+			if err := h.collectCommits(ctx, bundle, &change); err != nil {
+				return nil, err
+			}
+		}
+		changes = append(changes, change)
+	}
+
+	for _, entry := range previous.Bundles {
+		if newEntries[entry.ID] || (selected != nil && !selected[entry.ID]) {
+			continue
+		}
+		changes = append(changes, BundleChange{
+			BundleID:    entry.ID,
+			Type:        ChangeRemoved,
+			OldRevision: entry.Revision,
+		})
+	}
+
+	return changes, nil
+}
+
+func (h *Hariti) collectCommits(ctx context.Context, bundle graph.Bundle, change *BundleChange) error {
+	v := vcs.Detect(bundle.Source.URL)
+	if v == nil {
+		return fmt.Errorf("failed to detect VCS for bundle %s with URL %s", bundle.ID, bundle.Source.URL)
+	}
+	var vcsOutput bytes.Buffer
+	vcsCtx := vcs.WithLogger(ctx, h.logger)
+	vcsCtx = vcs.WithWriter(vcsCtx, &vcsOutput)
+	vcsCtx = vcs.WithErrWriter(vcsCtx, &vcsOutput)
+
+	commits, err := v.Log(vcsCtx, bundle, change.OldRevision, change.NewRevision)
+	if err != nil {
+		return fmt.Errorf("failed to read commit log for bundle %s: %w\n%s", bundle.ID, err, strings.TrimSpace(vcsOutput.String()))
+	}
+	dropped, err := v.Log(vcsCtx, bundle, change.NewRevision, change.OldRevision)
+	if err != nil {
+		return fmt.Errorf("failed to read commit log for bundle %s: %w\n%s", bundle.ID, err, strings.TrimSpace(vcsOutput.String()))
+	}
+	if commits == nil && dropped == nil {
+		h.logger.Debugf("history between %s and %s is not cached for bundle %s", change.OldRevision, change.NewRevision, bundle.ID)
+	}
+
+	change.Commits = commits
+	if len(dropped) > 0 {
+		change.Type = ChangeRewound
+		change.Dropped = dropped
+	}
+	return nil
+}
+
+// selectChangelogBundles resolves bundle IDs or aliases like selectBundles, but
+// also accepts the IDs of bundles that only the previous lockfile records. A nil
+// set selects every bundle.
+func selectChangelogBundles(bundles []graph.Bundle, previous *Lockfile, names []string) (map[string]bool, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	locked := make(map[string]bool, len(previous.Bundles))
+	for _, entry := range previous.Bundles {
+		locked[entry.ID] = true
+	}
+
+	var known []string
+	selected := make(map[string]bool, len(names))
+	for _, name := range names {
+		if locked[name] {
+			selected[name] = true
+			continue
+		}
+		known = append(known, name)
+	}
+
+	ids, err := selectBundles(bundles, known)
+	if err != nil {
+		return nil, err
+	}
+	for id := range ids {
+		selected[id] = true
+	}
+	return selected, nil
+}
@@ -0,0 +1,151 @@
+package hariti_test
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kamichidu/go-hariti"
+	"github.com/kamichidu/go-hariti/graph"
+	"github.com/kamichidu/go-hariti/vcs"
+)
+
+func TestHariti_Changelog(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	xdgHome := filepath.Join(tmpDir, "xdg_home")
+	_ = os.Setenv("XDG_DATA_HOME", xdgHome)
+	defer func() {
+		_ = os.Unsetenv("XDG_DATA_HOME")
+	}()
+
+	// 1. Setup mock Git remote repo
+	remoteRepoDir := filepath.Join(tmpDir, "remote_repo")
+	if err := os.MkdirAll(remoteRepoDir, 0755); err != nil {
+		t.Fatalf("failed to create mock remote dir: %v", err)
+	}
+
+	_ = runGitCmdInDir(t, remoteRepoDir, "init", "--initial-branch=main")
+	_ = runGitCmdInDir(t, remoteRepoDir, "config", "user.email", "test@hariti.io")
+	_ = runGitCmdInDir(t, remoteRepoDir, "config", "user.name", "Test Hariti")
+	_ = runGitCmdInDir(t, remoteRepoDir, "commit", "--allow-empty", "-m", "initial commit")
+	rev1 := runGitCmdInDir(t, remoteRepoDir, "rev-parse", "HEAD")
+
+	remoteURL, err := url.Parse("file://" + filepath.ToSlash(remoteRepoDir))
+	if err != nil {
+		t.Fatalf("failed to parse remote URL: %v", err)
+	}
+
+	newBundle := func(id string) graph.Bundle {
+		return graph.Bundle{
+			ID: id,
+			Source: graph.Source{
+				Type: graph.SourceTypeRemote,
+				URL:  remoteURL,
+				Path: filepath.Join(xdgHome, "hariti", "repos", url.QueryEscape(id)),
+			},
+		}
+	}
+
+	cfg := &hariti.HaritiConfig{
+		Paths: hariti.Paths{
+			ConfigFile: filepath.Join(tmpDir, "hariti_home", "bundles.hariti"),
+			ConfigDir:  filepath.Join(tmpDir, "hariti_home"),
+			DataDir:    filepath.Join(xdgHome, "hariti"),
+		},
+		Writer:    io.Discard,
+		ErrWriter: io.Discard,
+	}
+	har := hariti.NewHariti(cfg)
+
+	ctx := context.Background()
+	ctx = vcs.WithWriter(ctx, io.Discard)
+	ctx = vcs.WithErrWriter(ctx, io.Discard)
+
+	// Step 1: The first sync reports every bundle as added
+	g := &graph.Graph{
+		Bundles: []graph.Bundle{newBundle("my-updated-plugin"), newBundle("my-removed-plugin")},
+	}
+	if _, err := har.Sync(ctx, g, hariti.SyncOptions{}); err != nil {
+		t.Fatalf("initial Sync failed: %v", err)
+	}
+	changes, err := har.Changelog(ctx, g, hariti.ChangelogOptions{})
+	if err != nil {
+		t.Fatalf("Changelog failed: %v", err)
+	}
+	if len(changes) != 2 || changes[0].Type != hariti.ChangeAdded || changes[1].Type != hariti.ChangeAdded {
+		t.Errorf("expected 2 added bundles, got %+v", changes)
+	}
+
+	// Step 2: Advance remote, drop one bundle and add another
+	_ = runGitCmdInDir(t, remoteRepoDir, "commit", "--allow-empty", "-m", "first change")
+	_ = runGitCmdInDir(t, remoteRepoDir, "commit", "--allow-empty", "-m", "second change")
+	rev3 := runGitCmdInDir(t, remoteRepoDir, "rev-parse", "HEAD")
+
+	g = &graph.Graph{
+		Bundles: []graph.Bundle{newBundle("my-updated-plugin"), newBundle("my-added-plugin")},
+	}
+	if _, err := har.Sync(ctx, g, hariti.SyncOptions{}); err != nil {
+		t.Fatalf("second Sync failed: %v", err)
+	}
+	changes, err = har.Changelog(ctx, g, hariti.ChangelogOptions{})
+	if err != nil {
+		t.Fatalf("Changelog failed: %v", err)
+	}
+	if len(changes) != 3 {
+		t.Fatalf("expected 3 changes, got %+v", changes)
+	}
+	updated := changes[0]
+	if updated.BundleID != "my-updated-plugin" || updated.Type != hariti.ChangeUpdated || updated.OldRevision != rev1 || updated.NewRevision != rev3 {
+		t.Errorf("unexpected update entry: %+v", updated)
+	}
+	if len(updated.Commits) != 2 || updated.Commits[0].Subject != "second change" || updated.Commits[1].Subject != "first change" {
+		t.Errorf("expected commits [second change, first change], got %+v", updated.Commits)
+	}
+	if changes[1].BundleID != "my-added-plugin" || changes[1].Type != hariti.ChangeAdded {
+		t.Errorf("expected my-added-plugin to be added, got %+v", changes[1])
+	}
+	if changes[2].BundleID != "my-removed-plugin" || changes[2].Type != hariti.ChangeRemoved {
+		t.Errorf("expected my-removed-plugin to be removed, got %+v", changes[2])
+	}
+
+	// Step 3: Bundles can be selected, including removed ones
+	changes, err = har.Changelog(ctx, g, hariti.ChangelogOptions{Only: []string{"my-removed-plugin"}})
+	if err != nil {
+		t.Fatalf("Changelog failed: %v", err)
+	}
+	if len(changes) != 1 || changes[0].BundleID != "my-removed-plugin" {
+		t.Errorf("expected only my-removed-plugin, got %+v", changes)
+	}
+
+	// Step 4: Resetting the remote to an older commit is flagged as a rewind
+	_ = runGitCmdInDir(t, remoteRepoDir, "reset", "--hard", "HEAD~1")
+	if _, err := har.Sync(ctx, g, hariti.SyncOptions{}); err != nil {
+		t.Fatalf("third Sync failed: %v", err)
+	}
+	changes, err = har.Changelog(ctx, g, hariti.ChangelogOptions{Only: []string{"my-updated-plugin"}})
+	if err != nil {
+		t.Fatalf("Changelog failed: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Type != hariti.ChangeRewound {
+		t.Fatalf("expected a rewound bundle, got %+v", changes)
+	}
+	if len(changes[0].Dropped) != 1 || changes[0].Dropped[0].Revision != rev3 {
+		t.Errorf("expected dropped commit %s, got %+v", rev3, changes[0].Dropped)
+	}
+
+	// Step 5: A sync that changes nothing keeps reporting the last change
+	if _, err := har.Sync(ctx, g, hariti.SyncOptions{}); err != nil {
+		t.Fatalf("fourth Sync failed: %v", err)
+	}
+	changes, err = har.Changelog(ctx, g, hariti.ChangelogOptions{Only: []string{"my-updated-plugin"}})
+	if err != nil {
+		t.Fatalf("Changelog failed: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Type != hariti.ChangeRewound {
+		t.Errorf("expected the rewind to be kept, got %+v", changes)
+	}
+}
@@ -9,7 +9,10 @@ import (
 	_ "github.com/kamichidu/go-hariti/vcs/git"
 )
 
+// appVersion is set at build time with -ldflags "-X main.appVersion=...".
+var appVersion = "dev"
+
 func main() {
-	ctx := context.Background()
+	ctx := cli.ContextWithVersion(context.Background(), appVersion)
 	os.Exit(cli.Run(ctx, os.Args))
 }
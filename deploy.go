@@ -1,29 +1,233 @@
 package hariti
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/kamichidu/go-hariti/graph"
 	"github.com/kamichidu/go-hariti/vcs"
+	"golang.org/x/sync/errgroup"
 )
 
+type DeployEventType string
+
+const (
+	DeployEventStarted         DeployEventType = "started"
+	DeployEventBundleStarted   DeployEventType = "bundle-started"
+	DeployEventBundlePhase     DeployEventType = "bundle-phase"
+	DeployEventBundleCompleted DeployEventType = "bundle-completed"
+	DeployEventBundleFailed    DeployEventType = "bundle-failed"
+	DeployEventCompleted       DeployEventType = "completed"
+	DeployEventFailed          DeployEventType = "failed"
+)
+
+type DeployProgressEvent struct {
+	Type        DeployEventType
+	BundleID    string
+	Total       int
+	Num         int
+	Parallelism int
+	// Phase is the step a bundle-phase event enters: archive, build or
+	// helptags.
+	Phase Phase
+	// Reused reports a completed bundle whose store entry was built by an
+	// earlier deploy.
+	Reused bool
+	Err    error
+	Output string
+	// GenerationID is the generation being deployed.
+	GenerationID string
+}
+
 type DeployOptions struct {
-	// Options can be expanded in the future
+	Parallelism int
+	OnProgress  func(event DeployProgressEvent)
+	// BuildTimeout bounds the time spent on the build steps of a single bundle.
+	// A bundle exceeding it fails with a *TimeoutError.
+	BuildTimeout time.Duration
+	// Force creates a new generation even when the current one already has
+	// the same identity.
+	Force bool
+	// Projections selects the runtime scripts generated besides packadd.vim,
+	// which every generation has.
+	Projections []Projection
 }
 
+// buildWaitDelay bounds how long a cancelled build step may keep its output
+// pipes open through child processes that outlive the shell.
+const buildWaitDelay = 5 * time.Second
+
 type GenerationMetadata struct {
 	ID        string `json:"id"`
 	CreatedAt string `json:"created_at"`
 	LockHash  string `json:"lock_hash"`
+	// Identity is the hash of every deploy input, as computed by
+	// generationIdentity. Generations written before it existed leave it
+	// empty and never match.
+	Identity string `json:"identity,omitempty"`
+	// HaritiVersion is the version of hariti that deployed the generation.
+	HaritiVersion string `json:"hariti_version,omitempty"`
+	// VimVersion is the first line of `vim --version` of the Vim used for
+	// help tags, empty when Vim could not be run.
+	VimVersion string `json:"vim_version,omitempty"`
+	// Graph is the resolved graph the generation was deployed from.
+	Graph *graph.Graph `json:"graph,omitempty"`
+	// Bundles records how each bundle was put into the generation, in graph
+	// order.
+	Bundles []GenerationBundle `json:"bundles,omitempty"`
+	// Projections lists the runtime scripts generated in the generation.
+	Projections []Projection `json:"projections,omitempty"`
+}
+
+// GenerationBundle records how a bundle was put into a generation. Durations
+// are in milliseconds and are left out for phases the bundle did not enter.
+type GenerationBundle struct {
+	ID string `json:"id"`
+	// Reused reports a bundle linked from a store entry built by an earlier
+	// deploy. Its build steps are the ones recorded when the entry was built.
+	Reused         bool              `json:"reused,omitempty"`
+	ArchiveMillis  int64             `json:"archive_ms,omitempty"`
+	BuildMillis    int64             `json:"build_ms,omitempty"`
+	HelptagsMillis int64             `json:"helptags_ms,omitempty"`
+	BuildSteps     []BuildStepResult `json:"build_steps,omitempty"`
+	// Log is the path of the captured VCS and build output, relative to the
+	// generation directory.
+	Log string `json:"log,omitempty"`
+}
+
+// BuildStepResult is the outcome of a build step that ran.
+type BuildStepResult struct {
+	OS             string `json:"os"`
+	Cmd            string `json:"cmd"`
+	ExitCode       int    `json:"exit_code"`
+	DurationMillis int64  `json:"duration_ms"`
+}
+
+// BuildError reports a failed build step of a bundle. Steps holds the results
+// of the steps that ran, the failed one last.
+type BuildError struct {
+	BundleID string
+	Steps    []BuildStepResult
+	Err      error
+}
+
+func (e *BuildError) Error() string {
+	failed := e.Steps[len(e.Steps)-1]
+	return fmt.Sprintf("failed to run build step for bundle %s on %s (exit code %d after %dms): %v", e.BundleID, failed.OS, failed.ExitCode, failed.DurationMillis, e.Err)
+}
+
+func (e *BuildError) Unwrap() error {
+	return e.Err
+}
+
+// phaseTimer accumulates the time a bundle spends in each phase it enters.
+type phaseTimer struct {
+	phase     Phase
+	start     time.Time
+	durations map[Phase]time.Duration
+}
+
+func newPhaseTimer() *phaseTimer {
+	return &phaseTimer{durations: make(map[Phase]time.Duration)}
+}
+
+// enter ends the running phase, if any, and starts timing phase.
+func (t *phaseTimer) enter(phase Phase) {
+	t.stop()
+	t.phase = phase
+	t.start = time.Now()
+}
+
+func (t *phaseTimer) stop() {
+	if t.phase != "" {
+		t.durations[t.phase] += time.Since(t.start)
+		t.phase = ""
+	}
+}
+
+func (t *phaseTimer) millis(phase Phase) int64 {
+	return t.durations[phase].Milliseconds()
+}
+
+// identityBundle holds the fields of a bundle that affect the generation
+// built from it.
+type identityBundle struct {
+	ID        string           `json:"id"`
+	Type      graph.SourceType `json:"type"`
+	LocalPath string           `json:"local_path,omitempty"`
+	EnableIf  string           `json:"enable_if,omitempty"`
+	// EnableIfLua only changes packadd.lua, but is hashed regardless of the
+	// projections since it is part of the graph
+	EnableIfLua string            `json:"enable_if_lua,omitempty"`
+	Build       []graph.BuildStep `json:"build,omitempty"`
+	// Lazily loaded bundles also depend on their triggers and on the
+	// dependencies their loader loads
+	OnCmd        []string `json:"on_cmd,omitempty"`
+	OnFt         []string `json:"on_ft,omitempty"`
+	OnMap        []string `json:"on_map,omitempty"`
+	OnEvent      []string `json:"on_event,omitempty"`
+	Dependencies []string `json:"dependencies,omitempty"`
+}
+
+// generationIdentity hashes the lockfile together with the deploy-relevant
+// fields of the graph. Two deploys with the same identity produce the same
+// generation.
+func generationIdentity(rg *runtimeGraph, lockBytes []byte, projections []Projection) (string, error) {
+	bundles := make([]identityBundle, 0, len(rg.bundles))
+	lazy := lazyBundles(rg.bundles)
+	for _, bundle := range rg.bundles {
+		ib := identityBundle{
+			ID:   bundle.ID,
+			Type: bundle.Source.Type,
+			// The effective condition equals enable_if for bundles without
+			// conditional dependencies, which keeps their identity stable
+			EnableIf:    bundle.EffectiveEnableIf,
+			EnableIfLua: bundle.EnableIfLua,
+			Build:       bundle.Build,
+		}
+		if bundle.Source.Type == graph.SourceTypeLocal {
+			ib.LocalPath = bundle.Source.Path
+		}
+		if lazy[bundle.ID] {
+			ib.OnCmd = bundle.OnCmd
+			ib.OnFt = bundle.OnFt
+			ib.OnMap = bundle.OnMap
+			ib.OnEvent = bundle.OnEvent
+			ib.Dependencies = bundle.Dependencies
+		}
+		bundles = append(bundles, ib)
+	}
+	graphBytes, err := json.Marshal(bundles)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize generation identity: %w", err)
+	}
+
+	hasher := sha256.New()
+	hasher.Write(lockBytes)
+	hasher.Write([]byte{0})
+	hasher.Write(graphBytes)
+	// Only deploys with extra projections hash them, which keeps the identity
+	// of packadd.vim-only generations stable
+	if len(projections) > 1 {
+		hasher.Write([]byte{0})
+		for _, p := range projections {
+			hasher.Write([]byte(p))
+			hasher.Write([]byte{0})
+		}
+	}
+	return fmt.Sprintf("%x", hasher.Sum(nil)), nil
 }
 
 func getExportedBundleDirName(bundleID string) string {
@@ -41,26 +245,78 @@ func matchOS(stepOS, currentOS string) bool {
 }
 
 func (h *Hariti) Deploy(ctx context.Context, g *graph.Graph, opts DeployOptions) (string, error) {
+	projections, err := normalizeProjections(opts.Projections)
+	if err != nil {
+		return "", err
+	}
 	rg := h.newRuntimeGraph(g)
 	h.logger.Infof("deploy started")
 
+	// Load every bundle after the bundles it depends on
+	ordered, err := graph.TopologicalSort(graph.Graph{Bundles: rg.bundles})
+	if err != nil {
+		return "", fmt.Errorf("failed to order bundles by dependencies: %w", err)
+	}
+	// and only when the bundles it depends on are enabled
+	deployGraph := graph.Graph{Bundles: ordered}
+	if err := deployGraph.ResolveDependencies(); err != nil {
+		return "", fmt.Errorf("failed to resolve dependencies: %w", err)
+	}
+	if err := deployGraph.ResolveEnableIf(); err != nil {
+		return "", fmt.Errorf("failed to resolve enable conditions: %w", err)
+	}
+	rg.bundles = deployGraph.Bundles
+
+	unlock, err := h.lockDataDir()
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+	if err := h.cleanStaging(); err != nil {
+		return "", err
+	}
+
 	// Read project-side hariti.lock content
 	lockBytes, err := os.ReadFile(h.LockfilePath())
 	if err != nil {
 		return "", fmt.Errorf("failed to read lockfile: %w", err)
 	}
 
-	// Calculate generation semantic identity (hash of lockfile contents)
+	// Calculate generation semantic identity (hash of lockfile contents and
+	// deploy-relevant graph fields)
 	hash := sha256.Sum256(lockBytes)
-	shortHash := fmt.Sprintf("%x", hash)[:8]
-	timestamp := time.Now().Format("20060102-150405")
-	genID := fmt.Sprintf("%s-%s", timestamp, shortHash)
+	identity, err := generationIdentity(rg, lockBytes, projections)
+	if err != nil {
+		return "", err
+	}
 
-	genDir := filepath.Join(h.GenerationsDir(), genID)
-	if err := os.MkdirAll(genDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create generation directory: %w", err)
+	if !opts.Force {
+		currentID, err := h.currentGenerationWithIdentity(identity)
+		if err != nil {
+			return "", err
+		}
+		if currentID != "" {
+			h.logger.Infof("generation %s is up to date, skipped deploy", h.describeGeneration(currentID))
+			return currentID, nil
+		}
 	}
-	h.logger.Infof("generation created: %s", genID)
+
+	shortHash := identity[:8]
+	timestamp := time.Now().Format("20060102-150405")
+	genID, err := h.createStagingDir(fmt.Sprintf("%s-%s", timestamp, shortHash))
+	if err != nil {
+		return "", err
+	}
+	// Build the generation in staging, so that a failed deploy never leaves a
+	// partial generation behind
+	genDir := filepath.Join(h.StagingDir(), genID)
+	published := false
+	defer func() {
+		if !published {
+			_ = os.RemoveAll(genDir)
+		}
+	}()
+	h.logger.Infof("generation staged: %s", genID)
 
 	// Parse lockfile to get mapped revisions
 	var lock Lockfile
@@ -68,132 +324,134 @@ func (h *Hariti) Deploy(ctx context.Context, g *graph.Graph, opts DeployOptions)
 		return "", fmt.Errorf("failed to parse lockfile: %w", err)
 	}
 
-	revisionsMap := make(map[string]string)
+	entries := make(map[string]LockfileEntry)
 	for _, entry := range lock.Bundles {
-		revisionsMap[entry.ID] = entry.Revision
+		entries[entry.ID] = entry
 	}
 
-	for _, bundle := range rg.bundles {
-		if bundle.Source.Type == graph.SourceTypeLocal {
-			h.logger.Debugf("local bundle %s: skipped folder creation and copying", bundle.ID)
-			docDir := filepath.Join(bundle.Source.Path, "doc")
-			if err := h.buildHelpTags(bundle.ID, docDir); err != nil {
-				return "", err
-			}
-			continue
-		}
+	parallelism := opts.Parallelism
+	if parallelism <= 0 {
+		parallelism = 8
+	}
+	if opts.OnProgress != nil {
+		opts.OnProgress(DeployProgressEvent{
+			Type:         DeployEventStarted,
+			Total:        len(rg.bundles),
+			Parallelism:  parallelism,
+			GenerationID: genID,
+		})
+	}
 
-		destDir := filepath.Join(genDir, "pack", "hariti", "opt", getExportedBundleDirName(bundle.ID))
-		h.logger.Debugf("resolved repository path for bundle %s to %s", bundle.ID, destDir)
-		if err := os.MkdirAll(destDir, 0755); err != nil {
-			return "", fmt.Errorf("failed to create directory for bundle %s: %w", bundle.ID, err)
-		}
+	logsDir := filepath.Join(genDir, "logs")
+	if err := os.MkdirAll(logsDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory %s: %w", logsDir, err)
+	}
 
-		if bundle.Source.Type == graph.SourceTypeRemote {
-			// Remote source: Export bundle from the revision in lockfile
-			revision, exists := revisionsMap[bundle.ID]
-			if !exists || revision == "" {
-				return "", fmt.Errorf("no revision found in lockfile for remote bundle %s", bundle.ID)
-			}
-			h.logger.Debugf("resolved revision for bundle %s to %s", bundle.ID, revision)
+	sem := make(chan struct{}, parallelism)
+	var completedCount int32
+	records := make([]GenerationBundle, len(rg.bundles))
 
-			v := vcs.Detect(bundle.Source.URL)
-			if v == nil {
-				return "", fmt.Errorf("failed to detect VCS for remote bundle %s", bundle.ID)
-			}
+	eg, egCtx := errgroup.WithContext(ctx)
 
-			vcsCtx := vcs.WithLogger(ctx, h.logger)
-			vcsCtx = vcs.WithWriter(vcsCtx, h.config.Writer)
-			vcsCtx = vcs.WithErrWriter(vcsCtx, h.config.ErrWriter)
-			h.logger.Debugf("archive source %s/destination %s", bundle.ID, destDir)
-			err := v.Archive(vcsCtx, bundle, revision, destDir)
-			if err != nil {
-				return "", fmt.Errorf("failed to archive remote bundle %s at revision %s: %w", bundle.ID, revision, err)
+	for i, bundle := range rg.bundles {
+		i, bundle := i, bundle
+		eg.Go(func() error {
+			select {
+			case sem <- struct{}{}:
+			case <-egCtx.Done():
+				return egCtx.Err()
 			}
-		}
+			defer func() {
+				<-sem
+			}()
 
-		// Run build steps inside the EXPORTED bundle directory in the generation
-		for _, step := range bundle.Build {
-			if matchOS(step.OS, runtime.GOOS) {
-				h.logger.Debugf("running build step for %s: %s", bundle.ID, step.Cmd)
-				var buildCmd *exec.Cmd
-				if runtime.GOOS == "windows" {
-					buildCmd = exec.Command("cmd", "/c", step.Cmd)
-				} else {
-					buildCmd = exec.Command("sh", "-c", step.Cmd)
-				}
-				buildCmd.Dir = destDir
-				buildCmd.Stdout = h.config.Writer
-				buildCmd.Stderr = h.config.ErrWriter
-				if err := buildCmd.Run(); err != nil {
-					return "", fmt.Errorf("failed to run build step for bundle %s on %s: %w", bundle.ID, step.OS, err)
+			if opts.OnProgress != nil {
+				opts.OnProgress(DeployProgressEvent{
+					Type:         DeployEventBundleStarted,
+					BundleID:     bundle.ID,
+					Total:        len(rg.bundles),
+					Parallelism:  parallelism,
+					GenerationID: genID,
+				})
+			}
+			timer := newPhaseTimer()
+			onPhase := func(phase Phase) {
+				timer.enter(phase)
+				if opts.OnProgress != nil {
+					opts.OnProgress(DeployProgressEvent{
+						Type:         DeployEventBundlePhase,
+						BundleID:     bundle.ID,
+						Total:        len(rg.bundles),
+						Parallelism:  parallelism,
+						Phase:        phase,
+						GenerationID: genID,
+					})
 				}
 			}
-		}
 
-		// Help tag generation for remote/exported bundle
-		docDir := filepath.Join(destDir, "doc")
-		if err := h.buildHelpTags(bundle.ID, docDir); err != nil {
-			return "", err
-		}
-	}
-
-	// Generate packadd.vim inside the generation dir
-	var packaddContent strings.Builder
-	packaddContent.WriteString(`function! s:add_rtp(path, after_path) abort
-  let l:rtps = split(&runtimepath, ',')
-  let l:idx = -1
-  for l:i in range(len(l:rtps))
-    if fnamemodify(l:rtps[l:i], ':t') ==# 'after'
-      let l:idx = l:i
-      break
-    endif
-  endfor
-  if l:idx >= 0
-    call insert(l:rtps, a:path, l:idx)
-  else
-    call add(l:rtps, a:path)
-  endif
-  if a:after_path !=# ''
-    call add(l:rtps, a:after_path)
-  endif
-  let &runtimepath = join(l:rtps, ',')
-endfunction
-
-`)
-
-	for _, bundle := range rg.bundles {
-		if bundle.Source.Type == graph.SourceTypeLocal {
-			localPath := bundle.Source.Path
-			if bundle.EnableIf != "" {
-				afterPath := filepath.Join(localPath, "after")
-				if _, err := os.Stat(afterPath); err == nil {
-					fmt.Fprintf(&packaddContent, "if %s\n  call s:add_rtp(%q, %q)\nendif\n", bundle.EnableIf, filepath.ToSlash(localPath), filepath.ToSlash(afterPath))
-				} else {
-					fmt.Fprintf(&packaddContent, "if %s\n  call s:add_rtp(%q, '')\nendif\n", bundle.EnableIf, filepath.ToSlash(localPath))
+			var output bytes.Buffer
+			record, err := h.deployOneBundle(egCtx, bundle, entries, genID, genDir, opts.BuildTimeout, onPhase, &output)
+			timer.stop()
+			if err == nil && !record.Reused && bundle.Source.Type == graph.SourceTypeRemote {
+				record.Log = filepath.ToSlash(filepath.Join("logs", getExportedBundleDirName(bundle.ID)+".log"))
+				if writeErr := os.WriteFile(filepath.Join(genDir, record.Log), output.Bytes(), 0644); writeErr != nil {
+					err = fmt.Errorf("failed to write build log of bundle %s: %w", bundle.ID, writeErr)
 				}
-			} else {
-				afterPath := filepath.Join(localPath, "after")
-				if _, err := os.Stat(afterPath); err == nil {
-					fmt.Fprintf(&packaddContent, "call s:add_rtp(%q, %q)\n", filepath.ToSlash(localPath), filepath.ToSlash(afterPath))
-				} else {
-					fmt.Fprintf(&packaddContent, "call s:add_rtp(%q, '')\n", filepath.ToSlash(localPath))
+			}
+			num := atomic.AddInt32(&completedCount, 1)
+			if err != nil {
+				if opts.OnProgress != nil {
+					opts.OnProgress(DeployProgressEvent{
+						Type:         DeployEventBundleFailed,
+						BundleID:     bundle.ID,
+						Total:        len(rg.bundles),
+						Num:          int(num),
+						Parallelism:  parallelism,
+						Err:          err,
+						Output:       output.String(),
+						GenerationID: genID,
+					})
 				}
+				return err
 			}
-		} else {
-			bundleName := getExportedBundleDirName(bundle.ID)
-			if bundle.EnableIf != "" {
-				fmt.Fprintf(&packaddContent, "if %s\n  packadd %s\nendif\n", bundle.EnableIf, bundleName)
-			} else {
-				fmt.Fprintf(&packaddContent, "packadd %s\n", bundleName)
+
+			if opts.OnProgress != nil {
+				opts.OnProgress(DeployProgressEvent{
+					Type:         DeployEventBundleCompleted,
+					BundleID:     bundle.ID,
+					Total:        len(rg.bundles),
+					Num:          int(num),
+					Parallelism:  parallelism,
+					Reused:       record.Reused,
+					GenerationID: genID,
+				})
 			}
+			record.ArchiveMillis = timer.millis(PhaseArchive)
+			record.BuildMillis = timer.millis(PhaseBuild)
+			record.HelptagsMillis = timer.millis(PhaseHelptags)
+			records[i] = record
+			return nil
+		})
+	}
+
+	if err := eg.Wait(); err != nil {
+		if opts.OnProgress != nil {
+			opts.OnProgress(DeployProgressEvent{
+				Type:         DeployEventFailed,
+				Total:        len(rg.bundles),
+				Num:          int(atomic.LoadInt32(&completedCount)),
+				Parallelism:  parallelism,
+				Err:          err,
+				GenerationID: genID,
+			})
 		}
+		return "", err
 	}
-	if err := os.WriteFile(filepath.Join(genDir, "packadd.vim"), []byte(packaddContent.String()), 0644); err != nil {
-		return "", fmt.Errorf("failed to write packadd.vim: %w", err)
+
+	if err := h.writeProjections(genDir, rg.bundles, projections); err != nil {
+		return "", err
 	}
 	h.logger.Infof("runtimepath projection generated")
-	h.logger.Debugf("generated file path: %s", filepath.Join(genDir, "packadd.vim"))
 
 	// Copy hariti.lock to lock.json
 	if err := os.WriteFile(filepath.Join(genDir, "lock.json"), lockBytes, 0644); err != nil {
@@ -203,8 +461,15 @@ endfunction
 	// Write metadata.json
 	meta := &GenerationMetadata{
 		ID:        genID,
-		CreatedAt: time.Now().Format(time.RFC3339),
+		CreatedAt: time.Now().Format(time.RFC3339Nano),
 		LockHash:  fmt.Sprintf("%x", hash),
+		Identity:  identity,
+
+		HaritiVersion: h.config.Version,
+		VimVersion:    h.vimVersion(ctx),
+		Graph:         &graph.Graph{Bundles: rg.bundles},
+		Bundles:       records,
+		Projections:   projections,
 	}
 	metaBytes, err := json.MarshalIndent(meta, "", "  ")
 	if err != nil {
@@ -214,6 +479,99 @@ endfunction
 		return "", fmt.Errorf("failed to write metadata.json: %w", err)
 	}
 
+	if err := h.validateStagedGeneration(rg, genDir, projections); err != nil {
+		return "", err
+	}
+	if err := h.publishGeneration(genID); err != nil {
+		return "", err
+	}
+	published = true
+	h.logger.Infof("generation created: %s", genID)
+
+	if err := h.switchCurrentGeneration(genID); err != nil {
+		return "", err
+	}
+	h.logger.Infof("current generation switched to: %s", genID)
+	if opts.OnProgress != nil {
+		opts.OnProgress(DeployProgressEvent{
+			Type:         DeployEventCompleted,
+			Total:        len(rg.bundles),
+			Num:          len(rg.bundles),
+			Parallelism:  parallelism,
+			GenerationID: genID,
+		})
+	}
+
+	return genID, nil
+}
+
+// deployOneBundle puts a single bundle into the generation and returns how
+// it did. Local bundles only get their help tags generated; remote bundles are
+// linked from the store, building the store entry first when needed. Output of
+// VCS commands and build steps is written to output.
+func (h *Hariti) deployOneBundle(ctx context.Context, bundle graph.Bundle, entries map[string]LockfileEntry, genID, genDir string, buildTimeout time.Duration, onPhase func(Phase), output io.Writer) (GenerationBundle, error) {
+	record := GenerationBundle{ID: bundle.ID}
+	if bundle.Source.Type == graph.SourceTypeLocal {
+		h.logger.Debugf("local bundle %s: skipped folder creation and copying", bundle.ID)
+		onPhase(PhaseHelptags)
+		docDir := filepath.Join(bundle.Source.Path, "doc")
+		return record, h.buildHelpTags(ctx, bundle.ID, docDir)
+	}
+
+	// Remote source: Link the bundle built at the revision in lockfile
+	entry, exists := entries[bundle.ID]
+	revision := entry.Revision
+	if !exists || revision == "" {
+		return record, fmt.Errorf("no revision found in lockfile for remote bundle %s", bundle.ID)
+	}
+	h.logger.Debugf("resolved revision for bundle %s to %s", bundle.ID, revision)
+
+	v := vcs.Detect(bundle.Source.URL)
+	if v == nil {
+		return record, fmt.Errorf("failed to detect VCS for remote bundle %s", bundle.ID)
+	}
+
+	vcsCtx := vcs.WithLogger(ctx, h.logger)
+	vcsCtx = vcs.WithWriter(vcsCtx, output)
+	vcsCtx = vcs.WithErrWriter(vcsCtx, output)
+	key, bundleDir, manifest, reused, err := h.ensureStoreEntry(vcsCtx, v, bundle, entry, buildTimeout, onPhase, output)
+	if err != nil {
+		return record, err
+	}
+
+	destDir := filepath.Join(genDir, "pack", "hariti", "opt", getExportedBundleDirName(bundle.ID))
+	h.logger.Debugf("resolved repository path for bundle %s to %s", bundle.ID, destDir)
+	if err := h.linkStoreEntry(key, bundleDir, genID, destDir); err != nil {
+		return record, err
+	}
+	record.Reused = reused
+	record.BuildSteps = manifest.BuildSteps
+	return record, nil
+}
+
+// currentGenerationWithIdentity returns the ID of the current generation if
+// it has the given identity, or an empty string otherwise.
+func (h *Hariti) currentGenerationWithIdentity(identity string) (string, error) {
+	currentID, err := h.CurrentGeneration()
+	if err != nil || currentID == "" {
+		return "", err
+	}
+	meta, err := h.readGenerationMetadata(currentID)
+	if err != nil {
+		if errors.Is(err, ErrGenerationNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+	if meta.Identity != identity {
+		return "", nil
+	}
+	return currentID, nil
+}
+
+// switchCurrentGeneration atomically points the current link at the given
+// generation.
+func (h *Hariti) switchCurrentGeneration(genID string) error {
 	// Switch current symlink atomically using temporary symlink and rename
 	currentPath := h.CurrentSymlinkPath()
 	tempSymlink := filepath.Join(filepath.Dir(currentPath), "current.tmp")
@@ -222,22 +580,71 @@ endfunction
 	// Point to the relative or absolute target
 	targetPath := filepath.Join("generations", genID)
 	if err := createGenerationLink(targetPath, tempSymlink); err != nil {
-		return "", fmt.Errorf("failed to create temporary symlink: %w", err)
+		return fmt.Errorf("failed to create temporary symlink: %w", err)
 	}
 
 	if err := os.Rename(tempSymlink, currentPath); err != nil {
 		// Fallback for environments where atomic rename of symlink has OS constraints
 		_ = os.Remove(currentPath)
 		if err := createGenerationLink(targetPath, currentPath); err != nil {
-			return "", fmt.Errorf("failed to switch current symlink: %w", err)
+			return fmt.Errorf("failed to switch current symlink: %w", err)
 		}
 	}
-	h.logger.Infof("current generation switched to: %s", genID)
+	return nil
+}
 
-	return genID, nil
+// runBuildSteps runs the build steps of a bundle that match this OS inside
+// destDir and returns their results. A failed step ends the build with a
+// *BuildError, and its result is written to output as well.
+func (h *Hariti) runBuildSteps(ctx context.Context, bundle graph.Bundle, destDir string, timeout time.Duration, output io.Writer) ([]BuildStepResult, error) {
+	buildCtx, cancel := withBundleTimeout(ctx, timeout)
+	defer cancel()
+
+	var results []BuildStepResult
+
+	for _, step := range bundle.Build {
+		if matchOS(step.OS, runtime.GOOS) {
+			h.logger.Debugf("running build step for %s: %s", bundle.ID, step.Cmd)
+			var buildCmd *exec.Cmd
+			if runtime.GOOS == "windows" {
+				buildCmd = exec.CommandContext(buildCtx, "cmd", "/c", step.Cmd)
+			} else {
+				buildCmd = exec.CommandContext(buildCtx, "sh", "-c", step.Cmd)
+			}
+			buildCmd.Dir = destDir
+			buildCmd.Stdout = output
+			buildCmd.Stderr = output
+			buildCmd.WaitDelay = buildWaitDelay
+			configureBuildCmd(buildCmd)
+			start := time.Now()
+			err := buildCmd.Run()
+			result := BuildStepResult{
+				OS:             step.OS,
+				Cmd:            step.Cmd,
+				ExitCode:       -1,
+				DurationMillis: time.Since(start).Milliseconds(),
+			}
+			if buildCmd.ProcessState != nil {
+				result.ExitCode = buildCmd.ProcessState.ExitCode()
+			}
+			results = append(results, result)
+			if err != nil {
+				fmt.Fprintf(output, "\nbuild step %q failed with exit code %d after %dms\n", step.Cmd, result.ExitCode, result.DurationMillis)
+				if isBundleTimeout(buildCtx) {
+					err = &TimeoutError{
+						BundleID: bundle.ID,
+						Phase:    PhaseBuild,
+						Timeout:  timeout,
+					}
+				}
+				return nil, &BuildError{BundleID: bundle.ID, Steps: results, Err: err}
+			}
+		}
+	}
+	return results, nil
 }
 
-func (h *Hariti) buildHelpTags(bundleID, docDir string) error {
+func (h *Hariti) buildHelpTags(ctx context.Context, bundleID, docDir string) error {
 	info, err := os.Stat(docDir)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -254,9 +661,21 @@ func (h *Hariti) buildHelpTags(bundleID, docDir string) error {
 
 	h.logger.Debugf("generating help tags for bundle %s inside %s", bundleID, docDir)
 	escapedCmd := fmt.Sprintf("execute 'helptags' fnameescape(%q)", filepath.ToSlash(docDir))
-	cmd := exec.Command("vim", "-Nu", "NONE", "-n", "-e", "-s", "-c", escapedCmd, "-c", "qa!")
+	cmd := exec.CommandContext(ctx, "vim", "-Nu", "NONE", "-n", "-e", "-s", "-c", escapedCmd, "-c", "qa!")
 	if out, err := cmd.CombinedOutput(); err != nil {
 		return fmt.Errorf("failed to generate help tags for bundle %s inside %s: %w (output: %s)", bundleID, docDir, err, string(out))
 	}
 	return nil
 }
+
+// vimVersion returns the first line of `vim --version`, or an empty string
+// when Vim cannot be run.
+func (h *Hariti) vimVersion(ctx context.Context) string {
+	out, err := exec.CommandContext(ctx, "vim", "--version").Output()
+	if err != nil {
+		h.logger.Debugf("failed to detect vim version: %v", err)
+		return ""
+	}
+	line, _, _ := strings.Cut(string(out), "\n")
+	return strings.TrimSpace(line)
+}
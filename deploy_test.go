@@ -3,13 +3,18 @@ package hariti_test
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/kamichidu/go-hariti"
 	"github.com/kamichidu/go-hariti/graph"
@@ -273,6 +278,1330 @@ func TestHariti_Deploy_Failure_BuildStep(t *testing.T) {
 	if !strings.Contains(err.Error(), "failed to run build step for bundle my/remote-plugin on all") {
 		t.Errorf("expected error message to contain build step failure detail, got: %v", err)
 	}
+	var buildErr *hariti.BuildError
+	if !errors.As(err, &buildErr) {
+		t.Fatalf("expected a *hariti.BuildError, got %T: %v", err, err)
+	}
+	if len(buildErr.Steps) == 0 {
+		t.Fatal("expected the failed build step to be recorded")
+	}
+	if failed := buildErr.Steps[len(buildErr.Steps)-1]; failed.ExitCode != 1 {
+		t.Errorf("expected the failed build step to exit with 1, got %d", failed.ExitCode)
+	}
+}
+
+func TestHariti_Deploy_Failure_BuildTimeout(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	xdgHome := filepath.Join(tmpDir, "xdg_home")
+	_ = os.Setenv("XDG_DATA_HOME", xdgHome)
+	defer func() {
+		_ = os.Unsetenv("XDG_DATA_HOME")
+	}()
+
+	// 1. Setup mock remote repository with a build step that never finishes in time
+	remoteRepoDir := filepath.Join(tmpDir, "remote_repo")
+	if err := os.MkdirAll(remoteRepoDir, 0755); err != nil {
+		t.Fatalf("failed to create mock remote dir: %v", err)
+	}
+
+	_ = runGitCmdInDir(t, remoteRepoDir, "init")
+	_ = runGitCmdInDir(t, remoteRepoDir, "config", "user.email", "test@hariti.io")
+	_ = runGitCmdInDir(t, remoteRepoDir, "config", "user.name", "Test Hariti")
+	_ = runGitCmdInDir(t, remoteRepoDir, "commit", "--allow-empty", "-m", "initial commit")
+
+	remoteURL, err := url.Parse("file://" + filepath.ToSlash(remoteRepoDir))
+	if err != nil {
+		t.Fatalf("failed to parse remote URL: %v", err)
+	}
+
+	g := &graph.Graph{
+		Bundles: []graph.Bundle{
+			{
+				ID: "my/slow-plugin",
+				Source: graph.Source{
+					Type: graph.SourceTypeRemote,
+					URL:  remoteURL,
+					Path: filepath.Join(xdgHome, "hariti", "repos", url.QueryEscape("my/slow-plugin")),
+				},
+				Build: []graph.BuildStep{
+					{
+						OS:  "all",
+						Cmd: "sleep 10",
+					},
+				},
+			},
+		},
+	}
+
+	cfg := &hariti.HaritiConfig{
+		Paths: hariti.Paths{
+			ConfigFile: filepath.Join(tmpDir, "hariti_home", "bundles.hariti"),
+			ConfigDir:  filepath.Join(tmpDir, "hariti_home"),
+			DataDir:    filepath.Join(xdgHome, "hariti"),
+		},
+		Writer:    io.Discard,
+		ErrWriter: io.Discard,
+	}
+	har := hariti.NewHariti(cfg)
+
+	ctx := context.Background()
+	ctx = vcs.WithWriter(ctx, io.Discard)
+	ctx = vcs.WithErrWriter(ctx, io.Discard)
+
+	if _, err := har.Sync(ctx, g, hariti.SyncOptions{}); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	// Step 2: Deploy must give up on the build step after the timeout
+	start := time.Now()
+	_, err = har.Deploy(ctx, g, hariti.DeployOptions{BuildTimeout: 100 * time.Millisecond})
+	var timeoutErr *hariti.TimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("expected *hariti.TimeoutError, got: %v", err)
+	}
+	if timeoutErr.BundleID != "my/slow-plugin" || timeoutErr.Phase != hariti.PhaseBuild {
+		t.Errorf("unexpected timeout error: %+v", timeoutErr)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("expected build step to be cancelled, but Deploy took %s", elapsed)
+	}
+}
+
+func TestHariti_Deploy_Failure_Staging(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	xdgHome := filepath.Join(tmpDir, "xdg_home")
+	_ = os.Setenv("XDG_DATA_HOME", xdgHome)
+	defer func() {
+		_ = os.Unsetenv("XDG_DATA_HOME")
+	}()
+
+	remoteRepoDir := filepath.Join(tmpDir, "remote_repo")
+	if err := os.MkdirAll(remoteRepoDir, 0755); err != nil {
+		t.Fatalf("failed to create mock remote dir: %v", err)
+	}
+	_ = runGitCmdInDir(t, remoteRepoDir, "init")
+	_ = runGitCmdInDir(t, remoteRepoDir, "config", "user.email", "test@hariti.io")
+	_ = runGitCmdInDir(t, remoteRepoDir, "config", "user.name", "Test Hariti")
+	_ = runGitCmdInDir(t, remoteRepoDir, "commit", "--allow-empty", "-m", "initial commit")
+
+	remoteURL, err := url.Parse("file://" + filepath.ToSlash(remoteRepoDir))
+	if err != nil {
+		t.Fatalf("failed to parse remote URL: %v", err)
+	}
+
+	g := &graph.Graph{
+		Bundles: []graph.Bundle{
+			{
+				ID: "my/remote-plugin",
+				Source: graph.Source{
+					Type: graph.SourceTypeRemote,
+					URL:  remoteURL,
+					Path: filepath.Join(xdgHome, "hariti", "repos", url.QueryEscape("my/remote-plugin")),
+				},
+			},
+		},
+	}
+
+	cfg := &hariti.HaritiConfig{
+		Paths: hariti.Paths{
+			ConfigFile: filepath.Join(tmpDir, "hariti_home", "bundles.hariti"),
+			ConfigDir:  filepath.Join(tmpDir, "hariti_home"),
+			DataDir:    filepath.Join(xdgHome, "hariti"),
+		},
+		Writer:    io.Discard,
+		ErrWriter: io.Discard,
+	}
+	har := hariti.NewHariti(cfg)
+
+	ctx := context.Background()
+	ctx = vcs.WithWriter(ctx, io.Discard)
+	ctx = vcs.WithErrWriter(ctx, io.Discard)
+
+	if _, err := har.Sync(ctx, g, hariti.SyncOptions{}); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	firstID, err := har.Deploy(ctx, g, hariti.DeployOptions{})
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+
+	listDir := func(dir string) []string {
+		t.Helper()
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", dir, err)
+		}
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		return names
+	}
+
+	// Step 1: A staging leftover that looks like a generation stays invisible
+	leftoverID := "20200101-000000-deadbeef"
+	leftoverDir := filepath.Join(har.StagingDir(), leftoverID)
+	if err := os.MkdirAll(leftoverDir, 0755); err != nil {
+		t.Fatalf("failed to create staging leftover: %v", err)
+	}
+	for _, name := range []string{"metadata.json", "lock.json", "packadd.vim"} {
+		data, err := os.ReadFile(filepath.Join(har.GenerationsDir(), firstID, name))
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", name, err)
+		}
+		if err := os.WriteFile(filepath.Join(leftoverDir, name), data, 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	generations, err := har.Generations()
+	if err != nil {
+		t.Fatalf("Generations failed: %v", err)
+	}
+	if len(generations) != 1 || generations[0].ID != firstID {
+		t.Errorf("expected only generation %s to be listed, got %+v", firstID, generations)
+	}
+	if _, err := har.Rollback(leftoverID, hariti.RollbackOptions{}); !errors.Is(err, hariti.ErrGenerationNotFound) {
+		t.Errorf("expected rollback to a staging leftover to fail with ErrGenerationNotFound, got: %v", err)
+	}
+
+	// Step 2: A failing deploy publishes nothing and cleans up staging
+	g.Bundles[0].Build = []graph.BuildStep{{OS: "all", Cmd: "exit 1"}}
+	if _, err := har.Deploy(ctx, g, hariti.DeployOptions{}); err == nil {
+		t.Fatal("expected failing build step to fail the deploy, got nil")
+	}
+	if names := listDir(har.GenerationsDir()); len(names) != 1 || names[0] != firstID {
+		t.Errorf("expected only generation %s after the failed deploy, got %v", firstID, names)
+	}
+	if names := listDir(har.StagingDir()); len(names) != 0 {
+		t.Errorf("expected empty staging directory after the failed deploy, got %v", names)
+	}
+	if current, _ := har.CurrentGeneration(); current != firstID {
+		t.Errorf("expected current to stay at %s, got %s", firstID, current)
+	}
+
+	// Step 3: A successful deploy is published out of staging
+	g.Bundles[0].Build = nil
+	secondID, err := har.Deploy(ctx, g, hariti.DeployOptions{Force: true})
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(har.GenerationsDir(), secondID, "metadata.json")); err != nil {
+		t.Errorf("expected generation %s to be published, got error: %v", secondID, err)
+	}
+	if names := listDir(har.StagingDir()); len(names) != 0 {
+		t.Errorf("expected empty staging directory after the deploy, got %v", names)
+	}
+}
+
+func TestHariti_Deploy_Failure_IntegrityMismatch(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	xdgHome := filepath.Join(tmpDir, "xdg_home")
+	_ = os.Setenv("XDG_DATA_HOME", xdgHome)
+	defer func() {
+		_ = os.Unsetenv("XDG_DATA_HOME")
+	}()
+
+	// 1. Setup mock remote repository with a plugin file
+	remoteRepoDir := filepath.Join(tmpDir, "remote_repo")
+	if err := os.MkdirAll(filepath.Join(remoteRepoDir, "plugin"), 0755); err != nil {
+		t.Fatalf("failed to create mock remote dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(remoteRepoDir, "plugin", "remote.vim"), []byte("echo 'remote'\n"), 0644); err != nil {
+		t.Fatalf("failed to write plugin file: %v", err)
+	}
+
+	_ = runGitCmdInDir(t, remoteRepoDir, "init")
+	_ = runGitCmdInDir(t, remoteRepoDir, "config", "user.email", "test@hariti.io")
+	_ = runGitCmdInDir(t, remoteRepoDir, "config", "user.name", "Test Hariti")
+	_ = runGitCmdInDir(t, remoteRepoDir, "add", ".")
+	_ = runGitCmdInDir(t, remoteRepoDir, "commit", "-m", "initial commit")
+	expectedTree := runGitCmdInDir(t, remoteRepoDir, "rev-parse", "HEAD^{tree}")
+
+	remoteURL, err := url.Parse("file://" + filepath.ToSlash(remoteRepoDir))
+	if err != nil {
+		t.Fatalf("failed to parse remote URL: %v", err)
+	}
+
+	g := &graph.Graph{
+		Bundles: []graph.Bundle{
+			{
+				ID: "my/remote-plugin",
+				Source: graph.Source{
+					Type: graph.SourceTypeRemote,
+					URL:  remoteURL,
+					Path: filepath.Join(xdgHome, "hariti", "repos", url.QueryEscape("my/remote-plugin")),
+				},
+			},
+		},
+	}
+
+	cfg := &hariti.HaritiConfig{
+		Paths: hariti.Paths{
+			ConfigFile: filepath.Join(tmpDir, "hariti_home", "bundles.hariti"),
+			ConfigDir:  filepath.Join(tmpDir, "hariti_home"),
+			DataDir:    filepath.Join(xdgHome, "hariti"),
+		},
+		Writer:    io.Discard,
+		ErrWriter: io.Discard,
+	}
+	har := hariti.NewHariti(cfg)
+
+	ctx := context.Background()
+	ctx = vcs.WithWriter(ctx, io.Discard)
+	ctx = vcs.WithErrWriter(ctx, io.Discard)
+
+	// Step 1: Sync records the tree and content hashes
+	if _, err := har.Sync(ctx, g, hariti.SyncOptions{}); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	readLock := func() hariti.Lockfile {
+		data, err := os.ReadFile(har.LockfilePath())
+		if err != nil {
+			t.Fatalf("failed to read lockfile: %v", err)
+		}
+		var lock hariti.Lockfile
+		if err := json.Unmarshal(data, &lock); err != nil {
+			t.Fatalf("failed to parse lockfile: %v", err)
+		}
+		return lock
+	}
+	writeLock := func(lock hariti.Lockfile) {
+		data, err := json.MarshalIndent(&lock, "", "  ")
+		if err != nil {
+			t.Fatalf("failed to serialize lockfile: %v", err)
+		}
+		if err := os.WriteFile(har.LockfilePath(), data, 0644); err != nil {
+			t.Fatalf("failed to write lockfile: %v", err)
+		}
+	}
+	lock := readLock()
+	entry := lock.Bundles[0]
+	if entry.Tree != expectedTree {
+		t.Errorf("expected locked tree %s, got %s", expectedTree, entry.Tree)
+	}
+	if !strings.HasPrefix(entry.ContentHash, "sha256:") {
+		t.Errorf("expected sha256 content hash, got %q", entry.ContentHash)
+	}
+
+	// Step 2: Untampered lockfiles deploy
+	if _, err := har.Deploy(ctx, g, hariti.DeployOptions{}); err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+	currentTarget, err := os.Readlink(har.CurrentSymlinkPath())
+	if err != nil {
+		t.Fatalf("failed to read current symlink: %v", err)
+	}
+
+	// Step 3: A content hash mismatch is refused and keeps the current generation
+	tampered := lock
+	tampered.Bundles = []hariti.LockfileEntry{entry}
+	tampered.Bundles[0].ContentHash = "sha256:0000"
+	writeLock(tampered)
+	_, err = har.Deploy(ctx, g, hariti.DeployOptions{})
+	var integrityErr *hariti.IntegrityError
+	if !errors.As(err, &integrityErr) {
+		t.Fatalf("expected *hariti.IntegrityError, got: %v", err)
+	}
+	if integrityErr.BundleID != "my/remote-plugin" || integrityErr.Kind != hariti.HashKindContent || integrityErr.Actual != entry.ContentHash {
+		t.Errorf("unexpected integrity error: %+v", integrityErr)
+	}
+	if target, _ := os.Readlink(har.CurrentSymlinkPath()); target != currentTarget {
+		t.Errorf("expected current to stay at %s, got %s", currentTarget, target)
+	}
+
+	// Step 4: A tree hash mismatch is refused
+	tampered.Bundles[0] = entry
+	tampered.Bundles[0].Tree = "0000000000000000000000000000000000000000"
+	writeLock(tampered)
+	_, err = har.Deploy(ctx, g, hariti.DeployOptions{})
+	if !errors.As(err, &integrityErr) || integrityErr.Kind != hariti.HashKindTree {
+		t.Fatalf("expected tree *hariti.IntegrityError, got: %v", err)
+	}
+
+	// Step 5: Lockfiles written before hashes were recorded still deploy
+	tampered.Bundles[0] = entry
+	tampered.Bundles[0].Tree = ""
+	tampered.Bundles[0].ContentHash = ""
+	writeLock(tampered)
+	if _, err := har.Deploy(ctx, g, hariti.DeployOptions{}); err != nil {
+		t.Fatalf("Deploy of lockfile without hashes failed: %v", err)
+	}
+
+	// Step 6: A store entry edited in place is refused on reuse, even though
+	// its manifest still matches the lockfile
+	writeLock(lock)
+	currentTarget, err = os.Readlink(har.CurrentSymlinkPath())
+	if err != nil {
+		t.Fatalf("failed to read current symlink: %v", err)
+	}
+	storeFiles, err := filepath.Glob(filepath.Join(har.StoreDir(), "*", "bundle", "plugin", "remote.vim"))
+	if err != nil || len(storeFiles) != 1 {
+		t.Fatalf("expected one store entry holding plugin/remote.vim, got %v (err %v)", storeFiles, err)
+	}
+	if err := os.WriteFile(storeFiles[0], []byte("echo 'tampered'\n"), 0644); err != nil {
+		t.Fatalf("failed to tamper with store entry: %v", err)
+	}
+	_, err = har.Deploy(ctx, g, hariti.DeployOptions{Force: true})
+	if !errors.As(err, &integrityErr) || integrityErr.Kind != hariti.HashKindBuilt {
+		t.Fatalf("expected built *hariti.IntegrityError, got: %v", err)
+	}
+	if target, _ := os.Readlink(har.CurrentSymlinkPath()); target != currentTarget {
+		t.Errorf("expected current to stay at %s, got %s", currentTarget, target)
+	}
+}
+
+func TestHariti_Deploy_SkipUnchanged(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	xdgHome := filepath.Join(tmpDir, "xdg_home")
+	_ = os.Setenv("XDG_DATA_HOME", xdgHome)
+	defer func() {
+		_ = os.Unsetenv("XDG_DATA_HOME")
+	}()
+
+	remoteRepoDir := filepath.Join(tmpDir, "remote_repo")
+	if err := os.MkdirAll(remoteRepoDir, 0755); err != nil {
+		t.Fatalf("failed to create mock remote dir: %v", err)
+	}
+	_ = runGitCmdInDir(t, remoteRepoDir, "init")
+	_ = runGitCmdInDir(t, remoteRepoDir, "config", "user.email", "test@hariti.io")
+	_ = runGitCmdInDir(t, remoteRepoDir, "config", "user.name", "Test Hariti")
+	_ = runGitCmdInDir(t, remoteRepoDir, "commit", "--allow-empty", "-m", "initial commit")
+
+	remoteURL, err := url.Parse("file://" + filepath.ToSlash(remoteRepoDir))
+	if err != nil {
+		t.Fatalf("failed to parse remote URL: %v", err)
+	}
+
+	g := &graph.Graph{
+		Bundles: []graph.Bundle{
+			{
+				ID: "my/remote-plugin",
+				Source: graph.Source{
+					Type: graph.SourceTypeRemote,
+					URL:  remoteURL,
+					Path: filepath.Join(xdgHome, "hariti", "repos", url.QueryEscape("my/remote-plugin")),
+				},
+			},
+		},
+	}
+
+	cfg := &hariti.HaritiConfig{
+		Paths: hariti.Paths{
+			ConfigFile: filepath.Join(tmpDir, "hariti_home", "bundles.hariti"),
+			ConfigDir:  filepath.Join(tmpDir, "hariti_home"),
+			DataDir:    filepath.Join(xdgHome, "hariti"),
+		},
+		Writer:    io.Discard,
+		ErrWriter: io.Discard,
+	}
+	har := hariti.NewHariti(cfg)
+
+	ctx := context.Background()
+	ctx = vcs.WithWriter(ctx, io.Discard)
+	ctx = vcs.WithErrWriter(ctx, io.Discard)
+
+	if _, err := har.Sync(ctx, g, hariti.SyncOptions{}); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	countGenerations := func() int {
+		t.Helper()
+		entries, err := os.ReadDir(har.GenerationsDir())
+		if err != nil {
+			t.Fatalf("failed to read generations directory: %v", err)
+		}
+		return len(entries)
+	}
+
+	// Step 1: The first deploy creates a generation
+	firstID, err := har.Deploy(ctx, g, hariti.DeployOptions{})
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+
+	// Step 2: An identical deploy returns the current generation
+	genID, err := har.Deploy(ctx, g, hariti.DeployOptions{})
+	if err != nil {
+		t.Fatalf("second Deploy failed: %v", err)
+	}
+	if genID != firstID {
+		t.Errorf("expected unchanged deploy to return %s, got %s", firstID, genID)
+	}
+	if n := countGenerations(); n != 1 {
+		t.Errorf("expected 1 generation, got %d", n)
+	}
+
+	// Step 3: --force deploys a new generation next to the current one
+	forcedID, err := har.Deploy(ctx, g, hariti.DeployOptions{Force: true})
+	if err != nil {
+		t.Fatalf("forced Deploy failed: %v", err)
+	}
+	if forcedID == firstID {
+		t.Errorf("expected forced deploy to create a new generation, got %s", forcedID)
+	}
+	if n := countGenerations(); n != 2 {
+		t.Errorf("expected 2 generations, got %d", n)
+	}
+
+	// Step 4: Deploy-relevant graph fields are part of the identity
+	changes := []func(b *graph.Bundle){
+		func(b *graph.Bundle) { b.EnableIf = "has('nvim')" },
+		func(b *graph.Bundle) { b.Build = []graph.BuildStep{{OS: "all", Cmd: "true"}} },
+	}
+	previousID := forcedID
+	for i, change := range changes {
+		change(&g.Bundles[0])
+		genID, err := har.Deploy(ctx, g, hariti.DeployOptions{})
+		if err != nil {
+			t.Fatalf("Deploy after change %d failed: %v", i, err)
+		}
+		if genID == previousID {
+			t.Errorf("expected change %d to create a new generation", i)
+		}
+		previousID = genID
+	}
+	if n := countGenerations(); n != 2+len(changes) {
+		t.Errorf("expected %d generations, got %d", 2+len(changes), n)
+	}
+}
+
+func TestHariti_Deploy_Store(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	xdgHome := filepath.Join(tmpDir, "xdg_home")
+	_ = os.Setenv("XDG_DATA_HOME", xdgHome)
+	defer func() {
+		_ = os.Unsetenv("XDG_DATA_HOME")
+	}()
+
+	remoteRepoDir := filepath.Join(tmpDir, "remote_repo")
+	if err := os.MkdirAll(remoteRepoDir, 0755); err != nil {
+		t.Fatalf("failed to create mock remote dir: %v", err)
+	}
+	_ = runGitCmdInDir(t, remoteRepoDir, "init")
+	_ = runGitCmdInDir(t, remoteRepoDir, "config", "user.email", "test@hariti.io")
+	_ = runGitCmdInDir(t, remoteRepoDir, "config", "user.name", "Test Hariti")
+	_ = runGitCmdInDir(t, remoteRepoDir, "commit", "--allow-empty", "-m", "initial commit")
+
+	remoteURL, err := url.Parse("file://" + filepath.ToSlash(remoteRepoDir))
+	if err != nil {
+		t.Fatalf("failed to parse remote URL: %v", err)
+	}
+
+	// Every build appends a line to the counter file outside the store
+	counterFile := filepath.Join(tmpDir, "builds.txt")
+	g := &graph.Graph{
+		Bundles: []graph.Bundle{
+			{
+				ID: "my/remote-plugin",
+				Source: graph.Source{
+					Type: graph.SourceTypeRemote,
+					URL:  remoteURL,
+					Path: filepath.Join(xdgHome, "hariti", "repos", url.QueryEscape("my/remote-plugin")),
+				},
+				Build: []graph.BuildStep{
+					{OS: "all", Cmd: fmt.Sprintf("echo built > build_output.txt && echo x >> %q", counterFile)},
+				},
+			},
+		},
+	}
+
+	cfg := &hariti.HaritiConfig{
+		Paths: hariti.Paths{
+			ConfigFile: filepath.Join(tmpDir, "hariti_home", "bundles.hariti"),
+			ConfigDir:  filepath.Join(tmpDir, "hariti_home"),
+			DataDir:    filepath.Join(xdgHome, "hariti"),
+		},
+		Writer:    io.Discard,
+		ErrWriter: io.Discard,
+	}
+	har := hariti.NewHariti(cfg)
+
+	ctx := context.Background()
+	ctx = vcs.WithWriter(ctx, io.Discard)
+	ctx = vcs.WithErrWriter(ctx, io.Discard)
+
+	if _, err := har.Sync(ctx, g, hariti.SyncOptions{}); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	countBuilds := func() int {
+		t.Helper()
+		data, err := os.ReadFile(counterFile)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return 0
+			}
+			t.Fatalf("failed to read counter file: %v", err)
+		}
+		return strings.Count(string(data), "x")
+	}
+	storeEntries := func() []string {
+		t.Helper()
+		entries, err := os.ReadDir(har.StoreDir())
+		if err != nil {
+			t.Fatalf("failed to read store directory: %v", err)
+		}
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		return names
+	}
+	resolveExport := func(genID string) string {
+		t.Helper()
+		exportPath := filepath.Join(har.GenerationsDir(), genID, "pack", "hariti", "opt", "my_remote-plugin")
+		info, err := os.Lstat(exportPath)
+		if err != nil {
+			t.Fatalf("failed to inspect exported bundle: %v", err)
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			t.Fatalf("expected %s to link into the store", exportPath)
+		}
+		resolved, err := filepath.EvalSymlinks(exportPath)
+		if err != nil {
+			t.Fatalf("failed to resolve exported bundle: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(resolved, "build_output.txt")); err != nil {
+			t.Errorf("expected build output in the linked store entry, got error: %v", err)
+		}
+		return resolved
+	}
+
+	// Step 1: Two generations of the same revision share one build
+	firstID, err := har.Deploy(ctx, g, hariti.DeployOptions{})
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+	secondID, err := har.Deploy(ctx, g, hariti.DeployOptions{Force: true})
+	if err != nil {
+		t.Fatalf("forced Deploy failed: %v", err)
+	}
+	if n := countBuilds(); n != 1 {
+		t.Errorf("expected 1 build, got %d", n)
+	}
+	if first, second := resolveExport(firstID), resolveExport(secondID); first != second {
+		t.Errorf("expected both generations to link %s, got %s", first, second)
+	}
+	entries := storeEntries()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 store entry, got %v", entries)
+	}
+	refs, err := os.ReadDir(filepath.Join(har.StoreDir(), entries[0], "refs"))
+	if err != nil {
+		t.Fatalf("failed to read store references: %v", err)
+	}
+	if len(refs) != 2 {
+		t.Errorf("expected 2 references to the store entry, got %d", len(refs))
+	}
+
+	// Step 2: Changed build steps build a new entry
+	g.Bundles[0].Build[0].Cmd += " && echo changed > changed.txt"
+	genID, err := har.Deploy(ctx, g, hariti.DeployOptions{})
+	if err != nil {
+		t.Fatalf("Deploy after build change failed: %v", err)
+	}
+	if n := countBuilds(); n != 2 {
+		t.Errorf("expected 2 builds, got %d", n)
+	}
+	if _, err := os.Stat(filepath.Join(resolveExport(genID), "changed.txt")); err != nil {
+		t.Errorf("expected output of the changed build step, got error: %v", err)
+	}
+	if entries := storeEntries(); len(entries) != 2 {
+		t.Errorf("expected 2 store entries, got %v", entries)
+	}
+
+	// Step 3: A failing build publishes nothing
+	g.Bundles[0].Build[0].Cmd = "exit 1"
+	if _, err := har.Deploy(ctx, g, hariti.DeployOptions{}); err == nil {
+		t.Fatal("expected failing build step to fail the deploy, got nil")
+	}
+	if entries := storeEntries(); len(entries) != 2 {
+		t.Errorf("expected failed build to leave the store untouched, got %v", entries)
+	}
+}
+
+func TestHariti_Deploy_Metadata(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	xdgHome := filepath.Join(tmpDir, "xdg_home")
+	_ = os.Setenv("XDG_DATA_HOME", xdgHome)
+	defer func() {
+		_ = os.Unsetenv("XDG_DATA_HOME")
+	}()
+
+	remoteRepoDir := filepath.Join(tmpDir, "remote_repo")
+	if err := os.MkdirAll(remoteRepoDir, 0755); err != nil {
+		t.Fatalf("failed to create mock remote dir: %v", err)
+	}
+	_ = runGitCmdInDir(t, remoteRepoDir, "init")
+	_ = runGitCmdInDir(t, remoteRepoDir, "config", "user.email", "test@hariti.io")
+	_ = runGitCmdInDir(t, remoteRepoDir, "config", "user.name", "Test Hariti")
+	_ = runGitCmdInDir(t, remoteRepoDir, "commit", "--allow-empty", "-m", "initial commit")
+
+	remoteURL, err := url.Parse("file://" + filepath.ToSlash(remoteRepoDir))
+	if err != nil {
+		t.Fatalf("failed to parse remote URL: %v", err)
+	}
+
+	g := &graph.Graph{
+		Bundles: []graph.Bundle{
+			{
+				ID: "my/remote-plugin",
+				Source: graph.Source{
+					Type: graph.SourceTypeRemote,
+					URL:  remoteURL,
+					Path: filepath.Join(xdgHome, "hariti", "repos", url.QueryEscape("my/remote-plugin")),
+				},
+				Build: []graph.BuildStep{
+					{OS: "all", Cmd: "echo build-stdout; echo build-stderr >&2"},
+				},
+			},
+		},
+	}
+
+	cfg := &hariti.HaritiConfig{
+		Paths: hariti.Paths{
+			ConfigFile: filepath.Join(tmpDir, "hariti_home", "bundles.hariti"),
+			ConfigDir:  filepath.Join(tmpDir, "hariti_home"),
+			DataDir:    filepath.Join(xdgHome, "hariti"),
+		},
+		Writer:    io.Discard,
+		ErrWriter: io.Discard,
+		Version:   "v1.2.3-test",
+	}
+	har := hariti.NewHariti(cfg)
+
+	ctx := context.Background()
+	ctx = vcs.WithWriter(ctx, io.Discard)
+	ctx = vcs.WithErrWriter(ctx, io.Discard)
+
+	if _, err := har.Sync(ctx, g, hariti.SyncOptions{}); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	readMetadata := func(genID string) hariti.GenerationMetadata {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(har.GenerationsDir(), genID, "metadata.json"))
+		if err != nil {
+			t.Fatalf("failed to read metadata.json: %v", err)
+		}
+		var meta hariti.GenerationMetadata
+		if err := json.Unmarshal(data, &meta); err != nil {
+			t.Fatalf("failed to parse metadata.json: %v", err)
+		}
+		return meta
+	}
+
+	// Step 1: A deploy that builds the bundle records the build and its log
+	firstID, err := har.Deploy(ctx, g, hariti.DeployOptions{})
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+	meta := readMetadata(firstID)
+	if meta.HaritiVersion != "v1.2.3-test" {
+		t.Errorf("expected hariti version v1.2.3-test, got %q", meta.HaritiVersion)
+	}
+	if meta.Graph == nil || len(meta.Graph.Bundles) != 1 || meta.Graph.Bundles[0].ID != "my/remote-plugin" {
+		t.Errorf("expected resolved graph with my/remote-plugin, got %+v", meta.Graph)
+	}
+	if len(meta.Bundles) != 1 {
+		t.Fatalf("expected 1 bundle record, got %+v", meta.Bundles)
+	}
+	record := meta.Bundles[0]
+	if record.ID != "my/remote-plugin" || record.Reused {
+		t.Errorf("expected freshly built my/remote-plugin, got %+v", record)
+	}
+	if len(record.BuildSteps) != 1 || record.BuildSteps[0].ExitCode != 0 || record.BuildSteps[0].Cmd != g.Bundles[0].Build[0].Cmd {
+		t.Errorf("expected 1 successful build step, got %+v", record.BuildSteps)
+	}
+	if record.Log != "logs/my_remote-plugin.log" {
+		t.Fatalf("expected log logs/my_remote-plugin.log, got %q", record.Log)
+	}
+	logData, err := os.ReadFile(filepath.Join(har.GenerationsDir(), firstID, filepath.FromSlash(record.Log)))
+	if err != nil {
+		t.Fatalf("failed to read build log: %v", err)
+	}
+	for _, want := range []string{"build-stdout", "build-stderr"} {
+		if !strings.Contains(string(logData), want) {
+			t.Errorf("expected build log to contain %q, got:\n%s", want, logData)
+		}
+	}
+
+	detail, err := har.InspectGeneration(firstID)
+	if err != nil {
+		t.Fatalf("InspectGeneration failed: %v", err)
+	}
+	if detail.HaritiVersion != "v1.2.3-test" || len(detail.Bundles) != 1 {
+		t.Errorf("expected inspection to expose the deploy records, got %+v", detail)
+	}
+
+	// Step 2: A reused store entry keeps the recorded build steps but runs nothing
+	secondID, err := har.Deploy(ctx, g, hariti.DeployOptions{Force: true})
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+	record = readMetadata(secondID).Bundles[0]
+	if !record.Reused || record.Log != "" || record.BuildMillis != 0 {
+		t.Errorf("expected reused bundle without log or build time, got %+v", record)
+	}
+	if len(record.BuildSteps) != 1 || record.BuildSteps[0].ExitCode != 0 {
+		t.Errorf("expected build steps recorded in the store entry, got %+v", record.BuildSteps)
+	}
+}
+
+func TestHariti_Deploy_DependencyOrder(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	xdgHome := filepath.Join(tmpDir, "xdg_home")
+	_ = os.Setenv("XDG_DATA_HOME", xdgHome)
+	defer func() {
+		_ = os.Unsetenv("XDG_DATA_HOME")
+	}()
+
+	localDir := func(name string) string {
+		dir := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("failed to create local bundle dir: %v", err)
+		}
+		return dir
+	}
+	pluginDir := localDir("plugin")
+	libDir := localDir("lib")
+
+	// The plugin is declared first and depends on the library by alias
+	g := &graph.Graph{
+		Bundles: []graph.Bundle{
+			{
+				ID:           "my/plugin",
+				Source:       graph.Source{Type: graph.SourceTypeLocal, Path: pluginDir},
+				Dependencies: []string{"lib"},
+			},
+			{
+				ID:      "my/lib.vim",
+				Source:  graph.Source{Type: graph.SourceTypeLocal, Path: libDir},
+				Aliases: []string{"lib"},
+			},
+		},
+	}
+
+	cfg := &hariti.HaritiConfig{
+		Paths: hariti.Paths{
+			ConfigFile: filepath.Join(tmpDir, "hariti_home", "bundles.hariti"),
+			ConfigDir:  filepath.Join(tmpDir, "hariti_home"),
+			DataDir:    filepath.Join(xdgHome, "hariti"),
+		},
+		Writer:    io.Discard,
+		ErrWriter: io.Discard,
+	}
+	har := hariti.NewHariti(cfg)
+	ctx := context.Background()
+
+	if _, err := har.Sync(ctx, g, hariti.SyncOptions{}); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	genID, err := har.Deploy(ctx, g, hariti.DeployOptions{})
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+
+	packadd, err := os.ReadFile(filepath.Join(har.GenerationsDir(), genID, "packadd.vim"))
+	if err != nil {
+		t.Fatalf("failed to read packadd.vim: %v", err)
+	}
+	libIdx := strings.Index(string(packadd), filepath.ToSlash(libDir))
+	pluginIdx := strings.Index(string(packadd), filepath.ToSlash(pluginDir))
+	if libIdx < 0 || pluginIdx < 0 || libIdx > pluginIdx {
+		t.Errorf("expected my/lib.vim to be added before my/plugin, got:\n%s", packadd)
+	}
+
+	// A condition of the library also guards the plugin depending on it
+	g.Bundles[1].EnableIf = "has('python3')"
+	genID, err = har.Deploy(ctx, g, hariti.DeployOptions{})
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+	packadd, err = os.ReadFile(filepath.Join(har.GenerationsDir(), genID, "packadd.vim"))
+	if err != nil {
+		t.Fatalf("failed to read packadd.vim: %v", err)
+	}
+	for _, dir := range []string{libDir, pluginDir} {
+		want := fmt.Sprintf("if has('python3')\n  call s:add_rtp(%q, '')\nendif\n", filepath.ToSlash(dir))
+		if !strings.Contains(string(packadd), want) {
+			t.Errorf("expected packadd.vim to contain %q, got:\n%s", want, packadd)
+		}
+	}
+
+	// A dependency cycle fails the deploy before anything is staged
+	g.Bundles[1].Dependencies = []string{"my/plugin"}
+	_, err = har.Deploy(ctx, g, hariti.DeployOptions{Force: true})
+	var cycle *graph.CycleError
+	if !errors.As(err, &cycle) {
+		t.Errorf("expected dependency cycle error, got: %v", err)
+	}
+}
+
+func TestHariti_Deploy_LazyLoading(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	xdgHome := filepath.Join(tmpDir, "xdg_home")
+	_ = os.Setenv("XDG_DATA_HOME", xdgHome)
+	defer func() {
+		_ = os.Unsetenv("XDG_DATA_HOME")
+	}()
+
+	localDir := func(name string) string {
+		dir := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("failed to create local bundle dir: %v", err)
+		}
+		return dir
+	}
+	pluginDir := localDir("plugin")
+	libDir := localDir("lib")
+
+	// The library declares a trigger, but the plugin loaded at startup
+	// depends on it
+	g := &graph.Graph{
+		Bundles: []graph.Bundle{
+			{
+				ID:     "my/lib.vim",
+				Source: graph.Source{Type: graph.SourceTypeLocal, Path: libDir},
+				OnCmd:  []string{"LibRun"},
+			},
+			{
+				ID:           "my/plugin",
+				Source:       graph.Source{Type: graph.SourceTypeLocal, Path: pluginDir},
+				Dependencies: []string{"my/lib.vim"},
+			},
+		},
+	}
+
+	cfg := &hariti.HaritiConfig{
+		Paths: hariti.Paths{
+			ConfigFile: filepath.Join(tmpDir, "hariti_home", "bundles.hariti"),
+			ConfigDir:  filepath.Join(tmpDir, "hariti_home"),
+			DataDir:    filepath.Join(xdgHome, "hariti"),
+		},
+		Writer:    io.Discard,
+		ErrWriter: io.Discard,
+	}
+	har := hariti.NewHariti(cfg)
+	ctx := context.Background()
+
+	if _, err := har.Sync(ctx, g, hariti.SyncOptions{}); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	eagerID, err := har.Deploy(ctx, g, hariti.DeployOptions{})
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+	packadd, err := os.ReadFile(filepath.Join(har.GenerationsDir(), eagerID, "packadd.vim"))
+	if err != nil {
+		t.Fatalf("failed to read packadd.vim: %v", err)
+	}
+	want := fmt.Sprintf("call s:add_rtp(%q, '')\n", filepath.ToSlash(libDir))
+	if !strings.Contains(string(packadd), want) || strings.Contains(string(packadd), "LibRun") {
+		t.Errorf("expected my/lib.vim to be loaded at startup, got:\n%s", packadd)
+	}
+
+	// Once the plugin is lazy too, both are loaded by the plugin's trigger
+	g.Bundles[1].OnFt = []string{"go"}
+	g.Bundles[1].EnableIf = "has('python3')"
+	lazyID, err := har.Deploy(ctx, g, hariti.DeployOptions{})
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+	if lazyID == eagerID {
+		t.Fatalf("expected triggers to change the generation identity, got %s again", lazyID)
+	}
+	packadd, err = os.ReadFile(filepath.Join(har.GenerationsDir(), lazyID, "packadd.vim"))
+	if err != nil {
+		t.Fatalf("failed to read packadd.vim: %v", err)
+	}
+	for _, want := range []string{
+		"\" my/lib.vim\nfunction! s:lazy_1() abort\n",
+		fmt.Sprintf("  silent! delcommand LibRun\n  call s:add_rtp(%q, '')\n", filepath.ToSlash(libDir)),
+		"command! -nargs=* -range -bang -complete=file LibRun call s:lazy_cmd(function('s:lazy_1'), 'LibRun', '<bang>', <range>, <line1>, <line2>, <q-args>)\n",
+		"\" my/plugin\nfunction! s:lazy_2() abort\n",
+		"  silent! autocmd! hariti_lazy_2\n  call s:lazy_1()\n",
+		"if has('python3')\n  augroup hariti_lazy_2\n    autocmd!\n    autocmd FileType go call s:lazy_ft(function('s:lazy_2'))\n  augroup END\nendif\n",
+	} {
+		if !strings.Contains(string(packadd), want) {
+			t.Errorf("expected packadd.vim to contain %q, got:\n%s", want, packadd)
+		}
+	}
+	if strings.Contains(string(packadd), fmt.Sprintf("\ncall s:add_rtp(%q", filepath.ToSlash(pluginDir))) {
+		t.Errorf("expected my/plugin not to be loaded at startup, got:\n%s", packadd)
+	}
+}
+
+func TestHariti_Deploy_LuaProjection(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	xdgHome := filepath.Join(tmpDir, "xdg_home")
+	_ = os.Setenv("XDG_DATA_HOME", xdgHome)
+	defer func() {
+		_ = os.Unsetenv("XDG_DATA_HOME")
+	}()
+
+	localDir := func(name string) string {
+		dir := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("failed to create local bundle dir: %v", err)
+		}
+		return dir
+	}
+	libDir := localDir("lib")
+	pluginDir := localDir("plugin")
+	lazyDir := localDir("lazy")
+
+	g := &graph.Graph{
+		Bundles: []graph.Bundle{
+			{
+				ID:       "my/lib.vim",
+				Source:   graph.Source{Type: graph.SourceTypeLocal, Path: libDir},
+				EnableIf: "has('nvim')",
+			},
+			{
+				ID:           "my/plugin",
+				Source:       graph.Source{Type: graph.SourceTypeLocal, Path: pluginDir},
+				EnableIfLua:  "vim.g.plugin ~= false",
+				Dependencies: []string{"my/lib.vim"},
+			},
+			{
+				ID:     "my/lazy",
+				Source: graph.Source{Type: graph.SourceTypeLocal, Path: lazyDir},
+				OnCmd:  []string{"LazyRun"},
+			},
+		},
+	}
+
+	cfg := &hariti.HaritiConfig{
+		Paths: hariti.Paths{
+			ConfigFile: filepath.Join(tmpDir, "hariti_home", "bundles.hariti"),
+			ConfigDir:  filepath.Join(tmpDir, "hariti_home"),
+			DataDir:    filepath.Join(xdgHome, "hariti"),
+		},
+		Writer:    io.Discard,
+		ErrWriter: io.Discard,
+	}
+	har := hariti.NewHariti(cfg)
+	ctx := context.Background()
+
+	if _, err := har.Sync(ctx, g, hariti.SyncOptions{}); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if _, err := har.Deploy(ctx, g, hariti.DeployOptions{Projections: []hariti.Projection{"fish"}}); err == nil {
+		t.Fatal("expected an unknown projection to fail the deploy, got nil")
+	}
+
+	vimID, err := har.Deploy(ctx, g, hariti.DeployOptions{})
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(har.GenerationsDir(), vimID, "packadd.lua")); !os.IsNotExist(err) {
+		t.Errorf("expected no packadd.lua without the lua projection, got err %v", err)
+	}
+
+	luaID, err := har.Deploy(ctx, g, hariti.DeployOptions{Projections: []hariti.Projection{hariti.ProjectionLua}})
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+	if luaID == vimID {
+		t.Fatalf("expected the projections to change the generation identity, got %s again", luaID)
+	}
+	genDir := filepath.Join(har.GenerationsDir(), luaID)
+	if _, err := os.Stat(filepath.Join(genDir, "packadd.vim")); err != nil {
+		t.Errorf("expected packadd.vim to be generated too: %v", err)
+	}
+	packadd, err := os.ReadFile(filepath.Join(genDir, "packadd.lua"))
+	if err != nil {
+		t.Fatalf("failed to read packadd.lua: %v", err)
+	}
+	libCond := `vim.fn.eval("(has('nvim')) ? 1 : 0") == 1`
+	for _, want := range []string{
+		fmt.Sprintf("if %s then\n  add_rtp(%q, \"\")\nend\n", libCond, filepath.ToSlash(libDir)),
+		fmt.Sprintf("if (vim.g.plugin ~= false) and (%s) then\n  add_rtp(%q, \"\")\nend\n", libCond, filepath.ToSlash(pluginDir)),
+		"-- my/lazy\nloaders[1] = function()\n",
+		"  pcall(vim.api.nvim_del_user_command, \"LazyRun\")\n",
+		"vim.api.nvim_create_user_command(\"LazyRun\", function(opts) lazy_cmd(loaders[1], \"LazyRun\", opts) end,",
+	} {
+		if !strings.Contains(string(packadd), want) {
+			t.Errorf("expected packadd.lua to contain %q, got:\n%s", want, packadd)
+		}
+	}
+
+	metaBytes, err := os.ReadFile(filepath.Join(genDir, "metadata.json"))
+	if err != nil {
+		t.Fatalf("failed to read metadata.json: %v", err)
+	}
+	var meta hariti.GenerationMetadata
+	if err := json.Unmarshal(metaBytes, &meta); err != nil {
+		t.Fatalf("failed to parse metadata.json: %v", err)
+	}
+	if !slices.Equal(meta.Projections, []hariti.Projection{hariti.ProjectionVim, hariti.ProjectionLua}) {
+		t.Errorf("expected projections [vim lua] in metadata, got %v", meta.Projections)
+	}
+}
+
+func TestHariti_Deploy_StaticProjection(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	xdgHome := filepath.Join(tmpDir, "xdg_home")
+	_ = os.Setenv("XDG_DATA_HOME", xdgHome)
+	defer func() {
+		_ = os.Unsetenv("XDG_DATA_HOME")
+	}()
+
+	// A remote bundle with plugin, ftdetect and after directories
+	remoteRepoDir := filepath.Join(tmpDir, "remote_repo")
+	for name, content := range map[string]string{
+		"plugin/remote.vim":      "let g:remote = 1",
+		"plugin/sub/nested.vim":  "let g:nested = 1",
+		"plugin/README":          "not a script",
+		"ftdetect/remote.vim":    "autocmd BufRead *.remote setfiletype remote",
+		"after/plugin/after.vim": "let g:after = 1",
+	} {
+		path := filepath.Join(remoteRepoDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create mock remote dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	_ = runGitCmdInDir(t, remoteRepoDir, "init")
+	_ = runGitCmdInDir(t, remoteRepoDir, "config", "user.email", "test@hariti.io")
+	_ = runGitCmdInDir(t, remoteRepoDir, "config", "user.name", "Test Hariti")
+	_ = runGitCmdInDir(t, remoteRepoDir, "add", ".")
+	_ = runGitCmdInDir(t, remoteRepoDir, "commit", "-m", "initial commit")
+	remoteURL, err := url.Parse("file://" + filepath.ToSlash(remoteRepoDir))
+	if err != nil {
+		t.Fatalf("failed to parse remote URL: %v", err)
+	}
+
+	localDir := func(name string) string {
+		dir := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("failed to create local bundle dir: %v", err)
+		}
+		return dir
+	}
+	localPluginDir := localDir("local")
+	guiPluginDir := localDir("gui")
+
+	g := &graph.Graph{
+		Bundles: []graph.Bundle{
+			{
+				ID: "my/remote-plugin",
+				Source: graph.Source{
+					Type: graph.SourceTypeRemote,
+					URL:  remoteURL,
+					Path: filepath.Join(xdgHome, "hariti", "repos", url.QueryEscape("my/remote-plugin")),
+				},
+			},
+			{
+				ID:       "my/gui",
+				Source:   graph.Source{Type: graph.SourceTypeLocal, Path: guiPluginDir},
+				EnableIf: "has('gui_running')",
+			},
+			{
+				ID:     "my/local",
+				Source: graph.Source{Type: graph.SourceTypeLocal, Path: localPluginDir},
+			},
+		},
+	}
+
+	cfg := &hariti.HaritiConfig{
+		Paths: hariti.Paths{
+			ConfigFile: filepath.Join(tmpDir, "hariti_home", "bundles.hariti"),
+			ConfigDir:  filepath.Join(tmpDir, "hariti_home"),
+			DataDir:    filepath.Join(xdgHome, "hariti"),
+		},
+		Writer:    io.Discard,
+		ErrWriter: io.Discard,
+	}
+	har := hariti.NewHariti(cfg)
+	ctx := context.Background()
+	ctx = vcs.WithWriter(ctx, io.Discard)
+	ctx = vcs.WithErrWriter(ctx, io.Discard)
+
+	if _, err := har.Sync(ctx, g, hariti.SyncOptions{}); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	genID, err := har.Deploy(ctx, g, hariti.DeployOptions{Projections: []hariti.Projection{hariti.ProjectionStatic}})
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+	packadd, err := os.ReadFile(filepath.Join(har.GenerationsDir(), genID, "packadd.static.vim"))
+	if err != nil {
+		t.Fatalf("failed to read packadd.static.vim: %v", err)
+	}
+
+	bundleDir := filepath.ToSlash(filepath.Join(har.GenerationsDir(), genID, "pack", "hariti", "opt", "my_remote-plugin"))
+	for _, want := range []string{
+		fmt.Sprintf("let s:rtp = ['%s', '%s']\n", bundleDir, filepath.ToSlash(localPluginDir)),
+		fmt.Sprintf("if has('gui_running')\n  call add(s:rtp, '%s')\nendif\n", filepath.ToSlash(guiPluginDir)),
+		fmt.Sprintf("let s:rtp += ['%s/after']\n", bundleDir),
+		fmt.Sprintf("for s:file in ['%s/plugin/remote.vim', '%s/plugin/sub/nested.vim']\n", bundleDir, bundleDir),
+		fmt.Sprintf("    for s:file in ['%s/ftdetect/remote.vim']\n", bundleDir),
+	} {
+		if !strings.Contains(string(packadd), want) {
+			t.Errorf("expected packadd.static.vim to contain %q, got:\n%s", want, packadd)
+		}
+	}
+	if n := strings.Count(string(packadd), "let &runtimepath ="); n != 1 {
+		t.Errorf("expected a single runtimepath assignment, got %d:\n%s", n, packadd)
+	}
+	for _, unwanted := range []string{"packadd ", "split(&runtimepath", "s:add_rtp"} {
+		if strings.Contains(string(packadd), unwanted) {
+			t.Errorf("expected packadd.static.vim not to contain %q without lazy or conditional remote bundles, got:\n%s", unwanted, packadd)
+		}
+	}
+}
+
+func TestHariti_Deploy_Progress(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	xdgHome := filepath.Join(tmpDir, "xdg_home")
+	_ = os.Setenv("XDG_DATA_HOME", xdgHome)
+	defer func() {
+		_ = os.Unsetenv("XDG_DATA_HOME")
+	}()
+
+	g := &graph.Graph{}
+	for _, id := range []string{"my/plain-plugin", "my/built-plugin"} {
+		remoteRepoDir := filepath.Join(tmpDir, "remote_"+strings.ReplaceAll(id, "/", "_"))
+		if err := os.MkdirAll(remoteRepoDir, 0755); err != nil {
+			t.Fatalf("failed to create mock remote dir: %v", err)
+		}
+		_ = runGitCmdInDir(t, remoteRepoDir, "init")
+		_ = runGitCmdInDir(t, remoteRepoDir, "config", "user.email", "test@hariti.io")
+		_ = runGitCmdInDir(t, remoteRepoDir, "config", "user.name", "Test Hariti")
+		_ = runGitCmdInDir(t, remoteRepoDir, "commit", "--allow-empty", "-m", "initial commit")
+
+		remoteURL, err := url.Parse("file://" + filepath.ToSlash(remoteRepoDir))
+		if err != nil {
+			t.Fatalf("failed to parse remote URL: %v", err)
+		}
+		g.Bundles = append(g.Bundles, graph.Bundle{
+			ID: id,
+			Source: graph.Source{
+				Type: graph.SourceTypeRemote,
+				URL:  remoteURL,
+				Path: filepath.Join(xdgHome, "hariti", "repos", url.QueryEscape(id)),
+			},
+		})
+	}
+	g.Bundles[1].Build = []graph.BuildStep{{OS: "all", Cmd: "echo building"}}
+
+	cfg := &hariti.HaritiConfig{
+		Paths: hariti.Paths{
+			ConfigFile: filepath.Join(tmpDir, "hariti_home", "bundles.hariti"),
+			ConfigDir:  filepath.Join(tmpDir, "hariti_home"),
+			DataDir:    filepath.Join(xdgHome, "hariti"),
+		},
+		Writer:    io.Discard,
+		ErrWriter: io.Discard,
+	}
+	har := hariti.NewHariti(cfg)
+
+	ctx := context.Background()
+	ctx = vcs.WithWriter(ctx, io.Discard)
+	ctx = vcs.WithErrWriter(ctx, io.Discard)
+
+	if _, err := har.Sync(ctx, g, hariti.SyncOptions{}); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	var mu sync.Mutex
+	var events []hariti.DeployProgressEvent
+	opts := hariti.DeployOptions{
+		Parallelism: 2,
+		OnProgress: func(event hariti.DeployProgressEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, event)
+		},
+	}
+	phasesOf := func(bundleID string) []string {
+		var phases []string
+		for _, event := range events {
+			if event.Type == hariti.DeployEventBundlePhase && event.BundleID == bundleID {
+				phases = append(phases, string(event.Phase))
+			}
+		}
+		return phases
+	}
+	completedOf := func(bundleID string) *hariti.DeployProgressEvent {
+		for i, event := range events {
+			if event.Type == hariti.DeployEventBundleCompleted && event.BundleID == bundleID {
+				return &events[i]
+			}
+		}
+		return nil
+	}
+
+	// Step 1: A fresh deploy reports every phase of every bundle
+	genID, err := har.Deploy(ctx, g, opts)
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+	if first, last := events[0], events[len(events)-1]; first.Type != hariti.DeployEventStarted || last.Type != hariti.DeployEventCompleted {
+		t.Errorf("expected started and completed events around the deploy, got %+v and %+v", first, last)
+	}
+	if events[0].Total != 2 || events[0].Parallelism != 2 || events[0].GenerationID != genID {
+		t.Errorf("unexpected started event: %+v", events[0])
+	}
+	if got := strings.Join(phasesOf("my/plain-plugin"), ","); got != "archive,helptags" {
+		t.Errorf("unexpected phases of my/plain-plugin: %s", got)
+	}
+	if got := strings.Join(phasesOf("my/built-plugin"), ","); got != "archive,build,helptags" {
+		t.Errorf("unexpected phases of my/built-plugin: %s", got)
+	}
+	for _, id := range []string{"my/plain-plugin", "my/built-plugin"} {
+		if completed := completedOf(id); completed == nil || completed.Reused {
+			t.Errorf("expected freshly built completion of %s, got %+v", id, completed)
+		}
+	}
+
+	// Step 2: Reused store entries skip every phase
+	events = nil
+	if _, err := har.Deploy(ctx, g, hariti.DeployOptions{Force: true, OnProgress: opts.OnProgress}); err != nil {
+		t.Fatalf("forced Deploy failed: %v", err)
+	}
+	for _, id := range []string{"my/plain-plugin", "my/built-plugin"} {
+		if phases := phasesOf(id); len(phases) != 0 {
+			t.Errorf("expected no phases for reused %s, got %v", id, phases)
+		}
+		if completed := completedOf(id); completed == nil || !completed.Reused {
+			t.Errorf("expected reused completion of %s, got %+v", id, completed)
+		}
+	}
+
+	// Step 3: A failing build reports its output
+	events = nil
+	g.Bundles[1].Build = []graph.BuildStep{{OS: "all", Cmd: "echo broken build && exit 1"}}
+	if _, err := har.Deploy(ctx, g, opts); err == nil {
+		t.Fatal("expected failing build step to fail the deploy, got nil")
+	}
+	var failed *hariti.DeployProgressEvent
+	for i, event := range events {
+		if event.Type == hariti.DeployEventBundleFailed {
+			failed = &events[i]
+		}
+	}
+	if failed == nil || failed.BundleID != "my/built-plugin" || !strings.Contains(failed.Output, "broken build") {
+		t.Errorf("expected bundle-failed event with build output, got %+v", failed)
+	}
+	if last := events[len(events)-1]; last.Type != hariti.DeployEventFailed {
+		t.Errorf("expected failed event last, got %+v", last)
+	}
 }
 
 func TestHariti_Deploy_Failure_HelpTags(t *testing.T) {
@@ -0,0 +1,149 @@
+package e2e_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kamichidu/go-hariti"
+	"github.com/kamichidu/go-hariti/internal/config/dsl"
+)
+
+func TestE2E_VimLazyLoading(t *testing.T) {
+	// Skip test if vim is not installed
+	if _, err := exec.LookPath("vim"); err != nil {
+		t.Skip("vim not installed")
+	}
+
+	tmpDir := t.TempDir()
+
+	// Copy fixture into isolated temporary directory for reproducibility
+	fixtureSrcAbs, err := filepath.Abs(filepath.Join("testdata", "lazy"))
+	if err != nil {
+		t.Fatalf("failed to get absolute path of fixture source: %v", err)
+	}
+	fixtureDst := filepath.Join(tmpDir, "lazy")
+	if err := copyDir(fixtureSrcAbs, fixtureDst); err != nil {
+		t.Fatalf("failed to copy fixture directory: %v", err)
+	}
+	t.Setenv("E2E_PLUGINS_DIR", filepath.Join(fixtureDst, "plugins"))
+
+	cfg := &hariti.HaritiConfig{
+		Paths: hariti.Paths{
+			ConfigFile: filepath.Join(fixtureDst, "bundles.hariti"),
+			ConfigDir:  filepath.Join(tmpDir, "config"),
+			DataDir:    filepath.Join(tmpDir, "data"),
+		},
+		Writer:    os.Stdout,
+		ErrWriter: os.Stderr,
+	}
+	har := hariti.NewHariti(cfg)
+	if err := har.SetupManagedDirectory(); err != nil {
+		t.Fatalf("failed to setup managed directories: %v", err)
+	}
+
+	g, err := dsl.LoadGraph(cfg.Paths.ConfigFile)
+	if err != nil {
+		t.Fatalf("failed to load graph: %v", err)
+	}
+	ctx := context.Background()
+	if _, err := har.Sync(ctx, g, hariti.SyncOptions{}); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if _, err := har.Deploy(ctx, g, hariti.DeployOptions{}); err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+
+	// Fire every trigger once and record which plugins were loaded
+	currentPath := filepath.ToSlash(har.CurrentSymlinkPath())
+	runVimScript := filepath.Join(tmpDir, "run.vim")
+	outFile := filepath.Join(tmpDir, "output.txt")
+	scriptContent := fmt.Sprintf(`set nomore
+filetype plugin on
+set packpath+=%s
+source %s/packadd.vim
+let result = {}
+let result.startup = [exists('g:lazy_dep_loaded'), exists('g:event_plugin_loaded'), exists('g:cmd_plugin_loaded'), exists('g:ft_plugin_loaded'), exists('g:map_plugin_loaded')]
+let result.disabled = exists(':Disabled')
+doautocmd InsertEnter
+let result.event = [exists('g:event_plugin_loaded'), get(g:, 'insert_entered', 0)]
+call setline(1, ['one', 'two', 'three'])
+2Greet world
+let result.command = [get(g:, 'greeting', ''), exists('g:lazy_dep_loaded')]
+let result.command_range = get(g:, 'greet_range', [])
+set filetype=harititest
+let result.filetype = [exists('g:ft_plugin_loaded'), exists('b:ft_plugin_applied')]
+call feedkeys("\<Plug>(hariti-test)", 'x')
+call feedkeys("\<Plug>(hariti-test)", 'x')
+let result.mapping = [exists('g:map_plugin_loaded'), get(g:, 'mapped', 0)]
+redir! > %s
+silent echo json_encode(result)
+redir END
+qa!
+`, currentPath, currentPath, filepath.ToSlash(outFile))
+	if err := os.WriteFile(runVimScript, []byte(scriptContent), 0644); err != nil {
+		t.Fatalf("failed to write run.vim script: %v", err)
+	}
+
+	cmd := exec.Command("vim", "-Nu", "NONE", "-n", "-e", "-s", "-S", runVimScript)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("failed to execute vim: %v\nOutput: %s", err, string(out))
+	}
+
+	outputBytes, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatalf("failed to read vim output file: %v", err)
+	}
+
+	type vimResult struct {
+		Startup  []int `json:"startup"`
+		Disabled int   `json:"disabled"`
+		Event    []int `json:"event"`
+		Command  []any `json:"command"`
+		CmdRange []int `json:"command_range"`
+		Filetype []int `json:"filetype"`
+		Mapping  []int `json:"mapping"`
+	}
+	var res vimResult
+	foundJSON := false
+	for _, line := range strings.Split(string(outputBytes), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		if err := json.Unmarshal([]byte(trimmed), &res); err == nil {
+			foundJSON = true
+			break
+		}
+	}
+	if !foundJSON {
+		t.Fatalf("failed to find valid JSON output from Vim in: %s", string(outputBytes))
+	}
+
+	if fmt.Sprint(res.Startup) != "[0 0 0 0 0]" {
+		t.Errorf("expected no lazy plugin to be loaded at startup, got %v", res.Startup)
+	}
+	if res.Disabled != 0 {
+		t.Errorf("expected no stub for a disabled bundle, got exists(':Disabled') = %d", res.Disabled)
+	}
+	if fmt.Sprint(res.Event) != "[1 1]" {
+		t.Errorf("expected the event to load the plugin and reach its autocmd, got %v", res.Event)
+	}
+	if fmt.Sprint(res.Command) != "[hello world 1]" {
+		t.Errorf("expected the command to load the plugin with its dependency and run, got %v", res.Command)
+	}
+	if fmt.Sprint(res.CmdRange) != "[1 2 2]" {
+		t.Errorf("expected the command to be replayed with its single line range, got %v", res.CmdRange)
+	}
+	if fmt.Sprint(res.Filetype) != "[1 1]" {
+		t.Errorf("expected the filetype to load the plugin and apply its ftplugin, got %v", res.Filetype)
+	}
+	if fmt.Sprint(res.Mapping) != "[1 2]" {
+		t.Errorf("expected the mapping to load the plugin and run twice, got %v", res.Mapping)
+	}
+}
@@ -72,6 +72,44 @@ func normalizeRuntimepath(t *testing.T, raw string, vars normalizeVars) []string
 	return result
 }
 
+// expectedSimpleRuntimepath returns the runtimepath the simple fixture is
+// expected to project onto the runtimepath before.
+func expectedSimpleRuntimepath(tmpDir, before string) string {
+	rawBeforeEntries := strings.Split(before, ",")
+
+	// Find the first after entry from the baseline inside the raw before runtimepath
+	firstRawBeforeAfterIdx := -1
+	for i, entry := range rawBeforeEntries {
+		cleaned := filepath.Clean(entry)
+		if filepath.Base(cleaned) == "after" {
+			firstRawBeforeAfterIdx = i
+			break
+		}
+	}
+
+	// Dynamic plugin paths
+	depPluginPath := filepath.Join(tmpDir, "simple", "plugins", "dep-plugin")
+	localPluginPath := filepath.Join(tmpDir, "simple", "plugins", "local-plugin")
+	depAfterPath := filepath.Join(depPluginPath, "after")
+	localAfterPath := filepath.Join(localPluginPath, "after")
+
+	normalBundlePaths := []string{depPluginPath, localPluginPath}
+	bundleAfterPaths := []string{depAfterPath, localAfterPath}
+
+	var expectedRawEntries []string
+	if firstRawBeforeAfterIdx >= 0 {
+		expectedRawEntries = append(expectedRawEntries, rawBeforeEntries[:firstRawBeforeAfterIdx]...)
+		expectedRawEntries = append(expectedRawEntries, normalBundlePaths...)
+		expectedRawEntries = append(expectedRawEntries, rawBeforeEntries[firstRawBeforeAfterIdx:]...)
+		expectedRawEntries = append(expectedRawEntries, bundleAfterPaths...)
+	} else {
+		expectedRawEntries = append(expectedRawEntries, rawBeforeEntries...)
+		expectedRawEntries = append(expectedRawEntries, normalBundlePaths...)
+		expectedRawEntries = append(expectedRawEntries, bundleAfterPaths...)
+	}
+	return strings.Join(expectedRawEntries, ",")
+}
+
 func TestE2E_VimRuntimepathProjection(t *testing.T) {
 	// Skip test if vim is not installed
 	if _, err := exec.LookPath("vim"); err != nil {
@@ -243,42 +281,9 @@ qa!
 		VimRuntime: vimRuntime,
 	}
 
-	rawBeforeEntries := strings.Split(res.BeforeRuntimepath, ",")
-
-	// Find the first after entry from the baseline inside the raw before runtimepath
-	firstRawBeforeAfterIdx := -1
-	for i, entry := range rawBeforeEntries {
-		cleaned := filepath.Clean(entry)
-		if filepath.Base(cleaned) == "after" {
-			firstRawBeforeAfterIdx = i
-			break
-		}
-	}
-
-	// Dynamic plugin paths
-	depPluginPath := filepath.Join(tmpDir, "simple", "plugins", "dep-plugin")
-	localPluginPath := filepath.Join(tmpDir, "simple", "plugins", "local-plugin")
-	depAfterPath := filepath.Join(depPluginPath, "after")
-	localAfterPath := filepath.Join(localPluginPath, "after")
-
-	normalBundlePaths := []string{depPluginPath, localPluginPath}
-	bundleAfterPaths := []string{depAfterPath, localAfterPath}
-
-	var expectedRawEntries []string
-	if firstRawBeforeAfterIdx >= 0 {
-		expectedRawEntries = append(expectedRawEntries, rawBeforeEntries[:firstRawBeforeAfterIdx]...)
-		expectedRawEntries = append(expectedRawEntries, normalBundlePaths...)
-		expectedRawEntries = append(expectedRawEntries, rawBeforeEntries[firstRawBeforeAfterIdx:]...)
-		expectedRawEntries = append(expectedRawEntries, bundleAfterPaths...)
-	} else {
-		expectedRawEntries = append(expectedRawEntries, rawBeforeEntries...)
-		expectedRawEntries = append(expectedRawEntries, normalBundlePaths...)
-		expectedRawEntries = append(expectedRawEntries, bundleAfterPaths...)
-	}
-
 	// Normalize both actual and expected to eliminate environment differences
 	gotRTP := normalizeRuntimepath(t, res.Runtimepath, vars)
-	expectedRTP := normalizeRuntimepath(t, strings.Join(expectedRawEntries, ","), vars)
+	expectedRTP := normalizeRuntimepath(t, expectedSimpleRuntimepath(tmpDir, res.BeforeRuntimepath), vars)
 
 	// Compare actual with expected projection model
 	mismatch := false
@@ -340,3 +345,226 @@ qa!
 			snapshotPath, strings.Join(gotRTP, "\n"), strings.Join(snapRTP, "\n"))
 	}
 }
+
+func TestE2E_NeovimRuntimepathProjection(t *testing.T) {
+	// Skip test if neovim is not installed
+	if _, err := exec.LookPath("nvim"); err != nil {
+		t.Skip("nvim not installed")
+	}
+
+	tmpDir := t.TempDir()
+
+	// Copy fixture into isolated temporary directory for reproducibility
+	fixtureSrcAbs, err := filepath.Abs(filepath.Join("testdata", "simple"))
+	if err != nil {
+		t.Fatalf("failed to get absolute path of fixture source: %v", err)
+	}
+	fixtureDst := filepath.Join(tmpDir, "simple")
+	if err := copyDir(fixtureSrcAbs, fixtureDst); err != nil {
+		t.Fatalf("failed to copy fixture directory: %v", err)
+	}
+	t.Setenv("E2E_PLUGINS_DIR", filepath.Join(fixtureDst, "plugins"))
+
+	cfg := &hariti.HaritiConfig{
+		Paths: hariti.Paths{
+			ConfigFile: filepath.Join(fixtureDst, "bundles.hariti"),
+			ConfigDir:  filepath.Join(tmpDir, "config"),
+			DataDir:    filepath.Join(tmpDir, "data"),
+		},
+		Writer:    os.Stdout,
+		ErrWriter: os.Stderr,
+	}
+	har := hariti.NewHariti(cfg)
+	if err := har.SetupManagedDirectory(); err != nil {
+		t.Fatalf("failed to setup managed directories: %v", err)
+	}
+
+	g, err := dsl.LoadGraph(cfg.Paths.ConfigFile)
+	if err != nil {
+		t.Fatalf("failed to load graph: %v", err)
+	}
+	ctx := context.Background()
+	if _, err := har.Sync(ctx, g, hariti.SyncOptions{}); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if _, err := har.Deploy(ctx, g, hariti.DeployOptions{Projections: []hariti.Projection{hariti.ProjectionLua}}); err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+
+	// Spawn headless Neovim to source packadd.lua and observe runtimepath
+	currentPath := filepath.ToSlash(har.CurrentSymlinkPath())
+	runVimScript := filepath.Join(tmpDir, "run.vim")
+	outFile := filepath.Join(tmpDir, "output.txt")
+	scriptContent := fmt.Sprintf(`set nomore
+set packpath+=%s
+let before_runtimepath = &runtimepath
+source %s/packadd.lua
+let after_runtimepath = &runtimepath
+try
+  help local-plugin
+  let help_ok = "SUCCESS"
+catch
+  let help_ok = "FAILURE: " . v:exception
+endtry
+let result = {
+\ 'before_runtimepath': before_runtimepath,
+\ 'runtimepath': after_runtimepath,
+\ 'vimruntime': $VIMRUNTIME,
+\ 'help': help_ok,
+\}
+call writefile([json_encode(result)], %s)
+qa!
+`, currentPath, currentPath, "'"+filepath.ToSlash(outFile)+"'")
+	if err := os.WriteFile(runVimScript, []byte(scriptContent), 0644); err != nil {
+		t.Fatalf("failed to write run.vim script: %v", err)
+	}
+
+	cmd := exec.Command("nvim", "--headless", "-u", "NONE", "-i", "NONE", "-n", "-S", runVimScript)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("failed to execute nvim: %v\nOutput: %s", err, string(out))
+	}
+
+	outputBytes, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatalf("failed to read nvim output file: %v", err)
+	}
+	var res struct {
+		BeforeRuntimepath string `json:"before_runtimepath"`
+		Runtimepath       string `json:"runtimepath"`
+		Vimruntime        string `json:"vimruntime"`
+		Help              string `json:"help"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(string(outputBytes))), &res); err != nil {
+		t.Fatalf("failed to parse nvim output %q: %v", string(outputBytes), err)
+	}
+	if res.Help != "SUCCESS" {
+		t.Errorf("Neovim help verification failed: %s", res.Help)
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil || homeDir == "" {
+		t.Fatalf("failed to get non-empty user home directory: %v", err)
+	}
+	vars := normalizeVars{
+		TestDir:    tmpDir,
+		HomeDir:    homeDir,
+		VimRuntime: res.Vimruntime,
+	}
+	gotRTP := normalizeRuntimepath(t, res.Runtimepath, vars)
+	expectedRTP := normalizeRuntimepath(t, expectedSimpleRuntimepath(tmpDir, res.BeforeRuntimepath), vars)
+	if strings.Join(gotRTP, ",") != strings.Join(expectedRTP, ",") {
+		t.Errorf("observed runtimepath projection does not match expected model!\nGot:\n%s\nExpected:\n%s",
+			strings.Join(gotRTP, "\n"), strings.Join(expectedRTP, "\n"))
+	}
+}
+
+func TestE2E_VimStaticRuntimepathProjection(t *testing.T) {
+	// Skip test if vim is not installed
+	if _, err := exec.LookPath("vim"); err != nil {
+		t.Skip("vim not installed")
+	}
+
+	tmpDir := t.TempDir()
+
+	// Copy fixture into isolated temporary directory for reproducibility
+	fixtureSrcAbs, err := filepath.Abs(filepath.Join("testdata", "simple"))
+	if err != nil {
+		t.Fatalf("failed to get absolute path of fixture source: %v", err)
+	}
+	fixtureDst := filepath.Join(tmpDir, "simple")
+	if err := copyDir(fixtureSrcAbs, fixtureDst); err != nil {
+		t.Fatalf("failed to copy fixture directory: %v", err)
+	}
+	t.Setenv("E2E_PLUGINS_DIR", filepath.Join(fixtureDst, "plugins"))
+
+	cfg := &hariti.HaritiConfig{
+		Paths: hariti.Paths{
+			ConfigFile: filepath.Join(fixtureDst, "bundles.hariti"),
+			ConfigDir:  filepath.Join(tmpDir, "config"),
+			DataDir:    filepath.Join(tmpDir, "data"),
+		},
+		Writer:    os.Stdout,
+		ErrWriter: os.Stderr,
+	}
+	har := hariti.NewHariti(cfg)
+	if err := har.SetupManagedDirectory(); err != nil {
+		t.Fatalf("failed to setup managed directories: %v", err)
+	}
+
+	g, err := dsl.LoadGraph(cfg.Paths.ConfigFile)
+	if err != nil {
+		t.Fatalf("failed to load graph: %v", err)
+	}
+	ctx := context.Background()
+	if _, err := har.Sync(ctx, g, hariti.SyncOptions{}); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if _, err := har.Deploy(ctx, g, hariti.DeployOptions{Projections: []hariti.Projection{hariti.ProjectionStatic}}); err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+
+	// Spawn Vim in Ex silent mode to source packadd.static.vim and observe runtimepath
+	currentPath := filepath.ToSlash(har.CurrentSymlinkPath())
+	runVimScript := filepath.Join(tmpDir, "run.vim")
+	outFile := filepath.Join(tmpDir, "output.txt")
+	scriptContent := fmt.Sprintf(`set nomore
+let before_runtimepath = &runtimepath
+source %s/packadd.static.vim
+let after_runtimepath = &runtimepath
+try
+  help local-plugin
+  let help_ok = "SUCCESS"
+catch
+  let help_ok = "FAILURE: " . v:exception
+endtry
+let result = {
+\ 'before_runtimepath': before_runtimepath,
+\ 'runtimepath': after_runtimepath,
+\ 'vimruntime': $VIMRUNTIME,
+\ 'help': help_ok,
+\}
+call writefile([json_encode(result)], '%s')
+qa!
+`, currentPath, filepath.ToSlash(outFile))
+	if err := os.WriteFile(runVimScript, []byte(scriptContent), 0644); err != nil {
+		t.Fatalf("failed to write run.vim script: %v", err)
+	}
+
+	cmd := exec.Command("vim", "-Nu", "NONE", "-n", "-e", "-s", "-S", runVimScript)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("failed to execute vim: %v\nOutput: %s", err, string(out))
+	}
+
+	outputBytes, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatalf("failed to read vim output file: %v", err)
+	}
+	var res struct {
+		BeforeRuntimepath string `json:"before_runtimepath"`
+		Runtimepath       string `json:"runtimepath"`
+		Vimruntime        string `json:"vimruntime"`
+		Help              string `json:"help"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(string(outputBytes))), &res); err != nil {
+		t.Fatalf("failed to parse vim output %q: %v", string(outputBytes), err)
+	}
+	if res.Help != "SUCCESS" {
+		t.Errorf("Vim help verification failed: %s", res.Help)
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil || homeDir == "" {
+		t.Fatalf("failed to get non-empty user home directory: %v", err)
+	}
+	vars := normalizeVars{
+		TestDir:    tmpDir,
+		HomeDir:    homeDir,
+		VimRuntime: res.Vimruntime,
+	}
+	gotRTP := normalizeRuntimepath(t, res.Runtimepath, vars)
+	expectedRTP := normalizeRuntimepath(t, expectedSimpleRuntimepath(tmpDir, res.BeforeRuntimepath), vars)
+	if strings.Join(gotRTP, ",") != strings.Join(expectedRTP, ",") {
+		t.Errorf("observed runtimepath projection does not match expected model!\nGot:\n%s\nExpected:\n%s",
+			strings.Join(gotRTP, "\n"), strings.Join(expectedRTP, "\n"))
+	}
+}
@@ -0,0 +1,2 @@
+let g:cmd_plugin_loaded = 1
+command! -nargs=1 -range Greet let g:greeting = "hello " . <q-args> | let g:greet_range = [<range>, <line1>, <line2>]
@@ -0,0 +1 @@
+let g:disabled_plugin_loaded = 1
@@ -0,0 +1 @@
+let g:eager_plugin_loaded = 1
@@ -0,0 +1,2 @@
+let g:event_plugin_loaded = 1
+autocmd InsertEnter * let g:insert_entered = 1
@@ -0,0 +1 @@
+let b:ft_plugin_applied = 1
@@ -0,0 +1 @@
+let g:ft_plugin_loaded = 1
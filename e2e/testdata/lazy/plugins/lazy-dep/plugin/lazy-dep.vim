@@ -0,0 +1 @@
+let g:lazy_dep_loaded = 1
@@ -0,0 +1,2 @@
+let g:map_plugin_loaded = 1
+nnoremap <silent> <Plug>(hariti-test) :<C-U>let g:mapped = get(g:, "mapped", 0) + 1<CR>
@@ -0,0 +1,302 @@
+package hariti
+
+import (
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/kamichidu/go-hariti/graph"
+)
+
+// GCOptions holds the retention policies for GC. A generation is kept when
+// any policy keeps it; the current generation and labeled generations are
+// always kept.
+type GCOptions struct {
+	// KeepLast keeps the N most recently created generations.
+	KeepLast int
+	// KeepNewerThan keeps generations created within the duration. Zero
+	// disables the policy.
+	KeepNewerThan time.Duration
+	// DryRun reports what would be removed without removing anything.
+	DryRun bool
+}
+
+// GCKind tells what kind of data directory entry a GCEntry is.
+type GCKind string
+
+const (
+	GCKindGeneration GCKind = "generation"
+	GCKindRepository GCKind = "repository"
+	GCKindMetadata   GCKind = "metadata"
+	GCKindStore      GCKind = "store"
+	GCKindStaging    GCKind = "staging"
+)
+
+// GCEntry is a data directory entry removed (or, in a dry run, to be removed)
+// by GC.
+type GCEntry struct {
+	Kind  GCKind `json:"kind"`
+	Name  string `json:"name"`
+	Path  string `json:"path"`
+	Bytes int64  `json:"bytes"`
+}
+
+// GCReport is the outcome of GC.
+type GCReport struct {
+	DryRun     bool      `json:"dry_run"`
+	Removed    []GCEntry `json:"removed"`
+	FreedBytes int64     `json:"freed_bytes"`
+}
+
+// GC removes the generations no retention policy keeps, the store entries no
+// remaining generation refers to, the repository caches and repository
+// metadata no bundle of the graph refers to, and the staging leftovers of
+// interrupted runs.
+func (h *Hariti) GC(g *graph.Graph, opts GCOptions) (*GCReport, error) {
+	if opts.KeepLast < 0 {
+		return nil, fmt.Errorf("invalid keep-last: %d", opts.KeepLast)
+	}
+	if opts.KeepNewerThan < 0 {
+		return nil, fmt.Errorf("invalid keep-newer-than: %s", opts.KeepNewerThan)
+	}
+
+	// Staging leftovers and unreferenced store entries are only known while
+	// no deploy is running
+	unlock, err := h.lockDataDir()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	var candidates []GCEntry
+
+	generations, err := h.expiredGenerations(opts)
+	if err != nil {
+		return nil, err
+	}
+	candidates = append(candidates, generations...)
+
+	unreferenced, err := h.unreferencedStoreEntries(generations, opts.DryRun)
+	if err != nil {
+		return nil, err
+	}
+	candidates = append(candidates, unreferenced...)
+
+	orphans, err := h.orphanedCaches(g)
+	if err != nil {
+		return nil, err
+	}
+	candidates = append(candidates, orphans...)
+
+	leftovers, err := h.stagingLeftovers()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range leftovers {
+		candidates = append(candidates, GCEntry{
+			Kind: GCKindStaging,
+			Name: name,
+			Path: filepath.Join(h.StagingDir(), name),
+		})
+	}
+
+	report := &GCReport{
+		DryRun:  opts.DryRun,
+		Removed: []GCEntry{},
+	}
+	for _, entry := range candidates {
+		size, err := diskUsage(entry.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to measure %s %s: %w", entry.Kind, entry.Name, err)
+		}
+		entry.Bytes = size
+
+		if opts.DryRun {
+			h.logger.Debugf("would remove %s %s", entry.Kind, entry.Name)
+		} else {
+			if err := os.RemoveAll(entry.Path); err != nil {
+				return report, fmt.Errorf("failed to remove %s %s: %w", entry.Kind, entry.Name, err)
+			}
+			h.logger.Debugf("removed %s %s", entry.Kind, entry.Name)
+		}
+		report.Removed = append(report.Removed, entry)
+		report.FreedBytes += size
+	}
+	return report, nil
+}
+
+// expiredGenerations lists the generations no retention policy keeps.
+func (h *Hariti) expiredGenerations(opts GCOptions) ([]GCEntry, error) {
+	generations, err := h.Generations()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var expired []GCEntry
+	for i, gen := range generations {
+		if gen.Current || len(gen.Labels) > 0 || i >= len(generations)-opts.KeepLast {
+			continue
+		}
+		if opts.KeepNewerThan > 0 {
+			createdAt, err := time.Parse(time.RFC3339Nano, gen.CreatedAt)
+			if err != nil {
+				h.logger.Warnf("kept generation %s: unparsable creation time %q", gen.ID, gen.CreatedAt)
+				continue
+			}
+			if now.Sub(createdAt) < opts.KeepNewerThan {
+				continue
+			}
+		}
+		expired = append(expired, GCEntry{
+			Kind: GCKindGeneration,
+			Name: gen.ID,
+			Path: filepath.Join(h.GenerationsDir(), gen.ID),
+		})
+	}
+	return expired, nil
+}
+
+// unreferencedStoreEntries lists the store entries that no generation other
+// than the expired ones refers to. A generation refers to its entries from
+// the time it is staged, so references to a generation still in staging are
+// live as well. References of kept entries to generations that no longer
+// exist are dropped unless dryRun is set.
+func (h *Hariti) unreferencedStoreEntries(expired []GCEntry, dryRun bool) ([]GCEntry, error) {
+	removed := make(map[string]bool, len(expired))
+	for _, entry := range expired {
+		removed[entry.Name] = true
+	}
+
+	entries, err := h.storeEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	var unreferenced []GCEntry
+	for _, entry := range entries {
+		live := 0
+		var stale []string
+		for _, ref := range entry.Refs {
+			if removed[ref] {
+				continue
+			}
+			exists, err := h.generationExists(ref)
+			if err != nil {
+				return nil, err
+			}
+			if !exists {
+				stale = append(stale, ref)
+				continue
+			}
+			live++
+		}
+
+		if live == 0 {
+			unreferenced = append(unreferenced, GCEntry{
+				Kind: GCKindStore,
+				Name: entry.Key,
+				Path: entry.Path,
+			})
+			continue
+		}
+		if dryRun {
+			continue
+		}
+		for _, ref := range stale {
+			if err := os.Remove(filepath.Join(entry.Path, "refs", ref)); err != nil && !os.IsNotExist(err) {
+				return nil, fmt.Errorf("failed to drop reference of store entry %s: %w", entry.Key, err)
+			}
+		}
+	}
+	return unreferenced, nil
+}
+
+// generationExists reports whether a generation is published or staged.
+func (h *Hariti) generationExists(genID string) (bool, error) {
+	for _, dir := range []string{h.GenerationsDir(), h.StagingDir()} {
+		if _, err := os.Stat(filepath.Join(dir, genID)); err == nil {
+			return true, nil
+		} else if !os.IsNotExist(err) {
+			return false, fmt.Errorf("failed to inspect generation %s: %w", genID, err)
+		}
+	}
+	return false, nil
+}
+
+// orphanedCaches lists the repository caches and repository metadata files
+// that belong to no bundle of the graph.
+func (h *Hariti) orphanedCaches(g *graph.Graph) ([]GCEntry, error) {
+	rg := h.newRuntimeGraph(g)
+	cachePaths := make(map[string]bool)
+	metadataNames := make(map[string]bool)
+	for _, bundle := range rg.bundles {
+		if bundle.Source.Type == graph.SourceTypeRemote {
+			cachePaths[filepath.Clean(bundle.Source.Path)] = true
+		}
+		metadataNames[url.QueryEscape(bundle.ID)] = true
+	}
+
+	var orphans []GCEntry
+	scan := func(dir string, kind GCKind, referenced func(name, path string) bool) error {
+		dirEntries, err := os.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return fmt.Errorf("failed to read %s directory: %w", kind, err)
+		}
+		for _, dirEntry := range dirEntries {
+			path := filepath.Join(dir, dirEntry.Name())
+			if referenced(dirEntry.Name(), path) {
+				continue
+			}
+			name, err := url.QueryUnescape(dirEntry.Name())
+			if err != nil {
+				name = dirEntry.Name()
+			}
+			orphans = append(orphans, GCEntry{
+				Kind: kind,
+				Name: name,
+				Path: path,
+			})
+		}
+		return nil
+	}
+
+	if err := scan(h.RepositoriesDir(), GCKindRepository, func(_, path string) bool {
+		return cachePaths[path]
+	}); err != nil {
+		return nil, err
+	}
+	if err := scan(h.MetadataDir(), GCKindMetadata, func(name, _ string) bool {
+		return metadataNames[name]
+	}); err != nil {
+		return nil, err
+	}
+	return orphans, nil
+}
+
+// diskUsage sums the sizes of the files under path, without following
+// symbolic links.
+func diskUsage(path string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	return size, err
+}
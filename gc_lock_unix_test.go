@@ -0,0 +1,79 @@
+//go:build !windows
+// +build !windows
+
+package hariti_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/kamichidu/go-hariti"
+	"github.com/kamichidu/go-hariti/graph"
+)
+
+func TestHariti_GC_WaitsForDataDirLock(t *testing.T) {
+	tmpDir := t.TempDir()
+	har := hariti.NewHariti(&hariti.HaritiConfig{
+		Paths: hariti.Paths{
+			ConfigFile: filepath.Join(tmpDir, "config", "bundles.hariti"),
+			ConfigDir:  filepath.Join(tmpDir, "config"),
+			DataDir:    filepath.Join(tmpDir, "data"),
+		},
+		Writer:    io.Discard,
+		ErrWriter: io.Discard,
+	})
+	if err := har.SetupManagedDirectory(); err != nil {
+		t.Fatalf("failed to setup managed directory: %v", err)
+	}
+
+	// A deploy in progress in another process holds the lock while it stages
+	lock, err := os.OpenFile(har.DataDirLockPath(), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		t.Fatalf("failed to open data directory lock: %v", err)
+	}
+	defer func() {
+		_ = lock.Close()
+	}()
+	if err := syscall.Flock(int(lock.Fd()), syscall.LOCK_EX); err != nil {
+		t.Fatalf("failed to lock data directory: %v", err)
+	}
+	stagedDir := filepath.Join(har.StagingDir(), "gen-in-progress")
+	if err := os.MkdirAll(stagedDir, 0755); err != nil {
+		t.Fatalf("failed to create staging entry: %v", err)
+	}
+
+	done := make(chan *hariti.GCReport, 1)
+	go func() {
+		report, err := har.GC(&graph.Graph{}, hariti.GCOptions{})
+		if err != nil {
+			t.Errorf("GC failed: %v", err)
+		}
+		done <- report
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("expected GC to wait for the data directory lock")
+	case <-time.After(200 * time.Millisecond):
+	}
+	if _, err := os.Stat(stagedDir); err != nil {
+		t.Fatalf("expected the staging entry of the running deploy to be kept: %v", err)
+	}
+
+	// The deploy is interrupted without cleaning up, leaving its entry stale
+	if err := syscall.Flock(int(lock.Fd()), syscall.LOCK_UN); err != nil {
+		t.Fatalf("failed to unlock data directory: %v", err)
+	}
+	select {
+	case report := <-done:
+		if report == nil || len(report.Removed) != 1 || report.Removed[0].Kind != hariti.GCKindStaging {
+			t.Errorf("expected the staging leftover to be removed, got %+v", report)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected GC to proceed once the lock is released")
+	}
+}
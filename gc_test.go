@@ -0,0 +1,196 @@
+package hariti_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kamichidu/go-hariti"
+	"github.com/kamichidu/go-hariti/graph"
+)
+
+func TestHariti_GC(t *testing.T) {
+	tmpDir := t.TempDir()
+	dataDir := filepath.Join(tmpDir, "data")
+
+	har := hariti.NewHariti(&hariti.HaritiConfig{
+		Paths: hariti.Paths{
+			ConfigFile: filepath.Join(tmpDir, "config", "bundles.hariti"),
+			ConfigDir:  filepath.Join(tmpDir, "config"),
+			DataDir:    dataDir,
+		},
+		Writer:    io.Discard,
+		ErrWriter: io.Discard,
+	})
+	if err := har.SetupManagedDirectory(); err != nil {
+		t.Fatalf("failed to setup managed directory: %v", err)
+	}
+
+	writeFile := func(path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+	}
+
+	// 1. Five generations created an hour apart, the oldest being current
+	now := time.Now()
+	var genIDs []string
+	for i := range 5 {
+		genID := "gen-" + string(rune('a'+i))
+		genIDs = append(genIDs, genID)
+		meta, err := json.Marshal(hariti.GenerationMetadata{
+			ID:        genID,
+			CreatedAt: now.Add(time.Duration(i-4) * time.Hour).Format(time.RFC3339Nano),
+		})
+		if err != nil {
+			t.Fatalf("failed to serialize metadata: %v", err)
+		}
+		genDir := filepath.Join(har.GenerationsDir(), genID)
+		writeFile(filepath.Join(genDir, "metadata.json"), string(meta))
+		writeFile(filepath.Join(genDir, "lock.json"), `{"bundles":[]}`)
+		writeFile(filepath.Join(genDir, "packadd.vim"), "")
+		writeFile(filepath.Join(genDir, "pack", "hariti", "opt", "plugin", "plugin.vim"), strings.Repeat("x", 1000))
+	}
+	if err := os.Symlink(filepath.Join("generations", genIDs[0]), har.CurrentSymlinkPath()); err != nil {
+		t.Fatalf("failed to create current link: %v", err)
+	}
+
+	// 2. Caches of a configured bundle and of a removed one
+	remoteURL, _ := url.Parse("https://example.com/my/plugin")
+	g := &graph.Graph{
+		Bundles: []graph.Bundle{
+			{
+				ID:     "my/plugin",
+				Source: graph.Source{Type: graph.SourceTypeRemote, URL: remoteURL},
+			},
+		},
+	}
+	for _, id := range []string{"my/plugin", "removed/plugin"} {
+		writeFile(filepath.Join(har.RepositoriesDir(), url.QueryEscape(id), "README"), strings.Repeat("y", 500))
+		writeFile(filepath.Join(har.MetadataDir(), url.QueryEscape(id)), `{}`)
+	}
+
+	// 3. Store entries referenced by expiring, kept and vanished generations
+	storeRefs := map[string][]string{
+		"entry-expired": {genIDs[1]},
+		"entry-shared":  {genIDs[1], genIDs[4]},
+		"entry-stale":   {"vanished", genIDs[3]},
+		"entry-staged":  {"gen-interrupted"},
+	}
+	for key, refs := range storeRefs {
+		writeFile(filepath.Join(har.StoreDir(), key, "bundle", "plugin.vim"), strings.Repeat("z", 200))
+		for _, ref := range refs {
+			writeFile(filepath.Join(har.StoreDir(), key, "refs", ref), "")
+		}
+	}
+
+	// 4. Leftovers of an interrupted deploy
+	writeFile(filepath.Join(har.StagingDir(), "gen-interrupted", "packadd.vim"), "")
+
+	exists := func(path string) bool {
+		_, err := os.Lstat(path)
+		return err == nil
+	}
+	removedNames := func(report *hariti.GCReport) []string {
+		var names []string
+		for _, entry := range report.Removed {
+			names = append(names, string(entry.Kind)+":"+entry.Name)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	// Step 1: A dry run reports without removing anything
+	report, err := har.GC(g, hariti.GCOptions{KeepLast: 2, DryRun: true})
+	if err != nil {
+		t.Fatalf("GC dry run failed: %v", err)
+	}
+	want := []string{
+		"generation:" + genIDs[1],
+		"generation:" + genIDs[2],
+		"metadata:removed/plugin",
+		"repository:removed/plugin",
+		"staging:gen-interrupted",
+		"store:entry-expired",
+	}
+	if got := removedNames(report); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("expected dry run to report %v, got %v", want, got)
+	}
+	if !report.DryRun || report.FreedBytes < 2*1000+500+200 {
+		t.Errorf("unexpected dry run report: %+v", report)
+	}
+	for _, entry := range report.Removed {
+		if !exists(entry.Path) {
+			t.Errorf("dry run removed %s", entry.Path)
+		}
+	}
+
+	// Step 2: Retention policies are combined, current is never removed
+	report, err = har.GC(g, hariti.GCOptions{KeepLast: 1, KeepNewerThan: 150 * time.Minute})
+	if err != nil {
+		t.Fatalf("GC failed: %v", err)
+	}
+	want = []string{
+		"generation:" + genIDs[1],
+		"metadata:removed/plugin",
+		"repository:removed/plugin",
+		"staging:gen-interrupted",
+		"store:entry-expired",
+	}
+	if got := removedNames(report); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("expected GC to remove %v, got %v", want, got)
+	}
+	for i, genID := range genIDs {
+		if got := exists(filepath.Join(har.GenerationsDir(), genID)); got != (i != 1) {
+			t.Errorf("unexpected existence of generation %s: %v", genID, got)
+		}
+	}
+	if !exists(filepath.Join(har.RepositoriesDir(), url.QueryEscape("my/plugin"))) || !exists(filepath.Join(har.MetadataDir(), url.QueryEscape("my/plugin"))) {
+		t.Error("expected the caches of the configured bundle to be kept")
+	}
+	if exists(filepath.Join(har.RepositoriesDir(), url.QueryEscape("removed/plugin"))) || exists(filepath.Join(har.MetadataDir(), url.QueryEscape("removed/plugin"))) {
+		t.Error("expected the caches of the removed bundle to be pruned")
+	}
+
+	if exists(filepath.Join(har.StoreDir(), "entry-expired")) {
+		t.Error("expected the unreferenced store entry to be removed")
+	}
+	if !exists(filepath.Join(har.StoreDir(), "entry-shared")) || !exists(filepath.Join(har.StoreDir(), "entry-stale")) {
+		t.Error("expected the referenced store entries to be kept")
+	}
+	if exists(filepath.Join(har.StoreDir(), "entry-stale", "refs", "vanished")) {
+		t.Error("expected the reference to a vanished generation to be dropped")
+	}
+
+	if exists(filepath.Join(har.StagingDir(), "gen-interrupted")) {
+		t.Error("expected the staging leftover to be removed")
+	}
+	if !exists(filepath.Join(har.StoreDir(), "entry-staged")) {
+		t.Error("expected the store entry referenced by a staged generation to be kept")
+	}
+
+	// Step 3: Once the staged generation is gone, its store entries are unreferenced
+	report, err = har.GC(g, hariti.GCOptions{KeepLast: 1, KeepNewerThan: 150 * time.Minute})
+	if err != nil {
+		t.Fatalf("GC failed: %v", err)
+	}
+	want = []string{"store:entry-staged"}
+	if got := removedNames(report); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("expected GC to remove %v, got %v", want, got)
+	}
+
+	// Step 4: Negative policies are rejected
+	if _, err := har.GC(g, hariti.GCOptions{KeepLast: -1}); err == nil {
+		t.Error("expected negative keep-last to fail, got nil")
+	}
+}
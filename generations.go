@@ -0,0 +1,178 @@
+package hariti
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/kamichidu/go-hariti/graph"
+)
+
+// Generation summarizes a deployed generation.
+type Generation struct {
+	ID          string `json:"id"`
+	CreatedAt   string `json:"created_at"`
+	LockHash    string `json:"lock_hash"`
+	BundleCount int    `json:"bundle_count"`
+	Current     bool   `json:"current"`
+	// Labels are the names given to the generation with TagGeneration.
+	Labels []string `json:"labels,omitempty"`
+}
+
+// GenerationDetail is a generation together with its lock snapshot,
+// runtimepath projection and the records of how it was deployed.
+type GenerationDetail struct {
+	Generation
+	Lock    Lockfile `json:"lock"`
+	Packadd string   `json:"packadd"`
+
+	HaritiVersion string             `json:"hariti_version,omitempty"`
+	VimVersion    string             `json:"vim_version,omitempty"`
+	Graph         *graph.Graph       `json:"graph,omitempty"`
+	Bundles       []GenerationBundle `json:"bundles,omitempty"`
+}
+
+// ErrGenerationNotFound is returned for generation IDs that do not name a
+// deployed generation.
+var ErrGenerationNotFound = errors.New("generation not found")
+
+// CurrentGeneration returns the ID of the generation the current link points
+// to, or an empty string when nothing has been deployed yet.
+func (h *Hariti) CurrentGeneration() (string, error) {
+	target, err := os.Readlink(h.CurrentSymlinkPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read current generation link: %w", err)
+	}
+	return filepath.Base(target), nil
+}
+
+// Generations lists the deployed generations, oldest first. Directories
+// without generation metadata are not generations and are left out.
+func (h *Hariti) Generations() ([]Generation, error) {
+	current, err := h.CurrentGeneration()
+	if err != nil {
+		return nil, err
+	}
+
+	dirEntries, err := os.ReadDir(h.GenerationsDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Generation{}, nil
+		}
+		return nil, fmt.Errorf("failed to read generations directory: %w", err)
+	}
+
+	generations := make([]Generation, 0, len(dirEntries))
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.IsDir() {
+			continue
+		}
+		gen, _, err := h.readGeneration(dirEntry.Name())
+		if err != nil {
+			if errors.Is(err, ErrGenerationNotFound) {
+				h.logger.Debugf("skipped %s: no generation metadata", dirEntry.Name())
+				continue
+			}
+			return nil, err
+		}
+		gen.Current = gen.ID == current
+		generations = append(generations, *gen)
+	}
+
+	sort.SliceStable(generations, func(i, j int) bool {
+		ti, erri := time.Parse(time.RFC3339Nano, generations[i].CreatedAt)
+		tj, errj := time.Parse(time.RFC3339Nano, generations[j].CreatedAt)
+		if erri == nil && errj == nil && !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return generations[i].ID < generations[j].ID
+	})
+	return generations, nil
+}
+
+// InspectGeneration reads the metadata, lock snapshot and packadd.vim of a
+// deployed generation.
+func (h *Hariti) InspectGeneration(id string) (*GenerationDetail, error) {
+	gen, lock, err := h.readGeneration(id)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := h.readGenerationMetadata(id)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := h.CurrentGeneration()
+	if err != nil {
+		return nil, err
+	}
+	gen.Current = gen.ID == current
+
+	packadd, err := os.ReadFile(filepath.Join(h.GenerationsDir(), id, "packadd.vim"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read packadd.vim of generation %s: %w", id, err)
+	}
+
+	return &GenerationDetail{
+		Generation: *gen,
+		Lock:       *lock,
+		Packadd:    string(packadd),
+
+		HaritiVersion: meta.HaritiVersion,
+		VimVersion:    meta.VimVersion,
+		Graph:         meta.Graph,
+		Bundles:       meta.Bundles,
+	}, nil
+}
+
+// readGeneration reads the metadata, lock snapshot and labels of a generation.
+func (h *Hariti) readGeneration(id string) (*Generation, *Lockfile, error) {
+	meta, err := h.readGenerationMetadata(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lock, err := readLockfile(filepath.Join(h.GenerationsDir(), id, "lock.json"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read lock snapshot of generation %s: %w", id, err)
+	}
+	labels, err := h.readLabels(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &Generation{
+		ID:          id,
+		CreatedAt:   meta.CreatedAt,
+		LockHash:    meta.LockHash,
+		BundleCount: len(lock.Bundles),
+		Labels:      labels,
+	}, lock, nil
+}
+
+// readGenerationMetadata reads the metadata.json of a generation.
+func (h *Hariti) readGenerationMetadata(id string) (*GenerationMetadata, error) {
+	if id == "" || id == "." || id == ".." || filepath.Base(id) != id {
+		return nil, fmt.Errorf("invalid generation ID %q", id)
+	}
+
+	metaBytes, err := os.ReadFile(filepath.Join(h.GenerationsDir(), id, "metadata.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrGenerationNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to read metadata of generation %s: %w", id, err)
+	}
+	meta := new(GenerationMetadata)
+	if err := json.Unmarshal(metaBytes, meta); err != nil {
+		return nil, fmt.Errorf("failed to parse metadata of generation %s: %w", id, err)
+	}
+	return meta, nil
+}
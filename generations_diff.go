@@ -0,0 +1,327 @@
+package hariti
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/kamichidu/go-hariti/graph"
+	"github.com/kamichidu/go-hariti/vcs"
+)
+
+// GenerationDiff describes how two generations differ.
+type GenerationDiff struct {
+	From    string       `json:"from"`
+	To      string       `json:"to"`
+	Bundles []BundleDiff `json:"bundles"`
+	// Packadd is a unified diff of the packadd.vim projections, empty when
+	// they are identical.
+	Packadd string `json:"packadd"`
+}
+
+// BundleDiff describes how a bundle differs between two generations. The
+// enable_if and build step fields are only compared when both generations
+// recorded their resolved graph.
+type BundleDiff struct {
+	BundleID    string     `json:"id"`
+	Type        ChangeType `json:"type"`
+	OldRevision string     `json:"old_revision,omitempty"`
+	NewRevision string     `json:"new_revision,omitempty"`
+	// Commits is the number of commits gained between the old and the new
+	// revision, and Dropped the number of commits of the old revision the new
+	// one no longer contains. Both are -1 when the repository cache cannot
+	// tell, and 0 when the revision did not change.
+	Commits int `json:"commits"`
+	Dropped int `json:"dropped"`
+
+	OldSource string `json:"old_source,omitempty"`
+	NewSource string `json:"new_source,omitempty"`
+
+	EnableIfChanged bool   `json:"enable_if_changed,omitempty"`
+	OldEnableIf     string `json:"old_enable_if,omitempty"`
+	NewEnableIf     string `json:"new_enable_if,omitempty"`
+
+	BuildChanged bool              `json:"build_changed,omitempty"`
+	OldBuild     []graph.BuildStep `json:"old_build,omitempty"`
+	NewBuild     []graph.BuildStep `json:"new_build,omitempty"`
+}
+
+// DiffGenerations compares two deployed generations: the bundles added or
+// removed, revision, source, enable_if and build step changes, and the
+// packadd.vim projections. Commit counts are read from the repository cache.
+func (h *Hariti) DiffGenerations(ctx context.Context, from, to string) (*GenerationDiff, error) {
+	oldGen, err := h.InspectGeneration(from)
+	if err != nil {
+		return nil, err
+	}
+	newGen, err := h.InspectGeneration(to)
+	if err != nil {
+		return nil, err
+	}
+
+	oldBundles := graphBundles(oldGen.Graph)
+	newBundles := graphBundles(newGen.Graph)
+	compareGraph := oldGen.Graph != nil && newGen.Graph != nil
+
+	oldEntries := make(map[string]LockfileEntry, len(oldGen.Lock.Bundles))
+	for _, entry := range oldGen.Lock.Bundles {
+		oldEntries[entry.ID] = entry
+	}
+	newEntries := make(map[string]bool, len(newGen.Lock.Bundles))
+
+	diff := &GenerationDiff{
+		From:    oldGen.ID,
+		To:      newGen.ID,
+		Bundles: []BundleDiff{},
+		Packadd: unifiedDiff(oldGen.ID+"/packadd.vim", newGen.ID+"/packadd.vim", oldGen.Packadd, newGen.Packadd),
+	}
+	for _, entry := range newGen.Lock.Bundles {
+		newEntries[entry.ID] = true
+		oldEntry, exists := oldEntries[entry.ID]
+		if !exists {
+			diff.Bundles = append(diff.Bundles, BundleDiff{
+				BundleID:    entry.ID,
+				Type:        ChangeAdded,
+				NewRevision: entry.Revision,
+			})
+			continue
+		}
+
+		change := BundleDiff{
+			BundleID: entry.ID,
+			Type:     ChangeUpdated,
+		}
+		changed := false
+		if oldEntry.Revision != entry.Revision {
+			changed = true
+			change.OldRevision = oldEntry.Revision
+			change.NewRevision = entry.Revision
+			change.Commits, change.Dropped = h.countGenerationCommits(ctx, entry, newBundles[entry.ID], oldEntry.Revision, entry.Revision)
+			if change.Dropped > 0 {
+				change.Type = ChangeRewound
+			}
+		}
+		if oldEntry.Source != entry.Source {
+			changed = true
+			change.OldSource = oldEntry.Source
+			change.NewSource = entry.Source
+		}
+		if compareGraph {
+			oldBundle, newBundle := oldBundles[entry.ID], newBundles[entry.ID]
+			if oldBundle.EnableIf != newBundle.EnableIf {
+				changed = true
+				change.EnableIfChanged = true
+				change.OldEnableIf = oldBundle.EnableIf
+				change.NewEnableIf = newBundle.EnableIf
+			}
+			if !reflect.DeepEqual(buildStepsFor(oldBundle), buildStepsFor(newBundle)) {
+				changed = true
+				change.BuildChanged = true
+				change.OldBuild = oldBundle.Build
+				change.NewBuild = newBundle.Build
+			}
+		}
+		if changed {
+			diff.Bundles = append(diff.Bundles, change)
+		}
+	}
+
+	for _, entry := range oldGen.Lock.Bundles {
+		if newEntries[entry.ID] {
+			continue
+		}
+		diff.Bundles = append(diff.Bundles, BundleDiff{
+			BundleID:    entry.ID,
+			Type:        ChangeRemoved,
+			OldRevision: entry.Revision,
+		})
+	}
+	return diff, nil
+}
+
+func graphBundles(g *graph.Graph) map[string]graph.Bundle {
+	bundles := make(map[string]graph.Bundle)
+	if g == nil {
+		return bundles
+	}
+	for _, bundle := range g.Bundles {
+		bundles[bundle.ID] = bundle
+	}
+	return bundles
+}
+
+// countGenerationCommits counts the commits gained and dropped between two
+// revisions of a locked bundle, or returns -1 for both when the repository
+// cache cannot tell. The bundle recorded in the generation graph is used when
+// there is one; otherwise the bundle is rebuilt from the lockfile entry.
+func (h *Hariti) countGenerationCommits(ctx context.Context, entry LockfileEntry, bundle graph.Bundle, from, to string) (int, int) {
+	if bundle.ID == "" {
+		u, err := url.Parse(entry.Source)
+		if err != nil || u.Scheme == "" {
+			return -1, -1
+		}
+		bundle = h.resolveBundle(graph.Bundle{
+			ID:     entry.ID,
+			Source: graph.Source{Type: graph.SourceTypeRemote, URL: u},
+		})
+	}
+	if bundle.Source.Type != graph.SourceTypeRemote {
+		return -1, -1
+	}
+	v := vcs.Detect(bundle.Source.URL)
+	if v == nil {
+		return -1, -1
+	}
+
+	var vcsOutput bytes.Buffer
+	vcsCtx := vcs.WithLogger(ctx, h.logger)
+	vcsCtx = vcs.WithWriter(vcsCtx, &vcsOutput)
+	vcsCtx = vcs.WithErrWriter(vcsCtx, &vcsOutput)
+
+	commits, err := v.CountCommits(vcsCtx, bundle, from, to)
+	if err != nil {
+		h.logger.Warnf("failed to count commits for bundle %s: %v", bundle.ID, err)
+		return -1, -1
+	}
+	dropped, err := v.CountCommits(vcsCtx, bundle, to, from)
+	if err != nil {
+		h.logger.Warnf("failed to count commits for bundle %s: %v", bundle.ID, err)
+		return -1, -1
+	}
+	if commits < 0 || dropped < 0 {
+		h.logger.Debugf("history between %s and %s is not cached for bundle %s", from, to, bundle.ID)
+		return -1, -1
+	}
+	return commits, dropped
+}
+
+// diffContext is the number of unchanged lines shown around each change of a
+// unified diff.
+const diffContext = 3
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// unifiedDiff returns a unified diff of the lines of a and b, or an empty
+// string when they are identical.
+func unifiedDiff(fromName, toName, a, b string) string {
+	if a == b {
+		return ""
+	}
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	// Number of lines of a and b before each op
+	aPos := make([]int, len(ops)+1)
+	bPos := make([]int, len(ops)+1)
+	for i, op := range ops {
+		aPos[i+1], bPos[i+1] = aPos[i], bPos[i]
+		if op.kind != '+' {
+			aPos[i+1]++
+		}
+		if op.kind != '-' {
+			bPos[i+1]++
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+	for i := 0; i < len(ops); {
+		for i < len(ops) && ops[i].kind == ' ' {
+			i++
+		}
+		if i == len(ops) {
+			break
+		}
+
+		// Extend the hunk over changes separated by less than twice the context
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*diffContext {
+				break
+			}
+			end = run
+		}
+		start := max(i-diffContext, 0)
+		end = min(end+diffContext, len(ops))
+
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(aPos[start], aPos[end]-aPos[start]), hunkRange(bPos[start], bPos[end]-bPos[start]))
+		for _, op := range ops[start:end] {
+			fmt.Fprintf(&out, "%c%s\n", op.kind, op.line)
+		}
+		i = end
+	}
+	return out.String()
+}
+
+func hunkRange(pos, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", pos)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", pos+1)
+	}
+	return fmt.Sprintf("%d,%d", pos+1, count)
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines computes an edit script turning a into b from their longest
+// common subsequence.
+func diffLines(a, b []string) []diffOp {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
@@ -0,0 +1,289 @@
+package hariti_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kamichidu/go-hariti"
+	"github.com/kamichidu/go-hariti/graph"
+	"github.com/kamichidu/go-hariti/vcs"
+	_ "github.com/kamichidu/go-hariti/vcs/git"
+)
+
+func TestHariti_Generations(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	xdgHome := filepath.Join(tmpDir, "xdg_home")
+	_ = os.Setenv("XDG_DATA_HOME", xdgHome)
+	defer func() {
+		_ = os.Unsetenv("XDG_DATA_HOME")
+	}()
+
+	// 1. Setup mock remote repository
+	remoteRepoDir := filepath.Join(tmpDir, "remote_repo")
+	if err := os.MkdirAll(remoteRepoDir, 0755); err != nil {
+		t.Fatalf("failed to create mock remote dir: %v", err)
+	}
+
+	_ = runGitCmdInDir(t, remoteRepoDir, "init", "--initial-branch=main")
+	_ = runGitCmdInDir(t, remoteRepoDir, "config", "user.email", "test@hariti.io")
+	_ = runGitCmdInDir(t, remoteRepoDir, "config", "user.name", "Test Hariti")
+	_ = runGitCmdInDir(t, remoteRepoDir, "commit", "--allow-empty", "-m", "initial commit")
+	rev1 := runGitCmdInDir(t, remoteRepoDir, "rev-parse", "HEAD")
+
+	remoteURL, err := url.Parse("file://" + filepath.ToSlash(remoteRepoDir))
+	if err != nil {
+		t.Fatalf("failed to parse remote URL: %v", err)
+	}
+
+	g := &graph.Graph{
+		Bundles: []graph.Bundle{
+			{
+				ID: "my/remote-plugin",
+				Source: graph.Source{
+					Type: graph.SourceTypeRemote,
+					URL:  remoteURL,
+					Path: filepath.Join(xdgHome, "hariti", "repos", url.QueryEscape("my/remote-plugin")),
+				},
+			},
+		},
+	}
+
+	cfg := &hariti.HaritiConfig{
+		Paths: hariti.Paths{
+			ConfigFile: filepath.Join(tmpDir, "hariti_home", "bundles.hariti"),
+			ConfigDir:  filepath.Join(tmpDir, "hariti_home"),
+			DataDir:    filepath.Join(xdgHome, "hariti"),
+		},
+		Writer:    io.Discard,
+		ErrWriter: io.Discard,
+	}
+	har := hariti.NewHariti(cfg)
+
+	ctx := context.Background()
+	ctx = vcs.WithWriter(ctx, io.Discard)
+	ctx = vcs.WithErrWriter(ctx, io.Discard)
+
+	// Step 1: Nothing deployed yet
+	generations, err := har.Generations()
+	if err != nil {
+		t.Fatalf("Generations failed: %v", err)
+	}
+	if len(generations) != 0 {
+		t.Errorf("expected no generations, got %+v", generations)
+	}
+
+	// Step 2: Deploy two generations from different lockfiles
+	deploy := func() string {
+		if _, err := har.Sync(ctx, g, hariti.SyncOptions{}); err != nil {
+			t.Fatalf("Sync failed: %v", err)
+		}
+		genID, err := har.Deploy(ctx, g, hariti.DeployOptions{})
+		if err != nil {
+			t.Fatalf("Deploy failed: %v", err)
+		}
+		return genID
+	}
+	firstID := deploy()
+	_ = runGitCmdInDir(t, remoteRepoDir, "commit", "--allow-empty", "-m", "second commit")
+	secondID := deploy()
+
+	// A directory without metadata is not a generation
+	if err := os.MkdirAll(filepath.Join(har.GenerationsDir(), "incomplete"), 0755); err != nil {
+		t.Fatalf("failed to create stray directory: %v", err)
+	}
+
+	generations, err = har.Generations()
+	if err != nil {
+		t.Fatalf("Generations failed: %v", err)
+	}
+	if len(generations) != 2 {
+		t.Fatalf("expected 2 generations, got %+v", generations)
+	}
+	if generations[0].ID != firstID || generations[0].Current {
+		t.Errorf("unexpected first generation: %+v", generations[0])
+	}
+	if generations[1].ID != secondID || !generations[1].Current {
+		t.Errorf("unexpected second generation: %+v", generations[1])
+	}
+	for _, gen := range generations {
+		if gen.BundleCount != 1 || gen.LockHash == "" || gen.CreatedAt == "" {
+			t.Errorf("incomplete generation summary: %+v", gen)
+		}
+	}
+
+	// Step 3: Inspect a generation that is not current
+	detail, err := har.InspectGeneration(firstID)
+	if err != nil {
+		t.Fatalf("InspectGeneration failed: %v", err)
+	}
+	if detail.Current {
+		t.Errorf("expected generation %s not to be current", firstID)
+	}
+	if len(detail.Lock.Bundles) != 1 || detail.Lock.Bundles[0].Revision != rev1 {
+		t.Errorf("expected lock snapshot at %s, got %+v", rev1, detail.Lock)
+	}
+	if !strings.Contains(detail.Packadd, "packadd my_remote-plugin") {
+		t.Errorf("expected packadd projection, got:\n%s", detail.Packadd)
+	}
+
+	// Step 4: Unknown and malformed IDs are rejected
+	if _, err := har.InspectGeneration("no-such-generation"); !errors.Is(err, hariti.ErrGenerationNotFound) {
+		t.Errorf("expected ErrGenerationNotFound, got: %v", err)
+	}
+	if _, err := har.InspectGeneration("../current"); err == nil || !strings.Contains(err.Error(), "invalid generation ID") {
+		t.Errorf("expected invalid generation ID error, got: %v", err)
+	}
+}
+
+func TestHariti_DiffGenerations(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	xdgHome := filepath.Join(tmpDir, "xdg_home")
+	_ = os.Setenv("XDG_DATA_HOME", xdgHome)
+	defer func() {
+		_ = os.Unsetenv("XDG_DATA_HOME")
+	}()
+
+	remoteRepoDir := filepath.Join(tmpDir, "remote_repo")
+	if err := os.MkdirAll(remoteRepoDir, 0755); err != nil {
+		t.Fatalf("failed to create mock remote dir: %v", err)
+	}
+	_ = runGitCmdInDir(t, remoteRepoDir, "init", "--initial-branch=main")
+	_ = runGitCmdInDir(t, remoteRepoDir, "config", "user.email", "test@hariti.io")
+	_ = runGitCmdInDir(t, remoteRepoDir, "config", "user.name", "Test Hariti")
+	_ = runGitCmdInDir(t, remoteRepoDir, "commit", "--allow-empty", "-m", "initial commit")
+
+	remoteURL, err := url.Parse("file://" + filepath.ToSlash(remoteRepoDir))
+	if err != nil {
+		t.Fatalf("failed to parse remote URL: %v", err)
+	}
+
+	oldLocal := filepath.Join(tmpDir, "old-local")
+	newLocal := filepath.Join(tmpDir, "new-local")
+	for _, dir := range []string{oldLocal, newLocal} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("failed to create local bundle dir: %v", err)
+		}
+	}
+
+	remote := graph.Bundle{
+		ID: "my/remote-plugin",
+		Source: graph.Source{
+			Type: graph.SourceTypeRemote,
+			URL:  remoteURL,
+			Path: filepath.Join(xdgHome, "hariti", "repos", url.QueryEscape("my/remote-plugin")),
+		},
+	}
+	g := &graph.Graph{
+		Bundles: []graph.Bundle{
+			remote,
+			{ID: "my/old-local", Source: graph.Source{Type: graph.SourceTypeLocal, Path: oldLocal}},
+		},
+	}
+
+	cfg := &hariti.HaritiConfig{
+		Paths: hariti.Paths{
+			ConfigFile: filepath.Join(tmpDir, "hariti_home", "bundles.hariti"),
+			ConfigDir:  filepath.Join(tmpDir, "hariti_home"),
+			DataDir:    filepath.Join(xdgHome, "hariti"),
+		},
+		Writer:    io.Discard,
+		ErrWriter: io.Discard,
+	}
+	har := hariti.NewHariti(cfg)
+
+	ctx := context.Background()
+	ctx = vcs.WithWriter(ctx, io.Discard)
+	ctx = vcs.WithErrWriter(ctx, io.Discard)
+
+	deploy := func() string {
+		if _, err := har.Sync(ctx, g, hariti.SyncOptions{}); err != nil {
+			t.Fatalf("Sync failed: %v", err)
+		}
+		genID, err := har.Deploy(ctx, g, hariti.DeployOptions{})
+		if err != nil {
+			t.Fatalf("Deploy failed: %v", err)
+		}
+		return genID
+	}
+	firstID := deploy()
+
+	// Step 1: Advance the remote, change its configuration and swap the local bundle
+	_ = runGitCmdInDir(t, remoteRepoDir, "commit", "--allow-empty", "-m", "second commit")
+	_ = runGitCmdInDir(t, remoteRepoDir, "commit", "--allow-empty", "-m", "third commit")
+	remote.EnableIf = "has('nvim')"
+	remote.Build = []graph.BuildStep{{OS: "all", Cmd: "true"}}
+	g.Bundles = []graph.Bundle{
+		remote,
+		{ID: "my/new-local", Source: graph.Source{Type: graph.SourceTypeLocal, Path: newLocal}},
+	}
+	secondID := deploy()
+
+	diff, err := har.DiffGenerations(ctx, firstID, secondID)
+	if err != nil {
+		t.Fatalf("DiffGenerations failed: %v", err)
+	}
+	if diff.From != firstID || diff.To != secondID {
+		t.Errorf("unexpected diff endpoints: %s -> %s", diff.From, diff.To)
+	}
+	changes := make(map[string]hariti.BundleDiff)
+	for _, change := range diff.Bundles {
+		changes[change.BundleID] = change
+	}
+	if len(changes) != 3 {
+		t.Fatalf("expected 3 bundle changes, got %+v", diff.Bundles)
+	}
+	updated := changes["my/remote-plugin"]
+	if updated.Type != hariti.ChangeUpdated || updated.Commits != 2 || updated.Dropped != 0 {
+		t.Errorf("expected my/remote-plugin updated by 2 commits, got %+v", updated)
+	}
+	if !updated.EnableIfChanged || updated.NewEnableIf != "has('nvim')" || !updated.BuildChanged {
+		t.Errorf("expected enable_if and build step changes, got %+v", updated)
+	}
+	if changes["my/new-local"].Type != hariti.ChangeAdded || changes["my/old-local"].Type != hariti.ChangeRemoved {
+		t.Errorf("expected my/new-local added and my/old-local removed, got %+v", diff.Bundles)
+	}
+	for _, want := range []string{
+		"--- " + firstID + "/packadd.vim",
+		"+++ " + secondID + "/packadd.vim",
+		"-packadd my_remote-plugin",
+		"+if has('nvim')",
+		"-call s:add_rtp(\"" + filepath.ToSlash(oldLocal) + "\", '')",
+		"+call s:add_rtp(\"" + filepath.ToSlash(newLocal) + "\", '')",
+	} {
+		if !strings.Contains(diff.Packadd, want) {
+			t.Errorf("expected packadd diff to contain %q, got:\n%s", want, diff.Packadd)
+		}
+	}
+
+	// Step 2: Diffing backwards reports the dropped commits
+	diff, err = har.DiffGenerations(ctx, secondID, firstID)
+	if err != nil {
+		t.Fatalf("DiffGenerations failed: %v", err)
+	}
+	for _, change := range diff.Bundles {
+		if change.BundleID == "my/remote-plugin" && (change.Type != hariti.ChangeRewound || change.Dropped != 2 || change.Commits != 0) {
+			t.Errorf("expected my/remote-plugin rewound by 2 commits, got %+v", change)
+		}
+	}
+
+	// Step 3: A generation does not differ from itself
+	diff, err = har.DiffGenerations(ctx, firstID, firstID)
+	if err != nil {
+		t.Fatalf("DiffGenerations failed: %v", err)
+	}
+	if len(diff.Bundles) != 0 || diff.Packadd != "" {
+		t.Errorf("expected no differences, got %+v", diff)
+	}
+
+	if _, err := har.DiffGenerations(ctx, firstID, "no-such-generation"); !errors.Is(err, hariti.ErrGenerationNotFound) {
+		t.Errorf("expected ErrGenerationNotFound, got: %v", err)
+	}
+}
@@ -0,0 +1,220 @@
+package graph
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MissingDependencyError reports a dependency that names no bundle of the
+// graph, neither by ID nor by alias.
+type MissingDependencyError struct {
+	BundleID   string
+	Dependency string
+}
+
+func (e *MissingDependencyError) Error() string {
+	return fmt.Sprintf("bundle %s depends on unknown bundle %s", e.BundleID, e.Dependency)
+}
+
+// CycleError reports bundles that depend on each other. Cycle lists the
+// canonical IDs along the cycle, starting and ending with the same bundle.
+type CycleError struct {
+	Cycle []string
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("dependency cycle: %s", strings.Join(e.Cycle, " -> "))
+}
+
+// canonicalIDs maps the ID and the aliases of every bundle to its ID. An
+// alias must not be another bundle's ID or be registered by two bundles.
+func canonicalIDs(g Graph) (map[string]string, error) {
+	ids := make(map[string]string, len(g.Bundles))
+	for _, b := range g.Bundles {
+		ids[b.ID] = b.ID
+	}
+	for _, b := range g.Bundles {
+		for _, alias := range b.Aliases {
+			owner, exists := ids[alias]
+			switch {
+			case !exists:
+				ids[alias] = b.ID
+			case owner == b.ID:
+			case owner == alias:
+				return nil, fmt.Errorf("alias %s of bundle %s conflicts with the ID of another bundle", alias, b.ID)
+			default:
+				return nil, fmt.Errorf("alias %s is registered by both bundles %s and %s", alias, owner, b.ID)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// ResolveDependencies rewrites dependencies declared by alias to the
+// canonical IDs of the bundles they name, dropping duplicates.
+func (g *Graph) ResolveDependencies() error {
+	ids, err := canonicalIDs(*g)
+	if err != nil {
+		return err
+	}
+	for i := range g.Bundles {
+		b := &g.Bundles[i]
+		if len(b.Dependencies) == 0 {
+			continue
+		}
+		resolved := make([]string, 0, len(b.Dependencies))
+		seen := make(map[string]bool, len(b.Dependencies))
+		for _, dep := range b.Dependencies {
+			id, exists := ids[dep]
+			if !exists {
+				return &MissingDependencyError{BundleID: b.ID, Dependency: dep}
+			}
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			resolved = append(resolved, id)
+		}
+		b.Dependencies = resolved
+	}
+	return nil
+}
+
+// TopologicalSort returns the bundles of the graph ordered so that every
+// bundle comes after the bundles it depends on. Dependencies may be declared
+// by ID or by alias. Bundles keep their declaration order wherever their
+// dependencies allow it.
+func TopologicalSort(g Graph) ([]Bundle, error) {
+	ids, err := canonicalIDs(g)
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[string]int, len(g.Bundles))
+	for i, b := range g.Bundles {
+		index[b.ID] = i
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(g.Bundles))
+	sorted := make([]Bundle, 0, len(g.Bundles))
+	var path []string
+
+	var visit func(i int) error
+	visit = func(i int) error {
+		b := g.Bundles[i]
+		switch state[i] {
+		case visited:
+			return nil
+		case visiting:
+			start := 0
+			for j, id := range path {
+				if id == b.ID {
+					start = j
+					break
+				}
+			}
+			cycle := append(append([]string{}, path[start:]...), b.ID)
+			return &CycleError{Cycle: cycle}
+		}
+
+		state[i] = visiting
+		path = append(path, b.ID)
+		for _, dep := range b.Dependencies {
+			id, exists := ids[dep]
+			if !exists {
+				return &MissingDependencyError{BundleID: b.ID, Dependency: dep}
+			}
+			if err := visit(index[id]); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[i] = visited
+		sorted = append(sorted, b)
+		return nil
+	}
+
+	for i := range g.Bundles {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
+
+// ConditionChains maps every bundle to the bundles whose conditions it is
+// enabled under: the bundle itself followed by the bundles it depends on,
+// directly or transitively, each in turn followed by its own dependencies.
+// Every bundle appears once in a chain.
+func (g Graph) ConditionChains() (map[string][]string, error) {
+	sorted, err := TopologicalSort(g)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := canonicalIDs(g)
+	if err != nil {
+		return nil, err
+	}
+
+	// Dependencies come first in sorted order, so their chains are known
+	chains := make(map[string][]string, len(sorted))
+	for _, b := range sorted {
+		chain := []string{b.ID}
+		seen := map[string]bool{b.ID: true}
+		for _, dep := range b.Dependencies {
+			for _, id := range chains[ids[dep]] {
+				if !seen[id] {
+					seen[id] = true
+					chain = append(chain, id)
+				}
+			}
+		}
+		chains[b.ID] = chain
+	}
+	return chains, nil
+}
+
+// ResolveEnableIf sets the EffectiveEnableIf of every bundle: a bundle is
+// only enabled when its own enable_if and those of every bundle it depends
+// on, directly or transitively, hold. Conditions are combined with && in the
+// order of the bundle's condition chain, each condition appearing once.
+func (g *Graph) ResolveEnableIf() error {
+	chains, err := g.ConditionChains()
+	if err != nil {
+		return err
+	}
+	enableIfs := make(map[string]string, len(g.Bundles))
+	for _, b := range g.Bundles {
+		enableIfs[b.ID] = b.EnableIf
+	}
+
+	for i := range g.Bundles {
+		var conds []string
+		seen := make(map[string]bool)
+		for _, id := range chains[g.Bundles[i].ID] {
+			if cond := enableIfs[id]; cond != "" && !seen[cond] {
+				seen[cond] = true
+				conds = append(conds, cond)
+			}
+		}
+		g.Bundles[i].EffectiveEnableIf = joinConditions(conds)
+	}
+	return nil
+}
+
+// joinConditions combines Vim expressions with &&, parenthesizing them when
+// there is more than one.
+func joinConditions(conds []string) string {
+	if len(conds) == 1 {
+		return conds[0]
+	}
+	parts := make([]string, len(conds))
+	for i, cond := range conds {
+		parts[i] = "(" + cond + ")"
+	}
+	return strings.Join(parts, " && ")
+}
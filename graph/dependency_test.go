@@ -0,0 +1,191 @@
+package graph_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/kamichidu/go-hariti/graph"
+)
+
+func bundleIDs(bundles []graph.Bundle) []string {
+	ids := make([]string, 0, len(bundles))
+	for _, b := range bundles {
+		ids = append(ids, b.ID)
+	}
+	return ids
+}
+
+func TestTopologicalSort(t *testing.T) {
+	tests := []struct {
+		name    string
+		bundles []graph.Bundle
+		want    []string
+	}{
+		{
+			name: "declaration order without dependencies",
+			bundles: []graph.Bundle{
+				{ID: "c"}, {ID: "a"}, {ID: "b"},
+			},
+			want: []string{"c", "a", "b"},
+		},
+		{
+			name: "dependency declared later",
+			bundles: []graph.Bundle{
+				{ID: "plugin", Dependencies: []string{"lib"}},
+				{ID: "other"},
+				{ID: "lib"},
+			},
+			want: []string{"lib", "plugin", "other"},
+		},
+		{
+			name: "dependency by alias",
+			bundles: []graph.Bundle{
+				{ID: "foo/plugin", Dependencies: []string{"lib"}},
+				{ID: "foo/lib.vim", Aliases: []string{"lib"}},
+			},
+			want: []string{"foo/lib.vim", "foo/plugin"},
+		},
+		{
+			name: "diamond",
+			bundles: []graph.Bundle{
+				{ID: "top", Dependencies: []string{"left", "right"}},
+				{ID: "left", Dependencies: []string{"base"}},
+				{ID: "right", Dependencies: []string{"base"}},
+				{ID: "base"},
+			},
+			want: []string{"base", "left", "right", "top"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sorted, err := graph.TopologicalSort(graph.Graph{Bundles: tt.bundles})
+			if err != nil {
+				t.Fatalf("TopologicalSort() error = %v", err)
+			}
+			if got := bundleIDs(sorted); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TopologicalSort() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTopologicalSort_Errors(t *testing.T) {
+	_, err := graph.TopologicalSort(graph.Graph{Bundles: []graph.Bundle{
+		{ID: "a", Dependencies: []string{"b"}},
+		{ID: "b", Dependencies: []string{"c"}},
+		{ID: "c", Aliases: []string{"cc"}, Dependencies: []string{"b"}},
+	}})
+	var cycle *graph.CycleError
+	if !errors.As(err, &cycle) || !reflect.DeepEqual(cycle.Cycle, []string{"b", "c", "b"}) {
+		t.Errorf("expected cycle b -> c -> b, got: %v", err)
+	}
+
+	_, err = graph.TopologicalSort(graph.Graph{Bundles: []graph.Bundle{
+		{ID: "self", Dependencies: []string{"self"}},
+	}})
+	if !errors.As(err, &cycle) || err.Error() != "dependency cycle: self -> self" {
+		t.Errorf("expected self dependency cycle, got: %v", err)
+	}
+
+	_, err = graph.TopologicalSort(graph.Graph{Bundles: []graph.Bundle{
+		{ID: "a", Dependencies: []string{"missing"}},
+	}})
+	var missing *graph.MissingDependencyError
+	if !errors.As(err, &missing) || err.Error() != "bundle a depends on unknown bundle missing" {
+		t.Errorf("expected missing dependency error, got: %v", err)
+	}
+
+	_, err = graph.TopologicalSort(graph.Graph{Bundles: []graph.Bundle{
+		{ID: "a", Aliases: []string{"x"}},
+		{ID: "b", Aliases: []string{"x"}},
+	}})
+	if err == nil {
+		t.Error("expected alias registered by two bundles to fail, got nil")
+	}
+
+	_, err = graph.TopologicalSort(graph.Graph{Bundles: []graph.Bundle{
+		{ID: "a", Aliases: []string{"b"}},
+		{ID: "b"},
+	}})
+	if err == nil {
+		t.Error("expected alias equal to another bundle ID to fail, got nil")
+	}
+}
+
+func TestResolveDependencies(t *testing.T) {
+	g := &graph.Graph{Bundles: []graph.Bundle{
+		{ID: "foo/plugin", Dependencies: []string{"lib", "foo/lib.vim", "foo/other"}},
+		{ID: "foo/lib.vim", Aliases: []string{"lib"}},
+		{ID: "foo/other"},
+	}}
+	if err := g.ResolveDependencies(); err != nil {
+		t.Fatalf("ResolveDependencies() error = %v", err)
+	}
+	want := []string{"foo/lib.vim", "foo/other"}
+	if got := g.Bundles[0].Dependencies; !reflect.DeepEqual(got, want) {
+		t.Errorf("Dependencies = %v, want %v", got, want)
+	}
+
+	g = &graph.Graph{Bundles: []graph.Bundle{
+		{ID: "foo/plugin", Dependencies: []string{"unknown"}},
+	}}
+	var missing *graph.MissingDependencyError
+	if err := g.ResolveDependencies(); !errors.As(err, &missing) {
+		t.Errorf("expected missing dependency error, got: %v", err)
+	}
+}
+
+func TestResolveEnableIf(t *testing.T) {
+	g := &graph.Graph{Bundles: []graph.Bundle{
+		{ID: "app", EnableIf: "has('nvim')", Dependencies: []string{"plugin", "base"}},
+		{ID: "plugin", Dependencies: []string{"py"}},
+		{ID: "py", EnableIf: "has('python3')", Dependencies: []string{"base"}},
+		{ID: "base", EnableIf: "has('unix')", Aliases: []string{"b"}},
+		{ID: "plain"},
+		{ID: "own", EnableIf: "has('gui_running')", Dependencies: []string{"plain"}},
+	}}
+	if err := g.ResolveEnableIf(); err != nil {
+		t.Fatalf("ResolveEnableIf() error = %v", err)
+	}
+
+	want := map[string]string{
+		"app":    "(has('nvim')) && (has('python3')) && (has('unix'))",
+		"plugin": "(has('python3')) && (has('unix'))",
+		"py":     "(has('python3')) && (has('unix'))",
+		"base":   "has('unix')",
+		"plain":  "",
+		"own":    "has('gui_running')",
+	}
+	for _, b := range g.Bundles {
+		if b.EffectiveEnableIf != want[b.ID] {
+			t.Errorf("EffectiveEnableIf of %s = %q, want %q", b.ID, b.EffectiveEnableIf, want[b.ID])
+		}
+	}
+
+	chains, err := g.ConditionChains()
+	if err != nil {
+		t.Fatalf("ConditionChains() error = %v", err)
+	}
+	wantChains := map[string][]string{
+		"app":    {"app", "plugin", "py", "base"},
+		"plugin": {"plugin", "py", "base"},
+		"py":     {"py", "base"},
+		"base":   {"base"},
+		"plain":  {"plain"},
+		"own":    {"own", "plain"},
+	}
+	if !reflect.DeepEqual(chains, wantChains) {
+		t.Errorf("ConditionChains() = %v, want %v", chains, wantChains)
+	}
+
+	g = &graph.Graph{Bundles: []graph.Bundle{
+		{ID: "a", Dependencies: []string{"b"}},
+		{ID: "b", Dependencies: []string{"a"}},
+	}}
+	var cycle *graph.CycleError
+	if err := g.ResolveEnableIf(); !errors.As(err, &cycle) {
+		t.Errorf("expected dependency cycle error, got: %v", err)
+	}
+}
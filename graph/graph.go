@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"regexp"
+	"strings"
 )
 
 type SourceType string
@@ -36,18 +38,104 @@ func (s Source) MarshalJSON() ([]byte, error) {
 	})
 }
 
+func (s *Source) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Type SourceType `json:"type"`
+		URL  string     `json:"url,omitempty"`
+		Path string     `json:"path,omitempty"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	s.Type = raw.Type
+	s.Path = raw.Path
+	s.URL = nil
+	if raw.URL != "" {
+		u, err := url.Parse(raw.URL)
+		if err != nil {
+			return fmt.Errorf("invalid source URL %q: %w", raw.URL, err)
+		}
+		s.URL = u
+	}
+	return nil
+}
+
 type BuildStep struct {
 	OS  string `json:"os"`
 	Cmd string `json:"cmd"`
 }
 
+type RefType string
+
+const (
+	RefTypeBranch   RefType = "branch"
+	RefTypeTag      RefType = "tag"
+	RefTypeRevision RefType = "rev"
+)
+
+type Ref struct {
+	Type RefType `json:"type"`
+	Name string  `json:"name"`
+}
+
+type CloneStrategy string
+
+const (
+	CloneStrategyFull     CloneStrategy = "full"
+	CloneStrategyShallow  CloneStrategy = "shallow"
+	CloneStrategyBlobless CloneStrategy = "blobless"
+	CloneStrategyTreeless CloneStrategy = "treeless"
+)
+
+// Clone describes how much history and content the repository cache of a
+// remote bundle holds. The zero value leaves the choice to the caller.
+type Clone struct {
+	Strategy CloneStrategy `json:"strategy"`
+	// Depth is the number of commits a shallow clone keeps. Zero means 1.
+	Depth int `json:"depth,omitempty"`
+}
+
+func (c Clone) Validate() error {
+	switch c.Strategy {
+	case "", CloneStrategyFull, CloneStrategyBlobless, CloneStrategyTreeless:
+		if c.Depth != 0 {
+			return fmt.Errorf("clone depth requires the %s strategy", CloneStrategyShallow)
+		}
+	case CloneStrategyShallow:
+		if c.Depth < 0 {
+			return fmt.Errorf("clone depth cannot be negative: %d", c.Depth)
+		}
+	default:
+		return fmt.Errorf("invalid clone strategy: %s", c.Strategy)
+	}
+	return nil
+}
+
 type Bundle struct {
 	ID           string      `json:"id"`
 	Source       Source      `json:"source"`
+	Ref          Ref         `json:"ref,omitzero"`
+	Clone        Clone       `json:"clone,omitzero"`
 	Dependencies []string    `json:"dependencies"`
 	EnableIf     string      `json:"enable_if,omitempty"`
+	EnableIfLua  string      `json:"enable_if_lua,omitempty"`
 	Build        []BuildStep `json:"build"`
 	Aliases      []string    `json:"aliases"`
+	// EffectiveEnableIf combines EnableIf with the effective conditions of
+	// the bundles this bundle depends on. It is set by ResolveEnableIf.
+	EffectiveEnableIf string `json:"effective_enable_if,omitempty"`
+	// OnCmd, OnFt, OnMap and OnEvent are the triggers of a lazily loaded
+	// bundle: the commands, filetypes, key mappings and autocmd events that
+	// load it on first use. A bundle without triggers is loaded at startup.
+	OnCmd   []string `json:"on_cmd,omitempty"`
+	OnFt    []string `json:"on_ft,omitempty"`
+	OnMap   []string `json:"on_map,omitempty"`
+	OnEvent []string `json:"on_event,omitempty"`
+}
+
+// IsLazy reports whether the bundle declares any lazy loading trigger.
+func (b Bundle) IsLazy() bool {
+	return len(b.OnCmd) > 0 || len(b.OnFt) > 0 || len(b.OnMap) > 0 || len(b.OnEvent) > 0
 }
 
 func (b Bundle) GetName() string {
@@ -117,12 +205,73 @@ func Validate(g Graph) error {
 			return fmt.Errorf("local source path cannot be empty for bundle %s", b.ID)
 		}
 
+		if b.Ref != (Ref{}) {
+			switch b.Ref.Type {
+			case RefTypeBranch, RefTypeTag, RefTypeRevision:
+			default:
+				return fmt.Errorf("invalid ref type for bundle %s: %s", b.ID, b.Ref.Type)
+			}
+			if b.Ref.Name == "" {
+				return fmt.Errorf("ref name cannot be empty for bundle %s", b.ID)
+			}
+		}
+
+		if b.Clone != (Clone{}) {
+			if b.Source.Type != SourceTypeRemote {
+				return fmt.Errorf("clone strategy cannot be set for local bundle %s", b.ID)
+			}
+			if err := b.Clone.Validate(); err != nil {
+				return fmt.Errorf("bundle %s: %w", b.ID, err)
+			}
+		}
+
 		for _, dep := range b.Dependencies {
 			if dep == "" {
 				return fmt.Errorf("bundle %s contains an empty dependency string", b.ID)
 			}
 		}
+
+		if err := validateTriggers(b); err != nil {
+			return err
+		}
+	}
+
+	// Every dependency must name a bundle, and bundles must not depend on
+	// each other in a cycle
+	if _, err := TopologicalSort(g); err != nil {
+		return err
 	}
 
 	return nil
 }
+
+var (
+	// Vim user commands must start with an uppercase letter
+	commandPattern  = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+	filetypePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+	eventPattern    = regexp.MustCompile(`^[A-Za-z]+$`)
+)
+
+func validateTriggers(b Bundle) error {
+	for _, cmd := range b.OnCmd {
+		if !commandPattern.MatchString(cmd) {
+			return fmt.Errorf("invalid on_cmd trigger for bundle %s: %q is not a user command name", b.ID, cmd)
+		}
+	}
+	for _, ft := range b.OnFt {
+		if !filetypePattern.MatchString(ft) {
+			return fmt.Errorf("invalid on_ft trigger for bundle %s: %q is not a filetype name", b.ID, ft)
+		}
+	}
+	for _, m := range b.OnMap {
+		if m == "" || strings.ContainsAny(m, " \t\r\n|") {
+			return fmt.Errorf("invalid on_map trigger for bundle %s: %q cannot be empty or contain whitespace or '|'", b.ID, m)
+		}
+	}
+	for _, event := range b.OnEvent {
+		if !eventPattern.MatchString(event) {
+			return fmt.Errorf("invalid on_event trigger for bundle %s: %q is not an autocmd event name", b.ID, event)
+		}
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package graph_test
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/kamichidu/go-hariti/graph"
@@ -44,6 +45,31 @@ func TestNormalize(t *testing.T) {
 	}
 }
 
+func TestSource_JSONRoundTrip(t *testing.T) {
+	var src graph.Source
+	if err := json.Unmarshal([]byte(`{"type":"remote","url":"https://github.com/foo/bar","path":"/repos/foo"}`), &src); err != nil {
+		t.Fatalf("failed to unmarshal source: %v", err)
+	}
+	if src.Type != graph.SourceTypeRemote || src.Path != "/repos/foo" {
+		t.Errorf("unexpected source: %+v", src)
+	}
+	if src.URL == nil || src.URL.String() != "https://github.com/foo/bar" {
+		t.Fatalf("expected URL https://github.com/foo/bar, got %v", src.URL)
+	}
+
+	data, err := json.Marshal(src)
+	if err != nil {
+		t.Fatalf("failed to marshal source: %v", err)
+	}
+	if string(data) != `{"type":"remote","url":"https://github.com/foo/bar","path":"/repos/foo"}` {
+		t.Errorf("unexpected JSON: %s", data)
+	}
+
+	if err := json.Unmarshal([]byte(`{"type":"remote","url":"://bad"}`), &src); err == nil {
+		t.Error("expected error for invalid URL, got nil")
+	}
+}
+
 func TestValidate(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -145,6 +171,188 @@ func TestValidate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "invalid ref type",
+			graph: graph.Graph{
+				Bundles: []graph.Bundle{
+					{
+						ID: "foo",
+						Source: graph.Source{
+							Type: graph.SourceTypeRemote,
+						},
+						Ref: graph.Ref{Type: "commit", Name: "abc123"},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "ref without name",
+			graph: graph.Graph{
+				Bundles: []graph.Bundle{
+					{
+						ID: "foo",
+						Source: graph.Source{
+							Type: graph.SourceTypeRemote,
+						},
+						Ref: graph.Ref{Type: graph.RefTypeBranch},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "shallow clone with depth",
+			graph: graph.Graph{
+				Bundles: []graph.Bundle{
+					{
+						ID: "foo",
+						Source: graph.Source{
+							Type: graph.SourceTypeRemote,
+						},
+						Clone: graph.Clone{Strategy: graph.CloneStrategyShallow, Depth: 10},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid clone strategy",
+			graph: graph.Graph{
+				Bundles: []graph.Bundle{
+					{
+						ID: "foo",
+						Source: graph.Source{
+							Type: graph.SourceTypeRemote,
+						},
+						Clone: graph.Clone{Strategy: "sparse"},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "clone depth without shallow strategy",
+			graph: graph.Graph{
+				Bundles: []graph.Bundle{
+					{
+						ID: "foo",
+						Source: graph.Source{
+							Type: graph.SourceTypeRemote,
+						},
+						Clone: graph.Clone{Strategy: graph.CloneStrategyBlobless, Depth: 1},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown dependency",
+			graph: graph.Graph{
+				Bundles: []graph.Bundle{
+					{
+						ID:           "foo",
+						Source:       graph.Source{Type: graph.SourceTypeLocal, Path: "/path/to/foo"},
+						Dependencies: []string{"bar"},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "dependency by alias",
+			graph: graph.Graph{
+				Bundles: []graph.Bundle{
+					{
+						ID:           "foo",
+						Source:       graph.Source{Type: graph.SourceTypeLocal, Path: "/path/to/foo"},
+						Dependencies: []string{"b"},
+					},
+					{
+						ID:      "bar",
+						Source:  graph.Source{Type: graph.SourceTypeLocal, Path: "/path/to/bar"},
+						Aliases: []string{"b"},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "dependency cycle",
+			graph: graph.Graph{
+				Bundles: []graph.Bundle{
+					{
+						ID:           "foo",
+						Source:       graph.Source{Type: graph.SourceTypeLocal, Path: "/path/to/foo"},
+						Dependencies: []string{"bar"},
+					},
+					{
+						ID:           "bar",
+						Source:       graph.Source{Type: graph.SourceTypeLocal, Path: "/path/to/bar"},
+						Dependencies: []string{"foo"},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "clone strategy on local bundle",
+			graph: graph.Graph{
+				Bundles: []graph.Bundle{
+					{
+						ID: "foo",
+						Source: graph.Source{
+							Type: graph.SourceTypeLocal,
+							Path: "/path/to/foo",
+						},
+						Clone: graph.Clone{Strategy: graph.CloneStrategyShallow},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "lazy loading triggers",
+			graph: graph.Graph{
+				Bundles: []graph.Bundle{
+					{
+						ID:      "foo",
+						Source:  graph.Source{Type: graph.SourceTypeRemote},
+						OnCmd:   []string{"FooRun"},
+						OnFt:    []string{"go", "javascript.jsx"},
+						OnMap:   []string{"<Plug>(foo)", "<Leader>f"},
+						OnEvent: []string{"InsertEnter"},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "lowercase on_cmd trigger",
+			graph: graph.Graph{
+				Bundles: []graph.Bundle{
+					{
+						ID:     "foo",
+						Source: graph.Source{Type: graph.SourceTypeRemote},
+						OnCmd:  []string{"foo"},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "on_map trigger with whitespace",
+			graph: graph.Graph{
+				Bundles: []graph.Bundle{
+					{
+						ID:     "foo",
+						Source: graph.Source{Type: graph.SourceTypeRemote},
+						OnMap:  []string{"<Leader> f"},
+					},
+				},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -1,6 +1,7 @@
 package hariti
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -22,6 +23,8 @@ type HaritiConfig struct {
 	Writer    io.Writer
 	ErrWriter io.Writer
 	Logger    Logger
+	// Version is the hariti version recorded in generation metadata.
+	Version string
 }
 
 type Hariti struct {
@@ -69,6 +72,8 @@ func (h *Hariti) SetupManagedDirectory() error {
 		h.RepositoriesDir(),
 		h.MetadataDir(),
 		h.GenerationsDir(),
+		h.StoreDir(),
+		h.StagingDir(),
 	}
 	for _, directory := range directories {
 		if info, err := os.Stat(directory); err != nil {
@@ -110,14 +115,25 @@ func (h *Hariti) LockfilePath() string {
 	return filepath.Join(h.config.Paths.ConfigDir, "hariti.lock")
 }
 
+// PreviousLockfilePath returns where the lockfile replaced by the latest sync
+// is kept for changelog generation.
+func (h *Hariti) PreviousLockfilePath() string {
+	return filepath.Join(h.config.Paths.DataDir, "previous.lock")
+}
+
 type RepositoryFact struct {
-	BundleID string
-	Revision string
+	BundleID    string
+	Revision    string
+	Tree        string
+	ContentHash string
 }
 
 type RepositoryMetadata struct {
 	BundleID string `json:"bundle_id"`
 	Source   string `json:"source"`
+	// CloneStrategy is the strategy the cache was cloned with. Caches written
+	// before clone strategies existed leave it empty and are full clones.
+	CloneStrategy graph.CloneStrategy `json:"clone_strategy,omitempty"`
 }
 
 type Lockfile struct {
@@ -128,6 +144,11 @@ type LockfileEntry struct {
 	ID       string `json:"id"`
 	Source   string `json:"source"`
 	Revision string `json:"revision"`
+	// Tree is the VCS hash of the root tree at Revision.
+	Tree string `json:"tree,omitempty"`
+	// ContentHash is the hash of the files Archive exports at Revision, as
+	// computed by hashExportedFiles.
+	ContentHash string `json:"content_hash,omitempty"`
 }
 
 func (h *Hariti) loadRepositoryMetadata(bundleID string) (*RepositoryMetadata, error) {
@@ -165,17 +186,57 @@ func (h *Hariti) writeRepositoryMetadata(bundleID string, meta *RepositoryMetada
 	return os.WriteFile(path, data, 0644)
 }
 
+func (h *Hariti) loadLockfile() (*Lockfile, error) {
+	return readLockfile(h.LockfilePath())
+}
+
+// lockedEntries maps the bundle IDs recorded in hariti.lock to their entries.
+// A missing lockfile records no bundles.
+func (h *Hariti) lockedEntries() (map[string]LockfileEntry, error) {
+	lock, err := h.loadLockfile()
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read lockfile: %w", err)
+		}
+		lock = &Lockfile{}
+	}
+	entries := make(map[string]LockfileEntry, len(lock.Bundles))
+	for _, entry := range lock.Bundles {
+		entries[entry.ID] = entry
+	}
+	return entries, nil
+}
+
+func readLockfile(path string) (*Lockfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	lock := new(Lockfile)
+	if err := json.Unmarshal(data, lock); err != nil {
+		return nil, err
+	}
+	return lock, nil
+}
+
 func (h *Hariti) writeLockfile(facts []RepositoryFact, g *graph.Graph) error {
 	lock := Lockfile{
 		Bundles: make([]LockfileEntry, 0, len(facts)),
 	}
 
-	factsMap := make(map[string]string)
+	factsMap := make(map[string]RepositoryFact)
 	for _, f := range facts {
-		factsMap[f.BundleID] = f.Revision
+		factsMap[f.BundleID] = f
 	}
 
 	for _, bundle := range g.Bundles {
+		fact := factsMap[bundle.ID]
+		// Bundles that failed before they were ever locked have no revision to record
+		if fact.Revision == "" {
+			continue
+		}
+
 		sourceExpr := ""
 		if bundle.Source.Type == graph.SourceTypeLocal {
 			sourceExpr = bundle.Source.Path
@@ -184,9 +245,11 @@ func (h *Hariti) writeLockfile(facts []RepositoryFact, g *graph.Graph) error {
 		}
 
 		lock.Bundles = append(lock.Bundles, LockfileEntry{
-			ID:       bundle.ID,
-			Source:   sourceExpr,
-			Revision: factsMap[bundle.ID],
+			ID:          bundle.ID,
+			Source:      sourceExpr,
+			Revision:    fact.Revision,
+			Tree:        fact.Tree,
+			ContentHash: fact.ContentHash,
 		})
 	}
 
@@ -195,6 +258,35 @@ func (h *Hariti) writeLockfile(facts []RepositoryFact, g *graph.Graph) error {
 		return err
 	}
 
+	return h.replaceLockfile(data)
+}
+
+// replaceLockfile writes data as hariti.lock. When that changes its content,
+// the replaced lockfile is kept as previous.lock first, or a stale copy is
+// removed when no lockfile exists yet. An unchanged lockfile is left as is, so
+// that previous.lock keeps recording the last change.
+func (h *Hariti) replaceLockfile(data []byte) error {
+	current, err := os.ReadFile(h.LockfilePath())
+	switch {
+	case err == nil:
+		if bytes.Equal(current, data) {
+			h.logger.Debugf("lockfile unchanged: kept previous lockfile")
+			return nil
+		}
+		if err := os.MkdirAll(h.DataDir(), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(h.PreviousLockfilePath(), current, 0644); err != nil {
+			return fmt.Errorf("failed to keep previous lockfile: %w", err)
+		}
+	case os.IsNotExist(err):
+		if err := os.Remove(h.PreviousLockfilePath()); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove stale previous lockfile: %w", err)
+		}
+	default:
+		return err
+	}
+
 	if err := os.MkdirAll(h.ConfigDir(), 0755); err != nil {
 		return err
 	}
@@ -0,0 +1,136 @@
+package hariti
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/kamichidu/go-hariti/graph"
+	"github.com/kamichidu/go-hariti/vcs"
+)
+
+// HashKind names the hash of a lockfile entry that failed verification.
+type HashKind string
+
+const (
+	HashKindTree    HashKind = "tree"
+	HashKindContent HashKind = "content"
+	// HashKindBuilt is the hash of a built store entry, recorded in its
+	// manifest rather than in the lockfile.
+	HashKindBuilt HashKind = "built"
+)
+
+// IntegrityError reports a bundle whose exported contents do not match the
+// hashes recorded in hariti.lock.
+type IntegrityError struct {
+	BundleID string
+	Kind     HashKind
+	Expected string
+	Actual   string
+}
+
+func (e *IntegrityError) Error() string {
+	if e.Kind == HashKindBuilt {
+		return fmt.Sprintf("integrity check failed for bundle %s: %s hash %s does not match %s recorded in its store entry", e.BundleID, e.Kind, e.Actual, e.Expected)
+	}
+	return fmt.Sprintf("integrity check failed for bundle %s: %s hash %s does not match locked %s", e.BundleID, e.Kind, e.Actual, e.Expected)
+}
+
+// hashExportedFiles hashes the relative paths and contents of the regular files
+// below dir. File modes are left out, so that the hash does not depend on the
+// platform the files were exported on.
+func hashExportedFiles(dir string) (string, error) {
+	h := sha256.New()
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		sum, err := hashFile(path)
+		if err != nil {
+			return err
+		}
+		//nolint:errcheck // safe: writing to a hash never fails
+		fmt.Fprintf(h, "%s\x00%x\n", filepath.ToSlash(rel), sum)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("sha256:%x", h.Sum(nil)), nil
+}
+
+func hashFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		//nolint:errcheck // safe: the file is only read, so close failures cannot lose data
+		f.Close()
+	}()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// exportContentHash exports revision into a temporary directory and hashes the
+// exported files the same way Deploy verifies them.
+func (h *Hariti) exportContentHash(ctx context.Context, v vcs.VCS, bundle graph.Bundle, revision string) (string, error) {
+	tmpDir, err := os.MkdirTemp("", "hariti-export-")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		//nolint:errcheck // safe: the temporary export is disposable and cleanup failures do not affect the computed hash
+		os.RemoveAll(tmpDir)
+	}()
+
+	if err := v.Archive(ctx, bundle, revision, tmpDir); err != nil {
+		return "", fmt.Errorf("failed to export bundle %s at revision %s: %w", bundle.ID, revision, err)
+	}
+	return hashExportedFiles(tmpDir)
+}
+
+// verifyHashes checks the hashes of an exported remote bundle against the
+// ones recorded in its lockfile entry. Entries written before hashes were
+// recorded are not verified.
+func (h *Hariti) verifyHashes(bundleID string, entry LockfileEntry, tree, contentHash string) error {
+	if entry.Tree == "" && entry.ContentHash == "" {
+		h.logger.Debugf("no content hashes recorded for bundle %s, skipped integrity check", bundleID)
+		return nil
+	}
+
+	if entry.Tree != "" && tree != entry.Tree {
+		return &IntegrityError{
+			BundleID: bundleID,
+			Kind:     HashKindTree,
+			Expected: entry.Tree,
+			Actual:   tree,
+		}
+	}
+	if entry.ContentHash != "" && contentHash != entry.ContentHash {
+		return &IntegrityError{
+			BundleID: bundleID,
+			Kind:     HashKindContent,
+			Expected: entry.ContentHash,
+			Actual:   contentHash,
+		}
+	}
+
+	h.logger.Debugf("verified content hashes of bundle %s", bundleID)
+	return nil
+}
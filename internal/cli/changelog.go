@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/kamichidu/go-hariti"
+)
+
+// PrintChangelog renders bundle changes as a human-readable summary.
+func PrintChangelog(w io.Writer, changes []hariti.BundleChange) {
+	if len(changes) == 0 {
+		//nolint:errcheck // safe: writing command results to terminal is presentation output; failures do not affect operational correctness
+		fmt.Fprintln(w, "No bundle changes.")
+		return
+	}
+
+	for _, change := range changes {
+		switch change.Type {
+		case hariti.ChangeAdded:
+			//nolint:errcheck // safe: writing command results to terminal is presentation output; failures do not affect operational correctness
+			fmt.Fprintf(w, "%s: added at %s\n", change.BundleID, ShortRevision(change.NewRevision))
+
+		case hariti.ChangeRemoved:
+			//nolint:errcheck // safe: writing command results to terminal is presentation output; failures do not affect operational correctness
+			fmt.Fprintf(w, "%s: removed (was %s)\n", change.BundleID, ShortRevision(change.OldRevision))
+
+		case hariti.ChangeUpdated:
+			summary := fmt.Sprintf("%d commits", len(change.Commits))
+			if change.Commits == nil {
+				summary = "history not cached"
+			}
+			//nolint:errcheck // safe: writing command results to terminal is presentation output; failures do not affect operational correctness
+			fmt.Fprintf(w, "%s: %s -> %s (%s)\n", change.BundleID, ShortRevision(change.OldRevision), ShortRevision(change.NewRevision), summary)
+			for _, commit := range change.Commits {
+				//nolint:errcheck // safe: writing command results to terminal is presentation output; failures do not affect operational correctness
+				fmt.Fprintf(w, "  + %s %s\n", ShortRevision(commit.Revision), commit.Subject)
+			}
+
+		case hariti.ChangeRewound:
+			//nolint:errcheck // safe: writing command results to terminal is presentation output; failures do not affect operational correctness
+			fmt.Fprintf(w, "%s: %s -> %s (rewound, %d commits dropped)\n", change.BundleID, ShortRevision(change.OldRevision), ShortRevision(change.NewRevision), len(change.Dropped))
+			for _, commit := range change.Dropped {
+				//nolint:errcheck // safe: writing command results to terminal is presentation output; failures do not affect operational correctness
+				fmt.Fprintf(w, "  - %s %s\n", ShortRevision(commit.Revision), commit.Subject)
+			}
+			for _, commit := range change.Commits {
+				//nolint:errcheck // safe: writing command results to terminal is presentation output; failures do not affect operational correctness
+				fmt.Fprintf(w, "  + %s %s\n", ShortRevision(commit.Revision), commit.Subject)
+			}
+		}
+	}
+}
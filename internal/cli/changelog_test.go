@@ -0,0 +1,60 @@
+package cli_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/kamichidu/go-hariti"
+	"github.com/kamichidu/go-hariti/internal/cli"
+	"github.com/kamichidu/go-hariti/vcs"
+)
+
+func TestPrintChangelog(t *testing.T) {
+	var buf bytes.Buffer
+	cli.PrintChangelog(&buf, []hariti.BundleChange{
+		{
+			BundleID:    "tpope/vim-fugitive",
+			Type:        hariti.ChangeUpdated,
+			OldRevision: "1111111111111111111111111111111111111111",
+			NewRevision: "2222222222222222222222222222222222222222",
+			Commits: []vcs.Commit{
+				{Revision: "2222222222222222222222222222222222222222", Subject: "Fix blame window"},
+			},
+		},
+		{
+			BundleID:    "folke/lazy.nvim",
+			Type:        hariti.ChangeAdded,
+			NewRevision: "3333333333333333333333333333333333333333",
+		},
+		{
+			BundleID:    "Shougo/vimproc.vim",
+			Type:        hariti.ChangeRewound,
+			OldRevision: "5555555555555555555555555555555555555555",
+			NewRevision: "4444444444444444444444444444444444444444",
+			Commits:     []vcs.Commit{},
+			Dropped: []vcs.Commit{
+				{Revision: "5555555555555555555555555555555555555555", Subject: "Reverted change"},
+			},
+		},
+	})
+
+	expected := []string{
+		"tpope/vim-fugitive: 111111111111 -> 222222222222 (1 commits)",
+		"  + 222222222222 Fix blame window",
+		"folke/lazy.nvim: added at 333333333333",
+		"Shougo/vimproc.vim: 555555555555 -> 444444444444 (rewound, 1 commits dropped)",
+		"  - 555555555555 Reverted change",
+	}
+	for _, line := range expected {
+		if !strings.Contains(buf.String(), line) {
+			t.Errorf("expected output to contain %q, got: %q", line, buf.String())
+		}
+	}
+
+	buf.Reset()
+	cli.PrintChangelog(&buf, nil)
+	if !strings.Contains(buf.String(), "No bundle changes.") {
+		t.Errorf("expected output to contain No bundle changes., got: %q", buf.String())
+	}
+}
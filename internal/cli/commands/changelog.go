@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+
+	"github.com/kamichidu/go-flagshim"
+	"github.com/kamichidu/go-hariti"
+	"github.com/kamichidu/go-hariti/internal/cli"
+	"github.com/kamichidu/go-hariti/internal/config/dsl"
+)
+
+//go:embed assets/changelog.txt
+var changelogUsage string
+
+type ChangelogFlags struct {
+	JSON bool
+}
+
+type ChangelogCommand struct{}
+
+func (c *ChangelogCommand) Name() string {
+	return "changelog"
+}
+
+func (c *ChangelogCommand) RegisterFlags(ctx context.Context, fs *flagshim.FlagSet) context.Context {
+	fs.Usage = func() {
+		//nolint:errcheck // safe: writing help/usage text to stderr is a presentation output; failures do not affect logic or durability
+		fmt.Fprint(fs.Output(), changelogUsage)
+	}
+	if global, ok := flagshim.FlagFromContext[cli.GlobalFlags](ctx); ok {
+		global.Register(ctx, fs)
+	}
+	flags := &ChangelogFlags{}
+	fs.BoolVar(&flags.JSON, "json", false, "")
+	return flagshim.ContextWithFlag(ctx, flags)
+}
+
+func (c *ChangelogCommand) Run(ctx context.Context, args []string) error {
+	global := cli.GetGlobalFlags(ctx)
+	stdout := cli.GetStdout(ctx)
+	stderr := cli.GetStderr(ctx)
+	logger := cli.GetLogger(ctx)
+	flags := flagshim.MustFlagFromContext[ChangelogFlags](ctx)
+
+	g, err := dsl.LoadGraph(global.ConfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to parse/resolve dsl: %w", err)
+	}
+
+	cfg := &hariti.HaritiConfig{
+		Paths: hariti.Paths{
+			ConfigFile: global.ConfigFile,
+			ConfigDir:  global.ConfigDir,
+			DataDir:    global.DataDir,
+		},
+		Writer:    stdout,
+		ErrWriter: stderr,
+		Logger:    logger,
+	}
+	har := hariti.NewHariti(cfg)
+
+	changes, err := har.Changelog(ctx, g, hariti.ChangelogOptions{
+		Only: args,
+	})
+	if err != nil {
+		return err
+	}
+
+	if flags.JSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(changes); err != nil {
+			return fmt.Errorf("failed to encode changelog to JSON: %w", err)
+		}
+		return nil
+	}
+
+	cli.PrintChangelog(stdout, changes)
+	return nil
+}
+
+func init() {
+	cli.Register(&ChangelogCommand{})
+}
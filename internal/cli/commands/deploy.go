@@ -4,6 +4,8 @@ import (
 	"context"
 	_ "embed"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/kamichidu/go-flagshim"
 	"github.com/kamichidu/go-hariti"
@@ -14,6 +16,13 @@ import (
 //go:embed assets/deploy.txt
 var deployUsage string
 
+type DeployFlags struct {
+	Parallelism  int
+	BuildTimeout time.Duration
+	Force        bool
+	Projection   string
+}
+
 type DeployCommand struct{}
 
 func (c *DeployCommand) Name() string {
@@ -28,7 +37,13 @@ func (c *DeployCommand) RegisterFlags(ctx context.Context, fs *flagshim.FlagSet)
 	if global, ok := flagshim.FlagFromContext[cli.GlobalFlags](ctx); ok {
 		global.Register(ctx, fs)
 	}
-	return ctx
+	flags := &DeployFlags{}
+	fs.IntVar(&flags.Parallelism, "parallelism", 0, "")
+	fs.Alias("parallelism", "p")
+	fs.DurationVar(&flags.BuildTimeout, "build-timeout", 0, "")
+	fs.BoolVar(&flags.Force, "force", false, "")
+	fs.StringVar(&flags.Projection, "projection", "", "")
+	return flagshim.ContextWithFlag(ctx, flags)
 }
 
 func (c *DeployCommand) Run(ctx context.Context, args []string) error {
@@ -36,6 +51,7 @@ func (c *DeployCommand) Run(ctx context.Context, args []string) error {
 	stdout := cli.GetStdout(ctx)
 	stderr := cli.GetStderr(ctx)
 	logger := cli.GetLogger(ctx)
+	flags := flagshim.MustFlagFromContext[DeployFlags](ctx)
 
 	configFile := global.ConfigFile
 	if len(args) > 0 {
@@ -56,13 +72,34 @@ func (c *DeployCommand) Run(ctx context.Context, args []string) error {
 		Writer:    stdout,
 		ErrWriter: stderr,
 		Logger:    logger,
+		Version:   cli.GetVersion(ctx),
 	}
 	har := hariti.NewHariti(cfg)
 
-	_, err = har.Deploy(ctx, g, hariti.DeployOptions{})
+	reporter := cli.NewProgressReporter(stdout)
+
+	_, err = har.Deploy(ctx, g, hariti.DeployOptions{
+		Parallelism:  flags.Parallelism,
+		OnProgress:   reporter.OnDeployProgress,
+		BuildTimeout: flags.BuildTimeout,
+		Force:        flags.Force,
+		Projections:  parseProjections(flags.Projection),
+	})
 	return err
 }
 
+// parseProjections splits the comma separated value of --projection. Deploy
+// validates the names.
+func parseProjections(value string) []hariti.Projection {
+	var projections []hariti.Projection
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			projections = append(projections, hariti.Projection(name))
+		}
+	}
+	return projections
+}
+
 func init() {
 	cli.Register(&DeployCommand{})
 }
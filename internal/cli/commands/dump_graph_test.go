@@ -1,12 +1,17 @@
 package commands_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"io"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/kamichidu/go-flagshim"
+	"github.com/kamichidu/go-hariti/graph"
 	"github.com/kamichidu/go-hariti/internal/cli"
 	"github.com/kamichidu/go-hariti/internal/cli/commands"
 )
@@ -33,3 +38,49 @@ func TestRunDumpGraph_UnsupportedFormat(t *testing.T) {
 		t.Errorf("expected error message to contain 'unsupported config format', got: %v", err)
 	}
 }
+
+func TestRunDumpGraph_EffectiveEnableIf(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "bundles.hariti")
+	src := `use foo/lib.vim
+  as lib
+  enable_if "has('python3')"
+
+use foo/plugin.vim
+  depends (
+    lib
+  )
+`
+	if err := os.WriteFile(configFile, []byte(src), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	ctx := context.Background()
+	global := &cli.GlobalFlags{
+		ConfigFile: configFile,
+		ConfigDir:  filepath.Dir(configFile),
+		DataDir:    filepath.Dir(configFile),
+	}
+	ctx = flagshim.ContextWithFlag(ctx, global)
+	var stdout bytes.Buffer
+	ctx = flagshim.ContextWithStdout(ctx, &stdout)
+	ctx = flagshim.ContextWithStderr(ctx, io.Discard)
+
+	if err := (&commands.DumpGraphCommand{}).Run(ctx, nil); err != nil {
+		t.Fatalf("dump-graph failed: %v", err)
+	}
+
+	var g graph.Graph
+	if err := json.Unmarshal(stdout.Bytes(), &g); err != nil {
+		t.Fatalf("failed to decode graph: %v\n%s", err, stdout.String())
+	}
+	if len(g.Bundles) != 2 {
+		t.Fatalf("expected 2 bundles, got %+v", g.Bundles)
+	}
+	plugin := g.Bundles[1]
+	if plugin.EnableIf != "" || plugin.EffectiveEnableIf != "has('python3')" {
+		t.Errorf("expected plugin guarded by has('python3'), got %+v", plugin)
+	}
+	if len(plugin.Dependencies) != 1 || plugin.Dependencies[0] != "foo/lib.vim" {
+		t.Errorf("expected dependency resolved to foo/lib.vim, got %v", plugin.Dependencies)
+	}
+}
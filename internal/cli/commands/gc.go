@@ -0,0 +1,137 @@
+package commands
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kamichidu/go-flagshim"
+	"github.com/kamichidu/go-hariti"
+	"github.com/kamichidu/go-hariti/internal/cli"
+	"github.com/kamichidu/go-hariti/internal/config/dsl"
+)
+
+//go:embed assets/gc.txt
+var gcUsage string
+
+type GCFlags struct {
+	KeepLast      int
+	KeepNewerThan time.Duration
+	DryRun        bool
+	JSON          bool
+}
+
+type GCCommand struct{}
+
+func (c *GCCommand) Name() string {
+	return "gc"
+}
+
+func (c *GCCommand) RegisterFlags(ctx context.Context, fs *flagshim.FlagSet) context.Context {
+	fs.Usage = func() {
+		//nolint:errcheck // safe: writing help/usage text to stderr is a presentation output; failures do not affect logic or durability
+		fmt.Fprint(fs.Output(), gcUsage)
+	}
+	if global, ok := flagshim.FlagFromContext[cli.GlobalFlags](ctx); ok {
+		global.Register(ctx, fs)
+	}
+	flags := &GCFlags{}
+	fs.IntVar(&flags.KeepLast, "keep-last", 3, "")
+	fs.DurationVar(&flags.KeepNewerThan, "keep-newer-than", 0, "")
+	fs.BoolVar(&flags.DryRun, "dry-run", false, "")
+	fs.BoolVar(&flags.JSON, "json", false, "")
+	return flagshim.ContextWithFlag(ctx, flags)
+}
+
+func (c *GCCommand) Run(ctx context.Context, args []string) error {
+	global := cli.GetGlobalFlags(ctx)
+	stdout := cli.GetStdout(ctx)
+	stderr := cli.GetStderr(ctx)
+	logger := cli.GetLogger(ctx)
+	flags := flagshim.MustFlagFromContext[GCFlags](ctx)
+
+	configFile := global.ConfigFile
+	if len(args) > 0 {
+		configFile = args[0]
+	}
+
+	g, err := dsl.LoadGraph(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to parse/resolve dsl: %w", err)
+	}
+
+	cfg := &hariti.HaritiConfig{
+		Paths: hariti.Paths{
+			ConfigFile: global.ConfigFile,
+			ConfigDir:  global.ConfigDir,
+			DataDir:    global.DataDir,
+		},
+		Writer:    stdout,
+		ErrWriter: stderr,
+		Logger:    logger,
+	}
+	har := hariti.NewHariti(cfg)
+
+	report, err := har.GC(g, hariti.GCOptions{
+		KeepLast:      flags.KeepLast,
+		KeepNewerThan: flags.KeepNewerThan,
+		DryRun:        flags.DryRun,
+	})
+	if err != nil {
+		return err
+	}
+
+	if flags.JSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return fmt.Errorf("failed to encode gc report to JSON: %w", err)
+		}
+		return nil
+	}
+
+	if len(report.Removed) == 0 {
+		//nolint:errcheck // safe: writing command results to terminal is presentation output; failures do not affect operational correctness
+		fmt.Fprintln(stdout, "Nothing to collect.")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	//nolint:errcheck // safe: writing command results to terminal is presentation output; failures do not affect operational correctness
+	fmt.Fprintln(tw, "KIND\tNAME\tSIZE")
+	for _, entry := range report.Removed {
+		//nolint:errcheck // safe: writing command results to terminal is presentation output; failures do not affect operational correctness
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", entry.Kind, entry.Name, formatBytes(entry.Bytes))
+	}
+	//nolint:errcheck // safe: writing command results to terminal is presentation output; failures do not affect operational correctness
+	tw.Flush()
+
+	verb := "Freed"
+	if report.DryRun {
+		verb = "Would free"
+	}
+	//nolint:errcheck // safe: writing command results to terminal is presentation output; failures do not affect operational correctness
+	fmt.Fprintf(stdout, "\n%s %s in %d entries.\n", verb, formatBytes(report.FreedBytes), len(report.Removed))
+	return nil
+}
+
+// formatBytes renders a byte count with a binary unit.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func init() {
+	cli.Register(&GCCommand{})
+}
@@ -0,0 +1,462 @@
+package commands
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kamichidu/go-flagshim"
+	"github.com/kamichidu/go-hariti"
+	"github.com/kamichidu/go-hariti/internal/cli"
+)
+
+//go:embed assets/generations.txt
+var generationsUsage string
+
+//go:embed assets/generations-list.txt
+var generationsListUsage string
+
+//go:embed assets/generations-show.txt
+var generationsShowUsage string
+
+//go:embed assets/generations-diff.txt
+var generationsDiffUsage string
+
+//go:embed assets/generations-tag.txt
+var generationsTagUsage string
+
+type GenerationsCommand struct{}
+
+func (c *GenerationsCommand) Name() string {
+	return "generations"
+}
+
+func (c *GenerationsCommand) RegisterFlags(ctx context.Context, fs *flagshim.FlagSet) context.Context {
+	fs.Usage = func() {
+		//nolint:errcheck // safe: writing help/usage text to stderr is a presentation output; failures do not affect logic or durability
+		fmt.Fprint(fs.Output(), generationsUsage)
+	}
+	if global, ok := flagshim.FlagFromContext[cli.GlobalFlags](ctx); ok {
+		global.Register(ctx, fs)
+	}
+	return ctx
+}
+
+func (c *GenerationsCommand) Run(ctx context.Context, args []string) error {
+	stderr := cli.GetStderr(ctx)
+	//nolint:errcheck // safe: writing help/usage text to stderr is a presentation output; failures do not affect logic or durability
+	fmt.Fprint(stderr, generationsUsage)
+	return cli.ErrNoSubcommand
+}
+
+func (c *GenerationsCommand) Commands() []flagshim.Command {
+	return []flagshim.Command{
+		&GenerationsListCommand{},
+		&GenerationsShowCommand{},
+		&GenerationsDiffCommand{},
+		&GenerationsTagCommand{},
+	}
+}
+
+// newGenerationsHariti builds a Hariti for commands that only read the data
+// directory and need no graph.
+func newGenerationsHariti(ctx context.Context) *hariti.Hariti {
+	global := cli.GetGlobalFlags(ctx)
+	return hariti.NewHariti(&hariti.HaritiConfig{
+		Paths: hariti.Paths{
+			ConfigFile: global.ConfigFile,
+			ConfigDir:  global.ConfigDir,
+			DataDir:    global.DataDir,
+		},
+		Writer:    cli.GetStdout(ctx),
+		ErrWriter: cli.GetStderr(ctx),
+		Logger:    cli.GetLogger(ctx),
+	})
+}
+
+// formatCreatedAt shortens a generation timestamp to whole seconds for
+// display.
+func formatCreatedAt(createdAt string) string {
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return createdAt
+	}
+	return t.Format(time.RFC3339)
+}
+
+// formatMillis prints a duration recorded in milliseconds, or "-" for a
+// phase that did not run.
+func formatMillis(ms int64) string {
+	if ms == 0 {
+		return "-"
+	}
+	return (time.Duration(ms) * time.Millisecond).String()
+}
+
+func formatLabels(labels []string) string {
+	if len(labels) == 0 {
+		return "-"
+	}
+	return strings.Join(labels, ",")
+}
+
+func currentMarker(current bool) string {
+	if current {
+		return "*"
+	}
+	return " "
+}
+
+type GenerationsListFlags struct {
+	JSON bool
+}
+
+type GenerationsListCommand struct{}
+
+func (c *GenerationsListCommand) Name() string {
+	return "list"
+}
+
+func (c *GenerationsListCommand) RegisterFlags(ctx context.Context, fs *flagshim.FlagSet) context.Context {
+	fs.Usage = func() {
+		//nolint:errcheck // safe: writing help/usage text to stderr is a presentation output; failures do not affect logic or durability
+		fmt.Fprint(fs.Output(), generationsListUsage)
+	}
+	if global, ok := flagshim.FlagFromContext[cli.GlobalFlags](ctx); ok {
+		global.Register(ctx, fs)
+	}
+	flags := &GenerationsListFlags{}
+	fs.BoolVar(&flags.JSON, "json", false, "")
+	return flagshim.ContextWithFlag(ctx, flags)
+}
+
+func (c *GenerationsListCommand) Run(ctx context.Context, args []string) error {
+	stdout := cli.GetStdout(ctx)
+	flags := flagshim.MustFlagFromContext[GenerationsListFlags](ctx)
+
+	generations, err := newGenerationsHariti(ctx).Generations()
+	if err != nil {
+		return err
+	}
+
+	if flags.JSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(generations); err != nil {
+			return fmt.Errorf("failed to encode generations to JSON: %w", err)
+		}
+		return nil
+	}
+
+	if len(generations) == 0 {
+		//nolint:errcheck // safe: writing command results to terminal is presentation output; failures do not affect operational correctness
+		fmt.Fprintln(stdout, "No generations deployed.")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	//nolint:errcheck // safe: writing command results to terminal is presentation output; failures do not affect operational correctness
+	fmt.Fprintln(tw, "  ID\tCREATED\tLOCK HASH\tBUNDLES\tLABELS")
+	for _, gen := range generations {
+		//nolint:errcheck // safe: writing command results to terminal is presentation output; failures do not affect operational correctness
+		fmt.Fprintf(tw, "%s %s\t%s\t%s\t%d\t%s\n", currentMarker(gen.Current), gen.ID, formatCreatedAt(gen.CreatedAt), cli.ShortRevision(gen.LockHash), gen.BundleCount, formatLabels(gen.Labels))
+	}
+	return tw.Flush()
+}
+
+type GenerationsShowFlags struct {
+	JSON bool
+}
+
+type GenerationsShowCommand struct{}
+
+func (c *GenerationsShowCommand) Name() string {
+	return "show"
+}
+
+func (c *GenerationsShowCommand) RegisterFlags(ctx context.Context, fs *flagshim.FlagSet) context.Context {
+	fs.Usage = func() {
+		//nolint:errcheck // safe: writing help/usage text to stderr is a presentation output; failures do not affect logic or durability
+		fmt.Fprint(fs.Output(), generationsShowUsage)
+	}
+	if global, ok := flagshim.FlagFromContext[cli.GlobalFlags](ctx); ok {
+		global.Register(ctx, fs)
+	}
+	flags := &GenerationsShowFlags{}
+	fs.BoolVar(&flags.JSON, "json", false, "")
+	return flagshim.ContextWithFlag(ctx, flags)
+}
+
+func (c *GenerationsShowCommand) Run(ctx context.Context, args []string) error {
+	stdout := cli.GetStdout(ctx)
+	flags := flagshim.MustFlagFromContext[GenerationsShowFlags](ctx)
+
+	if len(args) != 1 {
+		return fmt.Errorf("generations show requires exactly one generation ID")
+	}
+
+	har := newGenerationsHariti(ctx)
+	genID, err := har.ResolveGeneration(args[0])
+	if err != nil {
+		return err
+	}
+	detail, err := har.InspectGeneration(genID)
+	if err != nil {
+		return err
+	}
+
+	if flags.JSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(detail); err != nil {
+			return fmt.Errorf("failed to encode generation to JSON: %w", err)
+		}
+		return nil
+	}
+
+	return printGeneration(stdout, detail)
+}
+
+func printGeneration(w io.Writer, detail *hariti.GenerationDetail) error {
+	current := ""
+	if detail.Current {
+		current = " (current)"
+	}
+	//nolint:errcheck // safe: writing command results to terminal is presentation output; failures do not affect operational correctness
+	fmt.Fprintf(w, "Generation: %s%s\nCreated:    %s\nLock hash:  %s\n", detail.ID, current, formatCreatedAt(detail.CreatedAt), detail.LockHash)
+	if len(detail.Labels) > 0 {
+		//nolint:errcheck // safe: writing command results to terminal is presentation output; failures do not affect operational correctness
+		fmt.Fprintf(w, "Labels:     %s\n", strings.Join(detail.Labels, ", "))
+	}
+	if detail.HaritiVersion != "" {
+		//nolint:errcheck // safe: writing command results to terminal is presentation output; failures do not affect operational correctness
+		fmt.Fprintf(w, "Hariti:     %s\n", detail.HaritiVersion)
+	}
+	if detail.VimVersion != "" {
+		//nolint:errcheck // safe: writing command results to terminal is presentation output; failures do not affect operational correctness
+		fmt.Fprintf(w, "Vim:        %s\n", detail.VimVersion)
+	}
+	//nolint:errcheck // safe: writing command results to terminal is presentation output; failures do not affect operational correctness
+	fmt.Fprintln(w)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	//nolint:errcheck // safe: writing command results to terminal is presentation output; failures do not affect operational correctness
+	fmt.Fprintln(tw, "BUNDLE\tREVISION\tSOURCE")
+	for _, entry := range detail.Lock.Bundles {
+		//nolint:errcheck // safe: writing command results to terminal is presentation output; failures do not affect operational correctness
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", entry.ID, cli.ShortRevision(entry.Revision), entry.Source)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(detail.Bundles) > 0 {
+		//nolint:errcheck // safe: writing command results to terminal is presentation output; failures do not affect operational correctness
+		fmt.Fprintln(w)
+		tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		//nolint:errcheck // safe: writing command results to terminal is presentation output; failures do not affect operational correctness
+		fmt.Fprintln(tw, "BUNDLE\tARCHIVE\tBUILD\tHELPTAGS\tLOG")
+		for _, bundle := range detail.Bundles {
+			archive := formatMillis(bundle.ArchiveMillis)
+			if bundle.Reused {
+				archive = "reused"
+			}
+			//nolint:errcheck // safe: writing command results to terminal is presentation output; failures do not affect operational correctness
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", bundle.ID, archive, formatMillis(bundle.BuildMillis), formatMillis(bundle.HelptagsMillis), bundle.Log)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	//nolint:errcheck // safe: writing command results to terminal is presentation output; failures do not affect operational correctness
+	fmt.Fprintf(w, "\npackadd.vim:\n%s", detail.Packadd)
+	return nil
+}
+
+type GenerationsDiffFlags struct {
+	JSON bool
+}
+
+type GenerationsDiffCommand struct{}
+
+func (c *GenerationsDiffCommand) Name() string {
+	return "diff"
+}
+
+func (c *GenerationsDiffCommand) RegisterFlags(ctx context.Context, fs *flagshim.FlagSet) context.Context {
+	fs.Usage = func() {
+		//nolint:errcheck // safe: writing help/usage text to stderr is a presentation output; failures do not affect logic or durability
+		fmt.Fprint(fs.Output(), generationsDiffUsage)
+	}
+	if global, ok := flagshim.FlagFromContext[cli.GlobalFlags](ctx); ok {
+		global.Register(ctx, fs)
+	}
+	flags := &GenerationsDiffFlags{}
+	fs.BoolVar(&flags.JSON, "json", false, "")
+	return flagshim.ContextWithFlag(ctx, flags)
+}
+
+func (c *GenerationsDiffCommand) Run(ctx context.Context, args []string) error {
+	stdout := cli.GetStdout(ctx)
+	flags := flagshim.MustFlagFromContext[GenerationsDiffFlags](ctx)
+
+	if len(args) != 2 {
+		return fmt.Errorf("generations diff requires exactly two generation IDs")
+	}
+
+	har := newGenerationsHariti(ctx)
+	from, err := har.ResolveGeneration(args[0])
+	if err != nil {
+		return err
+	}
+	to, err := har.ResolveGeneration(args[1])
+	if err != nil {
+		return err
+	}
+	diff, err := har.DiffGenerations(ctx, from, to)
+	if err != nil {
+		return err
+	}
+
+	if flags.JSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(diff); err != nil {
+			return fmt.Errorf("failed to encode generation diff to JSON: %w", err)
+		}
+		return nil
+	}
+
+	return printGenerationDiff(stdout, diff)
+}
+
+func printGenerationDiff(w io.Writer, diff *hariti.GenerationDiff) error {
+	//nolint:errcheck // safe: writing command results to terminal is presentation output; failures do not affect operational correctness
+	fmt.Fprintf(w, "Generation %s -> %s\n\n", diff.From, diff.To)
+
+	if len(diff.Bundles) == 0 {
+		//nolint:errcheck // safe: writing command results to terminal is presentation output; failures do not affect operational correctness
+		fmt.Fprintln(w, "No bundle changes.")
+	} else {
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		//nolint:errcheck // safe: writing command results to terminal is presentation output; failures do not affect operational correctness
+		fmt.Fprintln(tw, "BUNDLE\tCHANGE\tDETAIL")
+		for _, change := range diff.Bundles {
+			//nolint:errcheck // safe: writing command results to terminal is presentation output; failures do not affect operational correctness
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", change.BundleID, change.Type, describeBundleDiff(change))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if diff.Packadd == "" {
+		//nolint:errcheck // safe: writing command results to terminal is presentation output; failures do not affect operational correctness
+		fmt.Fprintln(w, "\npackadd.vim: unchanged")
+		return nil
+	}
+	//nolint:errcheck // safe: writing command results to terminal is presentation output; failures do not affect operational correctness
+	fmt.Fprintf(w, "\npackadd.vim:\n%s", diff.Packadd)
+	return nil
+}
+
+// describeBundleDiff summarizes what changed about a bundle in one line.
+func describeBundleDiff(change hariti.BundleDiff) string {
+	switch change.Type {
+	case hariti.ChangeAdded:
+		return cli.ShortRevision(change.NewRevision)
+	case hariti.ChangeRemoved:
+		return cli.ShortRevision(change.OldRevision)
+	}
+
+	var parts []string
+	if change.OldRevision != "" || change.NewRevision != "" {
+		revision := fmt.Sprintf("%s..%s", cli.ShortRevision(change.OldRevision), cli.ShortRevision(change.NewRevision))
+		switch {
+		case change.Commits < 0:
+		case change.Dropped > 0:
+			revision += fmt.Sprintf(" (+%d, -%d dropped)", change.Commits, change.Dropped)
+		default:
+			revision += fmt.Sprintf(" (+%d)", change.Commits)
+		}
+		parts = append(parts, revision)
+	}
+	if change.OldSource != "" || change.NewSource != "" {
+		parts = append(parts, fmt.Sprintf("source %s -> %s", change.OldSource, change.NewSource))
+	}
+	if change.EnableIfChanged {
+		parts = append(parts, fmt.Sprintf("enable_if %q -> %q", change.OldEnableIf, change.NewEnableIf))
+	}
+	if change.BuildChanged {
+		parts = append(parts, "build steps changed")
+	}
+	return strings.Join(parts, "; ")
+}
+
+type GenerationsTagFlags struct {
+	Delete bool
+	Force  bool
+}
+
+type GenerationsTagCommand struct{}
+
+func (c *GenerationsTagCommand) Name() string {
+	return "tag"
+}
+
+func (c *GenerationsTagCommand) RegisterFlags(ctx context.Context, fs *flagshim.FlagSet) context.Context {
+	fs.Usage = func() {
+		//nolint:errcheck // safe: writing help/usage text to stderr is a presentation output; failures do not affect logic or durability
+		fmt.Fprint(fs.Output(), generationsTagUsage)
+	}
+	if global, ok := flagshim.FlagFromContext[cli.GlobalFlags](ctx); ok {
+		global.Register(ctx, fs)
+	}
+	flags := &GenerationsTagFlags{}
+	fs.BoolVar(&flags.Delete, "delete", false, "")
+	fs.Alias("delete", "d")
+	fs.BoolVar(&flags.Force, "force", false, "")
+	return flagshim.ContextWithFlag(ctx, flags)
+}
+
+func (c *GenerationsTagCommand) Run(ctx context.Context, args []string) error {
+	stdout := cli.GetStdout(ctx)
+	flags := flagshim.MustFlagFromContext[GenerationsTagFlags](ctx)
+	har := newGenerationsHariti(ctx)
+
+	if flags.Delete {
+		if len(args) != 1 {
+			return fmt.Errorf("generations tag --delete requires exactly one label")
+		}
+		genID, err := har.UntagGeneration(args[0])
+		if err != nil {
+			return err
+		}
+		//nolint:errcheck // safe: writing command results to terminal is presentation output; failures do not affect operational correctness
+		fmt.Fprintf(stdout, "Removed label %s from generation %s.\n", args[0], genID)
+		return nil
+	}
+
+	if len(args) != 2 {
+		return fmt.Errorf("generations tag requires a generation and a label")
+	}
+	genID, err := har.TagGeneration(args[0], args[1], hariti.TagOptions{
+		Force: flags.Force,
+	})
+	if err != nil {
+		return err
+	}
+	//nolint:errcheck // safe: writing command results to terminal is presentation output; failures do not affect operational correctness
+	fmt.Fprintf(stdout, "Labeled generation %s as %s.\n", genID, args[1])
+	return nil
+}
+
+func init() {
+	cli.Register(&GenerationsCommand{})
+}
@@ -0,0 +1,210 @@
+package commands_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kamichidu/go-flagshim"
+	"github.com/kamichidu/go-hariti"
+	"github.com/kamichidu/go-hariti/internal/cli"
+	"github.com/kamichidu/go-hariti/internal/cli/commands"
+)
+
+func writeGeneration(t *testing.T, dataDir, id, createdAt string, lock hariti.Lockfile) {
+	genDir := filepath.Join(dataDir, "generations", id)
+	if err := os.MkdirAll(genDir, 0755); err != nil {
+		t.Fatalf("failed to create generation dir: %v", err)
+	}
+	files := map[string]any{
+		"metadata.json": hariti.GenerationMetadata{
+			ID:            id,
+			CreatedAt:     createdAt,
+			LockHash:      strings.Repeat("ab", 32),
+			HaritiVersion: "v1.0.0",
+			Bundles: []hariti.GenerationBundle{
+				{ID: "my/plugin", ArchiveMillis: 1500, Log: "logs/my_plugin.log"},
+			},
+		},
+		"lock.json": lock,
+	}
+	for name, v := range files {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("failed to serialize %s: %v", name, err)
+		}
+		if err := os.WriteFile(filepath.Join(genDir, name), data, 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(genDir, "packadd.vim"), []byte("packadd "+id+"\n"), 0644); err != nil {
+		t.Fatalf("failed to write packadd.vim: %v", err)
+	}
+}
+
+func runGenerationsCommand(t *testing.T, dataDir string, args ...string) (string, error) {
+	ctx := context.Background()
+	global := &cli.GlobalFlags{
+		ConfigFile: filepath.Join(dataDir, "bundles.hariti"),
+		ConfigDir:  dataDir,
+		DataDir:    dataDir,
+	}
+	ctx = flagshim.ContextWithFlag(ctx, global)
+	var stdout bytes.Buffer
+	ctx = flagshim.ContextWithStdout(ctx, &stdout)
+	ctx = flagshim.ContextWithStderr(ctx, io.Discard)
+
+	var cmd flagshim.Command
+	for _, sub := range (&commands.GenerationsCommand{}).Commands() {
+		if sub.Name() == args[0] {
+			cmd = sub
+		}
+	}
+	if cmd == nil {
+		t.Fatalf("no generations subcommand %s", args[0])
+	}
+	fs := flagshim.NewFlagSet(cmd.Name(), flag.ContinueOnError)
+	ctx = cmd.RegisterFlags(ctx, fs)
+	if err := fs.Parse(args[1:]); err != nil {
+		t.Fatalf("failed to parse flags: %v", err)
+	}
+	err := cmd.Run(ctx, fs.Args())
+	return stdout.String(), err
+}
+
+func TestGenerationsCommand(t *testing.T) {
+	dataDir := t.TempDir()
+	lock := hariti.Lockfile{
+		Bundles: []hariti.LockfileEntry{
+			{ID: "my/plugin", Source: "https://example.com/my/plugin", Revision: "0123456789abcdef0123456789abcdef01234567"},
+		},
+	}
+	writeGeneration(t, dataDir, "20260101-000000-aaaaaaaa", "2026-01-01T00:00:00Z", lock)
+	writeGeneration(t, dataDir, "20260102-000000-bbbbbbbb", "2026-01-02T00:00:00Z", hariti.Lockfile{})
+	if err := os.Symlink(filepath.Join("generations", "20260101-000000-aaaaaaaa"), filepath.Join(dataDir, "current")); err != nil {
+		t.Fatalf("failed to create current link: %v", err)
+	}
+
+	// list marks the current generation
+	out, err := runGenerationsCommand(t, dataDir, "list")
+	if err != nil {
+		t.Fatalf("generations list failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected header and 2 generations, got:\n%s", out)
+	}
+	if !strings.HasPrefix(lines[1], "* 20260101-000000-aaaaaaaa") || !strings.HasPrefix(lines[2], "  20260102-000000-bbbbbbbb") {
+		t.Errorf("unexpected generation listing:\n%s", out)
+	}
+
+	// list --json
+	out, err = runGenerationsCommand(t, dataDir, "list", "--json")
+	if err != nil {
+		t.Fatalf("generations list --json failed: %v", err)
+	}
+	var generations []hariti.Generation
+	if err := json.Unmarshal([]byte(out), &generations); err != nil {
+		t.Fatalf("failed to decode JSON output: %v\n%s", err, out)
+	}
+	if len(generations) != 2 || !generations[0].Current || generations[0].BundleCount != 1 {
+		t.Errorf("unexpected JSON listing: %+v", generations)
+	}
+
+	// show prints the lock snapshot and packadd.vim
+	out, err = runGenerationsCommand(t, dataDir, "show", "20260101-000000-aaaaaaaa")
+	if err != nil {
+		t.Fatalf("generations show failed: %v", err)
+	}
+	for _, want := range []string{"Generation: 20260101-000000-aaaaaaaa (current)", "Hariti:     v1.0.0", "my/plugin", "0123456789ab", "1.5s", "logs/my_plugin.log", "packadd 20260101-000000-aaaaaaaa"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected show output to contain %q, got:\n%s", want, out)
+		}
+	}
+
+	// show --json
+	out, err = runGenerationsCommand(t, dataDir, "show", "--json", "20260102-000000-bbbbbbbb")
+	if err != nil {
+		t.Fatalf("generations show --json failed: %v", err)
+	}
+	var detail hariti.GenerationDetail
+	if err := json.Unmarshal([]byte(out), &detail); err != nil {
+		t.Fatalf("failed to decode JSON output: %v\n%s", err, out)
+	}
+	if detail.ID != "20260102-000000-bbbbbbbb" || detail.Current || detail.Packadd != "packadd 20260102-000000-bbbbbbbb\n" {
+		t.Errorf("unexpected JSON detail: %+v", detail)
+	}
+
+	// show requires a generation ID
+	if _, err := runGenerationsCommand(t, dataDir, "show"); err == nil {
+		t.Error("expected error without generation ID, got nil")
+	}
+
+	// diff reports removed bundles and the packadd.vim diff
+	out, err = runGenerationsCommand(t, dataDir, "diff", "20260101-000000-aaaaaaaa", "20260102-000000-bbbbbbbb")
+	if err != nil {
+		t.Fatalf("generations diff failed: %v", err)
+	}
+	for _, want := range []string{"Generation 20260101-000000-aaaaaaaa -> 20260102-000000-bbbbbbbb", "my/plugin  removed  0123456789ab", "@@ -1 +1 @@\n-packadd 20260101-000000-aaaaaaaa\n+packadd 20260102-000000-bbbbbbbb\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected diff output to contain %q, got:\n%s", want, out)
+		}
+	}
+
+	// diff --json
+	out, err = runGenerationsCommand(t, dataDir, "diff", "--json", "20260101-000000-aaaaaaaa", "20260101-000000-aaaaaaaa")
+	if err != nil {
+		t.Fatalf("generations diff --json failed: %v", err)
+	}
+	var diff hariti.GenerationDiff
+	if err := json.Unmarshal([]byte(out), &diff); err != nil {
+		t.Fatalf("failed to decode JSON output: %v\n%s", err, out)
+	}
+	if len(diff.Bundles) != 0 || diff.Packadd != "" {
+		t.Errorf("expected no differences, got %+v", diff)
+	}
+
+	// tag labels a generation, shown in list and usable in place of its ID
+	out, err = runGenerationsCommand(t, dataDir, "tag", "20260102-000000-bbbbbbbb", "stable")
+	if err != nil {
+		t.Fatalf("generations tag failed: %v", err)
+	}
+	if !strings.Contains(out, "Labeled generation 20260102-000000-bbbbbbbb as stable.") {
+		t.Errorf("unexpected tag output:\n%s", out)
+	}
+	out, err = runGenerationsCommand(t, dataDir, "list")
+	if err != nil {
+		t.Fatalf("generations list failed: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(out), "\n"); !strings.Contains(lines[0], "LABELS") || !strings.HasSuffix(lines[2], "stable") {
+		t.Errorf("expected stable label in listing, got:\n%s", out)
+	}
+	out, err = runGenerationsCommand(t, dataDir, "show", "stable")
+	if err != nil {
+		t.Fatalf("generations show by label failed: %v", err)
+	}
+	if !strings.Contains(out, "Generation: 20260102-000000-bbbbbbbb") || !strings.Contains(out, "Labels:     stable") {
+		t.Errorf("expected show by label to print the labeled generation, got:\n%s", out)
+	}
+	out, err = runGenerationsCommand(t, dataDir, "tag", "--delete", "stable")
+	if err != nil {
+		t.Fatalf("generations tag --delete failed: %v", err)
+	}
+	if !strings.Contains(out, "Removed label stable from generation 20260102-000000-bbbbbbbb.") {
+		t.Errorf("unexpected tag --delete output:\n%s", out)
+	}
+	if _, err := runGenerationsCommand(t, dataDir, "show", "stable"); err == nil {
+		t.Error("expected show of a removed label to fail, got nil")
+	}
+
+	// diff requires two generation IDs
+	if _, err := runGenerationsCommand(t, dataDir, "diff", "20260101-000000-aaaaaaaa"); err == nil {
+		t.Error("expected error with a single generation ID, got nil")
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	_ "embed"
 	"fmt"
+	"time"
 
 	"github.com/kamichidu/go-flagshim"
 	"github.com/kamichidu/go-hariti"
+	"github.com/kamichidu/go-hariti/graph"
 	"github.com/kamichidu/go-hariti/internal/cli"
 	"github.com/kamichidu/go-hariti/internal/config/dsl"
 )
@@ -15,7 +17,16 @@ import (
 var installUsage string
 
 type InstallFlags struct {
-	Parallelism int
+	Parallelism   int
+	Retries       int
+	BundleTimeout time.Duration
+	Clone         string
+	Depth         int
+	BuildTimeout  time.Duration
+	Force         bool
+	Frozen        bool
+	KeepGoing     bool
+	Projection    string
 }
 
 type InstallCommand struct{}
@@ -35,6 +46,15 @@ func (c *InstallCommand) RegisterFlags(ctx context.Context, fs *flagshim.FlagSet
 	flags := &InstallFlags{}
 	fs.IntVar(&flags.Parallelism, "parallelism", 0, "")
 	fs.Alias("parallelism", "p")
+	fs.IntVar(&flags.Retries, "retries", 0, "")
+	fs.DurationVar(&flags.BundleTimeout, "bundle-timeout", 0, "")
+	fs.StringVar(&flags.Clone, "clone", "", "")
+	fs.IntVar(&flags.Depth, "depth", 0, "")
+	fs.DurationVar(&flags.BuildTimeout, "build-timeout", 0, "")
+	fs.BoolVar(&flags.Force, "force", false, "")
+	fs.BoolVar(&flags.Frozen, "frozen", false, "")
+	fs.BoolVar(&flags.KeepGoing, "keep-going", false, "")
+	fs.StringVar(&flags.Projection, "projection", "", "")
 	return flagshim.ContextWithFlag(ctx, flags)
 }
 
@@ -64,6 +84,7 @@ func (c *InstallCommand) Run(ctx context.Context, args []string) error {
 		Writer:    stdout,
 		ErrWriter: stderr,
 		Logger:    logger,
+		Version:   cli.GetVersion(ctx),
 	}
 	har := hariti.NewHariti(cfg)
 
@@ -71,10 +92,21 @@ func (c *InstallCommand) Run(ctx context.Context, args []string) error {
 
 	return har.Install(ctx, g, hariti.InstallOptions{
 		Sync: hariti.SyncOptions{
-			Parallelism: flags.Parallelism,
-			OnProgress:  reporter.OnProgress,
+			Parallelism:   flags.Parallelism,
+			Retries:       flags.Retries,
+			BundleTimeout: flags.BundleTimeout,
+			Clone:         graph.Clone{Strategy: graph.CloneStrategy(flags.Clone), Depth: flags.Depth},
+			OnProgress:    reporter.OnProgress,
+			FromLockfile:  flags.Frozen,
+			KeepGoing:     flags.KeepGoing,
+		},
+		Deploy: hariti.DeployOptions{
+			Parallelism:  flags.Parallelism,
+			OnProgress:   reporter.OnDeployProgress,
+			BuildTimeout: flags.BuildTimeout,
+			Force:        flags.Force,
+			Projections:  parseProjections(flags.Projection),
 		},
-		Deploy: hariti.DeployOptions{},
 	})
 }
 
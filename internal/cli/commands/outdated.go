@@ -0,0 +1,117 @@
+package commands
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/kamichidu/go-flagshim"
+	"github.com/kamichidu/go-hariti"
+	"github.com/kamichidu/go-hariti/internal/cli"
+	"github.com/kamichidu/go-hariti/internal/config/dsl"
+)
+
+//go:embed assets/outdated.txt
+var outdatedUsage string
+
+type OutdatedFlags struct {
+	Parallelism int
+	JSON        bool
+}
+
+type OutdatedCommand struct{}
+
+func (c *OutdatedCommand) Name() string {
+	return "outdated"
+}
+
+func (c *OutdatedCommand) RegisterFlags(ctx context.Context, fs *flagshim.FlagSet) context.Context {
+	fs.Usage = func() {
+		//nolint:errcheck // safe: writing help/usage text to stderr is a presentation output; failures do not affect logic or durability
+		fmt.Fprint(fs.Output(), outdatedUsage)
+	}
+	if global, ok := flagshim.FlagFromContext[cli.GlobalFlags](ctx); ok {
+		global.Register(ctx, fs)
+	}
+	flags := &OutdatedFlags{}
+	fs.IntVar(&flags.Parallelism, "parallelism", 0, "")
+	fs.Alias("parallelism", "p")
+	fs.BoolVar(&flags.JSON, "json", false, "")
+	return flagshim.ContextWithFlag(ctx, flags)
+}
+
+func (c *OutdatedCommand) Run(ctx context.Context, args []string) error {
+	global := cli.GetGlobalFlags(ctx)
+	stdout := cli.GetStdout(ctx)
+	stderr := cli.GetStderr(ctx)
+	logger := cli.GetLogger(ctx)
+	flags := flagshim.MustFlagFromContext[OutdatedFlags](ctx)
+
+	configFile := global.ConfigFile
+	if len(args) > 0 {
+		configFile = args[0]
+	}
+
+	g, err := dsl.LoadGraph(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to parse/resolve dsl: %w", err)
+	}
+
+	cfg := &hariti.HaritiConfig{
+		Paths: hariti.Paths{
+			ConfigFile: global.ConfigFile,
+			ConfigDir:  global.ConfigDir,
+			DataDir:    global.DataDir,
+		},
+		Writer:    stdout,
+		ErrWriter: stderr,
+		Logger:    logger,
+	}
+	har := hariti.NewHariti(cfg)
+
+	outdated, err := har.Outdated(ctx, g, hariti.OutdatedOptions{
+		Parallelism: flags.Parallelism,
+	})
+	if err != nil {
+		return err
+	}
+
+	if flags.JSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(outdated); err != nil {
+			return fmt.Errorf("failed to encode outdated bundles to JSON: %w", err)
+		}
+		return nil
+	}
+
+	if len(outdated) == 0 {
+		//nolint:errcheck // safe: writing command results to terminal is presentation output; failures do not affect operational correctness
+		fmt.Fprintln(stdout, "All bundles are up to date.")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	//nolint:errcheck // safe: writing command results to terminal is presentation output; failures do not affect operational correctness
+	fmt.Fprintln(tw, "BUNDLE\tLOCKED\tUPSTREAM\tBEHIND")
+	for _, b := range outdated {
+		locked := cli.ShortRevision(b.LockedRevision)
+		if locked == "" {
+			locked = "-"
+		}
+		behind := "?"
+		if b.Behind >= 0 {
+			behind = strconv.Itoa(b.Behind)
+		}
+		//nolint:errcheck // safe: writing command results to terminal is presentation output; failures do not affect operational correctness
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", b.BundleID, locked, cli.ShortRevision(b.UpstreamRevision), behind)
+	}
+	return tw.Flush()
+}
+
+func init() {
+	cli.Register(&OutdatedCommand{})
+}
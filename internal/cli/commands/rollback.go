@@ -0,0 +1,99 @@
+package commands
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"strconv"
+
+	"github.com/kamichidu/go-flagshim"
+	"github.com/kamichidu/go-hariti"
+	"github.com/kamichidu/go-hariti/internal/cli"
+)
+
+//go:embed assets/rollback.txt
+var rollbackUsage string
+
+// maxRelativeGeneration is the largest N of the -N targets accepted where
+// flags are; larger ones are passed after --.
+const maxRelativeGeneration = 999
+
+type RollbackFlags struct {
+	RestoreLock bool
+	// Relative is the -N target given among the flags.
+	Relative string
+}
+
+// relativeGenerationFlag is the boolean flag -N, which selects the Nth
+// generation before the current one as the rollback target.
+type relativeGenerationFlag struct {
+	target *string
+	n      int
+}
+
+func (f relativeGenerationFlag) String() string {
+	return ""
+}
+
+func (f relativeGenerationFlag) Set(s string) error {
+	set, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	if set {
+		*f.target = "-" + strconv.Itoa(f.n)
+	}
+	return nil
+}
+
+func (f relativeGenerationFlag) IsBoolFlag() bool {
+	return true
+}
+
+type RollbackCommand struct{}
+
+func (c *RollbackCommand) Name() string {
+	return "rollback"
+}
+
+func (c *RollbackCommand) RegisterFlags(ctx context.Context, fs *flagshim.FlagSet) context.Context {
+	fs.Usage = func() {
+		//nolint:errcheck // safe: writing help/usage text to stderr is a presentation output; failures do not affect logic or durability
+		fmt.Fprint(fs.Output(), rollbackUsage)
+	}
+	if global, ok := flagshim.FlagFromContext[cli.GlobalFlags](ctx); ok {
+		global.Register(ctx, fs)
+	}
+	flags := &RollbackFlags{}
+	fs.BoolVar(&flags.RestoreLock, "restore-lock", false, "")
+	// -N looks like a flag, so it is parsed as one wherever flags may appear
+	for n := 1; n <= maxRelativeGeneration; n++ {
+		fs.Var(relativeGenerationFlag{target: &flags.Relative, n: n}, strconv.Itoa(n), "")
+	}
+	return flagshim.ContextWithFlag(ctx, flags)
+}
+
+func (c *RollbackCommand) Run(ctx context.Context, args []string) error {
+	flags := flagshim.MustFlagFromContext[RollbackFlags](ctx)
+
+	if flags.Relative != "" {
+		args = append([]string{flags.Relative}, args...)
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("rollback takes at most one generation, got %d", len(args))
+	}
+	target := ""
+	if len(args) == 1 {
+		target = args[0]
+	}
+
+	har := newGenerationsHariti(ctx)
+	_, err := har.Rollback(target, hariti.RollbackOptions{
+		RestoreLock: flags.RestoreLock,
+	})
+	return err
+}
+
+func init() {
+	cli.Register(&RollbackCommand{})
+}
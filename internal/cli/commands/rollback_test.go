@@ -0,0 +1,93 @@
+package commands_test
+
+import (
+	"context"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kamichidu/go-flagshim"
+	"github.com/kamichidu/go-hariti"
+	"github.com/kamichidu/go-hariti/internal/cli"
+	"github.com/kamichidu/go-hariti/internal/cli/commands"
+)
+
+func TestRollbackCommand_RelativeTarget(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		expectCurrent string
+		expectLock    bool
+	}{
+		{
+			name:          "one generation back",
+			args:          []string{"-1"},
+			expectCurrent: "20260102-000000-bbbbbbbb",
+		},
+		{
+			name:          "two generations back",
+			args:          []string{"-2"},
+			expectCurrent: "20260101-000000-aaaaaaaa",
+		},
+		{
+			name:          "followed by options",
+			args:          []string{"-2", "--restore-lock"},
+			expectCurrent: "20260101-000000-aaaaaaaa",
+			expectLock:    true,
+		},
+		{
+			name:          "after a global flag",
+			args:          []string{"-v", "-1"},
+			expectCurrent: "20260102-000000-bbbbbbbb",
+		},
+		{
+			name:          "after the separator",
+			args:          []string{"--", "-1"},
+			expectCurrent: "20260102-000000-bbbbbbbb",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dataDir := t.TempDir()
+			for _, id := range []string{"20260101-000000-aaaaaaaa", "20260102-000000-bbbbbbbb", "20260103-000000-cccccccc"} {
+				writeGeneration(t, dataDir, id, id[:4]+"-"+id[4:6]+"-"+id[6:8]+"T00:00:00Z", hariti.Lockfile{})
+			}
+			if err := os.Symlink(filepath.Join("generations", "20260103-000000-cccccccc"), filepath.Join(dataDir, "current")); err != nil {
+				t.Fatalf("failed to create current link: %v", err)
+			}
+
+			ctx := context.Background()
+			ctx = flagshim.ContextWithFlag(ctx, &cli.GlobalFlags{
+				ConfigFile: filepath.Join(dataDir, "bundles.hariti"),
+				ConfigDir:  dataDir,
+				DataDir:    dataDir,
+			})
+			ctx = flagshim.ContextWithStdout(ctx, io.Discard)
+			ctx = flagshim.ContextWithStderr(ctx, io.Discard)
+
+			cmd := &commands.RollbackCommand{}
+			fs := flagshim.NewFlagSet(cmd.Name(), flag.ContinueOnError)
+			ctx = cmd.RegisterFlags(ctx, fs)
+			if err := fs.Parse(tc.args); err != nil {
+				t.Fatalf("failed to parse flags: %v", err)
+			}
+			if err := cmd.Run(ctx, fs.Args()); err != nil {
+				t.Fatalf("rollback failed: %v", err)
+			}
+
+			target, err := os.Readlink(filepath.Join(dataDir, "current"))
+			if err != nil {
+				t.Fatalf("failed to read current link: %v", err)
+			}
+			if filepath.Base(target) != tc.expectCurrent {
+				t.Errorf("expected current to be %s, got %s", tc.expectCurrent, target)
+			}
+			if _, err := os.Stat(filepath.Join(dataDir, "hariti.lock")); (err == nil) != tc.expectLock {
+				t.Errorf("expected lockfile restored to be %v, got stat error %v", tc.expectLock, err)
+			}
+		})
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	_ "embed"
 	"fmt"
+	"time"
 
 	"github.com/kamichidu/go-flagshim"
 	"github.com/kamichidu/go-hariti"
+	"github.com/kamichidu/go-hariti/graph"
 	"github.com/kamichidu/go-hariti/internal/cli"
 	"github.com/kamichidu/go-hariti/internal/config/dsl"
 )
@@ -15,7 +17,14 @@ import (
 var syncUsage string
 
 type SyncFlags struct {
-	Parallelism int
+	Parallelism   int
+	Retries       int
+	BundleTimeout time.Duration
+	Clone         string
+	Depth         int
+	Frozen        bool
+	KeepGoing     bool
+	Changelog     bool
 }
 
 type SyncCommand struct{}
@@ -35,6 +44,13 @@ func (c *SyncCommand) RegisterFlags(ctx context.Context, fs *flagshim.FlagSet) c
 	flags := &SyncFlags{}
 	fs.IntVar(&flags.Parallelism, "parallelism", 0, "")
 	fs.Alias("parallelism", "p")
+	fs.IntVar(&flags.Retries, "retries", 0, "")
+	fs.DurationVar(&flags.BundleTimeout, "bundle-timeout", 0, "")
+	fs.StringVar(&flags.Clone, "clone", "", "")
+	fs.IntVar(&flags.Depth, "depth", 0, "")
+	fs.BoolVar(&flags.Frozen, "frozen", false, "")
+	fs.BoolVar(&flags.KeepGoing, "keep-going", false, "")
+	fs.BoolVar(&flags.Changelog, "changelog", false, "")
 	return flagshim.ContextWithFlag(ctx, flags)
 }
 
@@ -70,10 +86,27 @@ func (c *SyncCommand) Run(ctx context.Context, args []string) error {
 	reporter := cli.NewProgressReporter(stdout)
 
 	_, err = har.Sync(ctx, g, hariti.SyncOptions{
-		Parallelism: flags.Parallelism,
-		OnProgress:  reporter.OnProgress,
+		Parallelism:   flags.Parallelism,
+		Retries:       flags.Retries,
+		BundleTimeout: flags.BundleTimeout,
+		Clone:         graph.Clone{Strategy: graph.CloneStrategy(flags.Clone), Depth: flags.Depth},
+		OnProgress:    reporter.OnProgress,
+		FromLockfile:  flags.Frozen,
+		KeepGoing:     flags.KeepGoing,
 	})
-	return err
+	if err != nil {
+		return err
+	}
+
+	// A frozen sync leaves hariti.lock untouched, so there is nothing to summarize
+	if flags.Changelog && !flags.Frozen {
+		changes, err := har.Changelog(ctx, g, hariti.ChangelogOptions{})
+		if err != nil {
+			return err
+		}
+		cli.PrintChangelog(stdout, changes)
+	}
+	return nil
 }
 
 func init() {
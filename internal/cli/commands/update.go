@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"time"
+
+	"github.com/kamichidu/go-flagshim"
+	"github.com/kamichidu/go-hariti"
+	"github.com/kamichidu/go-hariti/graph"
+	"github.com/kamichidu/go-hariti/internal/cli"
+	"github.com/kamichidu/go-hariti/internal/config/dsl"
+)
+
+//go:embed assets/update.txt
+var updateUsage string
+
+type UpdateFlags struct {
+	Parallelism   int
+	Retries       int
+	BundleTimeout time.Duration
+	Clone         string
+	Depth         int
+}
+
+type UpdateCommand struct{}
+
+func (c *UpdateCommand) Name() string {
+	return "update"
+}
+
+func (c *UpdateCommand) RegisterFlags(ctx context.Context, fs *flagshim.FlagSet) context.Context {
+	fs.Usage = func() {
+		//nolint:errcheck // safe: writing help/usage text to stderr is a presentation output; failures do not affect logic or durability
+		fmt.Fprint(fs.Output(), updateUsage)
+	}
+	if global, ok := flagshim.FlagFromContext[cli.GlobalFlags](ctx); ok {
+		global.Register(ctx, fs)
+	}
+	flags := &UpdateFlags{}
+	fs.IntVar(&flags.Parallelism, "parallelism", 0, "")
+	fs.Alias("parallelism", "p")
+	fs.IntVar(&flags.Retries, "retries", 0, "")
+	fs.DurationVar(&flags.BundleTimeout, "bundle-timeout", 0, "")
+	fs.StringVar(&flags.Clone, "clone", "", "")
+	fs.IntVar(&flags.Depth, "depth", 0, "")
+	return flagshim.ContextWithFlag(ctx, flags)
+}
+
+func (c *UpdateCommand) Run(ctx context.Context, args []string) error {
+	global := cli.GetGlobalFlags(ctx)
+	stdout := cli.GetStdout(ctx)
+	stderr := cli.GetStderr(ctx)
+	logger := cli.GetLogger(ctx)
+	flags := flagshim.MustFlagFromContext[UpdateFlags](ctx)
+
+	if len(args) == 0 {
+		return fmt.Errorf("no bundle specified: hariti update <id|alias>...")
+	}
+
+	g, err := dsl.LoadGraph(global.ConfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to parse/resolve dsl: %w", err)
+	}
+
+	cfg := &hariti.HaritiConfig{
+		Paths: hariti.Paths{
+			ConfigFile: global.ConfigFile,
+			ConfigDir:  global.ConfigDir,
+			DataDir:    global.DataDir,
+		},
+		Writer:    stdout,
+		ErrWriter: stderr,
+		Logger:    logger,
+	}
+	har := hariti.NewHariti(cfg)
+
+	reporter := cli.NewProgressReporter(stdout)
+
+	_, err = har.Sync(ctx, g, hariti.SyncOptions{
+		Parallelism:   flags.Parallelism,
+		Retries:       flags.Retries,
+		BundleTimeout: flags.BundleTimeout,
+		Clone:         graph.Clone{Strategy: graph.CloneStrategy(flags.Clone), Depth: flags.Depth},
+		OnProgress:    reporter.OnProgress,
+		Only:          args,
+	})
+	return err
+}
+
+func init() {
+	cli.Register(&UpdateCommand{})
+}
@@ -37,6 +37,19 @@ func LoggerFromContext(ctx context.Context) hariti.Logger {
 	return nil
 }
 
+type contextKeyVersion struct{}
+
+func ContextWithVersion(ctx context.Context, version string) context.Context {
+	return context.WithValue(ctx, contextKeyVersion{}, version)
+}
+
+// GetVersion returns the version of the hariti binary, or an empty string
+// when it was not set.
+func GetVersion(ctx context.Context) string {
+	version, _ := ctx.Value(contextKeyVersion{}).(string)
+	return version
+}
+
 func GetGlobalFlags(ctx context.Context) *GlobalFlags {
 	return flagshim.MustFlagFromContext[GlobalFlags](ctx)
 }
@@ -0,0 +1,9 @@
+package cli
+
+// ShortRevision abbreviates a commit revision for human-readable output.
+func ShortRevision(rev string) string {
+	if len(rev) > 12 {
+		return rev[:12]
+	}
+	return rev
+}
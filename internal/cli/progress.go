@@ -55,6 +55,12 @@ func (p *ProgressReporter) OnProgress(event hariti.SyncProgressEvent) {
 			fmt.Fprintln(p.writer, event.Output)
 		}
 
+	case hariti.SyncEventBundleRetrying:
+		status := fmt.Sprintf("%-10s", "[retry]")
+		progress := fmt.Sprintf("%-*s", totalWidth*2+3, "")
+		//nolint:errcheck // safe: writing progress state to terminal is presentation output; failures do not affect operational correctness
+		fmt.Fprintf(p.writer, "%s%s %s (attempt %d): %v\n", status, progress, event.BundleID, event.Attempt, event.Err)
+
 	case hariti.SyncEventCompleted:
 		//nolint:errcheck // safe: writing progress state to terminal is presentation output; failures do not affect operational correctness
 		fmt.Fprintf(p.writer, "Sync completed. (%d/%d)\n", event.Total, event.Total)
@@ -64,3 +70,59 @@ func (p *ProgressReporter) OnProgress(event hariti.SyncProgressEvent) {
 		fmt.Fprintf(p.writer, "Sync failed. (%d/%d)\n", event.Num, event.Total)
 	}
 }
+
+func (p *ProgressReporter) OnDeployProgress(event hariti.DeployProgressEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	totalWidth := len(fmt.Sprintf("%d", event.Total))
+	if totalWidth < 1 {
+		totalWidth = 1
+	}
+
+	switch event.Type {
+	case hariti.DeployEventStarted:
+		//nolint:errcheck // safe: writing progress state to terminal is presentation output; failures do not affect operational correctness
+		fmt.Fprintf(p.writer, "Deploying generation %s... (%d bundles, parallelism=%d)\n", event.GenerationID, event.Total, event.Parallelism)
+
+	case hariti.DeployEventBundleStarted:
+		status := fmt.Sprintf("%-10s", "[start]")
+		progress := fmt.Sprintf("%-*s", totalWidth*2+3, "")
+		//nolint:errcheck // safe: writing progress state to terminal is presentation output; failures do not affect operational correctness
+		fmt.Fprintf(p.writer, "%s%s %s\n", status, progress, event.BundleID)
+
+	case hariti.DeployEventBundlePhase:
+		status := fmt.Sprintf("%-10s", "["+string(event.Phase)+"]")
+		progress := fmt.Sprintf("%-*s", totalWidth*2+3, "")
+		//nolint:errcheck // safe: writing progress state to terminal is presentation output; failures do not affect operational correctness
+		fmt.Fprintf(p.writer, "%s%s %s\n", status, progress, event.BundleID)
+
+	case hariti.DeployEventBundleCompleted:
+		status := fmt.Sprintf("%-10s", "[done]")
+		progress := fmt.Sprintf("(%*d/%d)", totalWidth, event.Num, event.Total)
+		suffix := ""
+		if event.Reused {
+			suffix = " (reused)"
+		}
+		//nolint:errcheck // safe: writing progress state to terminal is presentation output; failures do not affect operational correctness
+		fmt.Fprintf(p.writer, "%s%s %s%s\n", status, progress, event.BundleID, suffix)
+
+	case hariti.DeployEventBundleFailed:
+		status := fmt.Sprintf("%-10s", "[fail]")
+		progress := fmt.Sprintf("(%*d/%d)", totalWidth, event.Num, event.Total)
+		//nolint:errcheck // safe: writing progress state to terminal is presentation output; failures do not affect operational correctness
+		fmt.Fprintf(p.writer, "%s%s %s\n", status, progress, event.BundleID)
+		if event.Output != "" {
+			//nolint:errcheck // safe: writing progress state to terminal is presentation output; failures do not affect operational correctness
+			fmt.Fprintln(p.writer, event.Output)
+		}
+
+	case hariti.DeployEventCompleted:
+		//nolint:errcheck // safe: writing progress state to terminal is presentation output; failures do not affect operational correctness
+		fmt.Fprintf(p.writer, "Deploy completed. (%d/%d)\n", event.Total, event.Total)
+
+	case hariti.DeployEventFailed:
+		//nolint:errcheck // safe: writing progress state to terminal is presentation output; failures do not affect operational correctness
+		fmt.Fprintf(p.writer, "Deploy failed. (%d/%d)\n", event.Num, event.Total)
+	}
+}
@@ -62,6 +62,19 @@ func TestProgressReporter_OnProgress(t *testing.T) {
 	}
 	buf.Reset()
 
+	// Test SyncEventBundleRetrying
+	reporter.OnProgress(hariti.SyncProgressEvent{
+		Type:     hariti.SyncEventBundleRetrying,
+		BundleID: "nvim-treesitter/nvim-treesitter",
+		Total:    61,
+		Attempt:  2,
+		Err:      errors.New("git fetch failed: exit status 128"),
+	})
+	if !strings.Contains(buf.String(), "[retry]") || !strings.Contains(buf.String(), "nvim-treesitter/nvim-treesitter (attempt 2): git fetch failed") {
+		t.Errorf("expected output to contain [retry], nvim-treesitter, attempt and error, got: %q", buf.String())
+	}
+	buf.Reset()
+
 	// Test SyncEventCompleted
 	reporter.OnProgress(hariti.SyncProgressEvent{
 		Type:  hariti.SyncEventCompleted,
@@ -83,3 +96,79 @@ func TestProgressReporter_OnProgress(t *testing.T) {
 		t.Errorf("expected output to contain Sync failed. (13/61), got: %q", buf.String())
 	}
 }
+
+func TestProgressReporter_OnDeployProgress(t *testing.T) {
+	var buf bytes.Buffer
+	reporter := cli.NewProgressReporter(&buf)
+
+	// Test DeployEventStarted
+	reporter.OnDeployProgress(hariti.DeployProgressEvent{
+		Type:         hariti.DeployEventStarted,
+		Total:        12,
+		Parallelism:  4,
+		GenerationID: "20260101-000000-abcdef01",
+	})
+	if !strings.Contains(buf.String(), "Deploying generation 20260101-000000-abcdef01... (12 bundles, parallelism=4)") {
+		t.Errorf("expected output to contain Deploying generation... (12 bundles, parallelism=4), got: %q", buf.String())
+	}
+	buf.Reset()
+
+	// Test DeployEventBundlePhase
+	reporter.OnDeployProgress(hariti.DeployProgressEvent{
+		Type:     hariti.DeployEventBundlePhase,
+		BundleID: "Shougo/vimproc.vim",
+		Total:    12,
+		Phase:    hariti.PhaseBuild,
+	})
+	if !strings.Contains(buf.String(), "[build]") || !strings.Contains(buf.String(), "Shougo/vimproc.vim") {
+		t.Errorf("expected output to contain [build] and Shougo/vimproc.vim, got: %q", buf.String())
+	}
+	buf.Reset()
+
+	// Test DeployEventBundleCompleted
+	reporter.OnDeployProgress(hariti.DeployProgressEvent{
+		Type:     hariti.DeployEventBundleCompleted,
+		BundleID: "tpope/vim-fugitive",
+		Num:      3,
+		Total:    12,
+		Reused:   true,
+	})
+	if !strings.Contains(buf.String(), "[done]") || !strings.Contains(buf.String(), "( 3/12)") || !strings.Contains(buf.String(), "tpope/vim-fugitive (reused)") {
+		t.Errorf("expected output to contain [done], ( 3/12), and tpope/vim-fugitive (reused), got: %q", buf.String())
+	}
+	buf.Reset()
+
+	// Test DeployEventBundleFailed
+	reporter.OnDeployProgress(hariti.DeployProgressEvent{
+		Type:     hariti.DeployEventBundleFailed,
+		BundleID: "Shougo/vimproc.vim",
+		Num:      4,
+		Total:    12,
+		Err:      errors.New("build failed"),
+		Output:   "make: *** [all] Error 1",
+	})
+	if !strings.Contains(buf.String(), "[fail]") || !strings.Contains(buf.String(), "( 4/12)") || !strings.Contains(buf.String(), "make: *** [all] Error 1") {
+		t.Errorf("expected output to contain [fail], ( 4/12), and build output, got: %q", buf.String())
+	}
+	buf.Reset()
+
+	// Test DeployEventCompleted
+	reporter.OnDeployProgress(hariti.DeployProgressEvent{
+		Type:  hariti.DeployEventCompleted,
+		Total: 12,
+	})
+	if !strings.Contains(buf.String(), "Deploy completed. (12/12)") {
+		t.Errorf("expected output to contain Deploy completed. (12/12), got: %q", buf.String())
+	}
+	buf.Reset()
+
+	// Test DeployEventFailed
+	reporter.OnDeployProgress(hariti.DeployProgressEvent{
+		Type:  hariti.DeployEventFailed,
+		Num:   4,
+		Total: 12,
+	})
+	if !strings.Contains(buf.String(), "Deploy failed. (4/12)") {
+		t.Errorf("expected output to contain Deploy failed. (4/12), got: %q", buf.String())
+	}
+}
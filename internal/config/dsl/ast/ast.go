@@ -8,12 +8,19 @@ type File struct {
 }
 
 type BundleDecl struct {
-	Use      string
-	Source   *string
-	Aliases  []string
-	Depends  []string
-	EnableIf *string
-	Build    []BuildBlock
+	Use         string
+	Source      *string
+	Aliases     []string
+	Depends     []string
+	EnableIf    *string
+	EnableIfLua *string
+	Build       []BuildBlock
+	Ref         *RefSpec
+	Clone       *CloneSpec
+	OnCmd       []string
+	OnFt        []string
+	OnMap       []string
+	OnEvent     []string
 }
 
 type BuildBlock struct {
@@ -21,6 +28,16 @@ type BuildBlock struct {
 	Commands []string
 }
 
+type RefSpec struct {
+	Kind string
+	Name string
+}
+
+type CloneSpec struct {
+	Strategy string
+	Depth    int
+}
+
 type IncludeDecl struct {
 	Path string
 }
@@ -36,9 +53,16 @@ type MergeDecl struct {
 }
 
 type BundlePatch struct {
-	Source   *string
-	Aliases  []string
-	Depends  *[]string
-	EnableIf *string
-	Build    *[]BuildBlock
+	Source      *string
+	Aliases     []string
+	Depends     *[]string
+	EnableIf    *string
+	EnableIfLua *string
+	Build       *[]BuildBlock
+	Ref         *RefSpec
+	Clone       *CloneSpec
+	OnCmd       *[]string
+	OnFt        *[]string
+	OnMap       *[]string
+	OnEvent     *[]string
 }
@@ -41,14 +41,25 @@ func ToGraph(file *ast.File) (*graph.Graph, error) {
 		if decl.EnableIf != nil {
 			enableIfVal = *decl.EnableIf
 		}
+		enableIfLuaVal := ""
+		if decl.EnableIfLua != nil {
+			enableIfLuaVal = *decl.EnableIfLua
+		}
 
 		b := graph.Bundle{
 			ID:           decl.Use,
 			Source:       src,
+			Ref:          toGraphRef(decl.Ref),
+			Clone:        toGraphClone(decl.Clone),
 			Dependencies: decl.Depends,
 			EnableIf:     enableIfVal,
+			EnableIfLua:  enableIfLuaVal,
 			Build:        buildSteps,
 			Aliases:      decl.Aliases,
+			OnCmd:        decl.OnCmd,
+			OnFt:         decl.OnFt,
+			OnMap:        decl.OnMap,
+			OnEvent:      decl.OnEvent,
 		}
 
 		bundlesMap[b.ID] = b
@@ -97,14 +108,25 @@ func ToGraph(file *ast.File) (*graph.Graph, error) {
 		if rep.Bundle.EnableIf != nil {
 			enableIfVal = *rep.Bundle.EnableIf
 		}
+		enableIfLuaVal := ""
+		if rep.Bundle.EnableIfLua != nil {
+			enableIfLuaVal = *rep.Bundle.EnableIfLua
+		}
 
 		replaced := graph.Bundle{
 			ID:           targetID, // preserve identity
 			Source:       src,
+			Ref:          toGraphRef(rep.Bundle.Ref),
+			Clone:        toGraphClone(rep.Bundle.Clone),
 			Dependencies: deps,
 			EnableIf:     enableIfVal,
+			EnableIfLua:  enableIfLuaVal,
 			Build:        buildSteps,
 			Aliases:      rep.Bundle.Aliases,
+			OnCmd:        derefStrings(rep.Bundle.OnCmd),
+			OnFt:         derefStrings(rep.Bundle.OnFt),
+			OnMap:        derefStrings(rep.Bundle.OnMap),
+			OnEvent:      derefStrings(rep.Bundle.OnEvent),
 		}
 
 		bundlesMap[targetID] = replaced
@@ -128,6 +150,14 @@ func ToGraph(file *ast.File) (*graph.Graph, error) {
 			merged.Source = src
 		}
 
+		if m.Patch.Ref != nil {
+			merged.Ref = toGraphRef(m.Patch.Ref)
+		}
+
+		if m.Patch.Clone != nil {
+			merged.Clone = toGraphClone(m.Patch.Clone)
+		}
+
 		if len(m.Patch.Aliases) > 0 {
 			merged.Aliases = append(merged.Aliases, m.Patch.Aliases...)
 		}
@@ -140,6 +170,23 @@ func ToGraph(file *ast.File) (*graph.Graph, error) {
 			merged.EnableIf = *m.Patch.EnableIf
 		}
 
+		if m.Patch.EnableIfLua != nil {
+			merged.EnableIfLua = *m.Patch.EnableIfLua
+		}
+
+		if m.Patch.OnCmd != nil {
+			merged.OnCmd = *m.Patch.OnCmd
+		}
+		if m.Patch.OnFt != nil {
+			merged.OnFt = *m.Patch.OnFt
+		}
+		if m.Patch.OnMap != nil {
+			merged.OnMap = *m.Patch.OnMap
+		}
+		if m.Patch.OnEvent != nil {
+			merged.OnEvent = *m.Patch.OnEvent
+		}
+
 		if m.Patch.Build != nil {
 			var buildSteps []graph.BuildStep
 			for _, bb := range *m.Patch.Build {
@@ -170,9 +217,44 @@ func ToGraph(file *ast.File) (*graph.Graph, error) {
 
 	g.Normalize()
 
+	if err := g.ResolveDependencies(); err != nil {
+		return nil, err
+	}
+
+	if err := g.ResolveEnableIf(); err != nil {
+		return nil, err
+	}
+
 	if err := graph.Validate(*g); err != nil {
 		return nil, err
 	}
 
 	return g, nil
 }
+
+func toGraphRef(ref *ast.RefSpec) graph.Ref {
+	if ref == nil {
+		return graph.Ref{}
+	}
+	return graph.Ref{
+		Type: graph.RefType(ref.Kind),
+		Name: ref.Name,
+	}
+}
+
+func toGraphClone(clone *ast.CloneSpec) graph.Clone {
+	if clone == nil {
+		return graph.Clone{}
+	}
+	return graph.Clone{
+		Strategy: graph.CloneStrategy(clone.Strategy),
+		Depth:    clone.Depth,
+	}
+}
+
+func derefStrings(s *[]string) []string {
+	if s == nil {
+		return nil
+	}
+	return *s
+}
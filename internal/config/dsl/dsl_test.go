@@ -2,6 +2,7 @@ package dsl_test
 
 import (
 	"encoding/json"
+	"errors"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -103,6 +104,67 @@ func TestParse_EnableIf(t *testing.T) {
 	}
 }
 
+func TestParse_EnableIfLua(t *testing.T) {
+	src := `use nvim-treesitter/nvim-treesitter
+  enable_if "has('nvim')"
+  enable_if_lua "vim.fn.has('nvim-0.9') == 1"`
+	f, err := dsl.Parse("", []byte(src))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	expected := &ast.File{
+		Bundles: []ast.BundleDecl{
+			{
+				Use:         "nvim-treesitter/nvim-treesitter",
+				EnableIf:    func() *string { s := "has('nvim')"; return &s }(),
+				EnableIfLua: func() *string { s := "vim.fn.has('nvim-0.9') == 1"; return &s }(),
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(f, expected) {
+		t.Errorf("expected %+v, got %+v", expected, f)
+	}
+}
+
+func TestParse_Triggers(t *testing.T) {
+	src := `use preservim/nerdtree
+  on_cmd (NERDTree NERDTreeToggle)
+  on_map ("<Plug>(nerdtree-open)" <Leader>n)
+
+use fatih/vim-go {
+  on_ft (go gomod)
+  on_event (
+    BufNewFile
+    InsertEnter
+  )
+}`
+	f, err := dsl.Parse("", []byte(src))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	expected := &ast.File{
+		Bundles: []ast.BundleDecl{
+			{
+				Use:   "preservim/nerdtree",
+				OnCmd: []string{"NERDTree", "NERDTreeToggle"},
+				OnMap: []string{"<Plug>(nerdtree-open)", "<Leader>n"},
+			},
+			{
+				Use:     "fatih/vim-go",
+				OnFt:    []string{"go", "gomod"},
+				OnEvent: []string{"BufNewFile", "InsertEnter"},
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(f, expected) {
+		t.Errorf("expected %+v, got %+v", expected, f)
+	}
+}
+
 func TestParse_Build(t *testing.T) {
 	src := `use Shougo/vimproc.vim
   build {
@@ -139,6 +201,139 @@ func TestParse_Build(t *testing.T) {
 	}
 }
 
+func TestParse_Ref(t *testing.T) {
+	src := `use Shougo/ddc.vim
+  branch dev
+
+use thinca/vim-quickrun
+  tag v2.1.0
+
+use Shougo/vimproc.vim
+  rev abc123`
+	f, err := dsl.Parse("", []byte(src))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	expected := &ast.File{
+		Bundles: []ast.BundleDecl{
+			{
+				Use: "Shougo/ddc.vim",
+				Ref: &ast.RefSpec{Kind: "branch", Name: "dev"},
+			},
+			{
+				Use: "thinca/vim-quickrun",
+				Ref: &ast.RefSpec{Kind: "tag", Name: "v2.1.0"},
+			},
+			{
+				Use: "Shougo/vimproc.vim",
+				Ref: &ast.RefSpec{Kind: "rev", Name: "abc123"},
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(f, expected) {
+		t.Errorf("expected %+v, got %+v", expected, f)
+	}
+}
+
+func TestParse_RefInvalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		src       string
+		expectErr string
+	}{
+		{
+			name: "tag without whitespace",
+			src: `use thinca/vim-quickrun
+  tagv2.1.0`,
+			expectErr: "no match found",
+		},
+		{
+			name: "branch without whitespace",
+			src: `use Shougo/ddc.vim {
+  branchmain
+}`,
+			expectErr: "no match found",
+		},
+		{
+			name: "rev without whitespace",
+			src: `use Shougo/vimproc.vim
+  revabc123`,
+			expectErr: "no match found",
+		},
+		{
+			name: "branch and tag",
+			src: `use Shougo/ddc.vim
+  branch main
+  tag v1.0.0`,
+			expectErr: "only one of branch, tag and rev can be set",
+		},
+		{
+			name: "tag and rev in a block",
+			src: `use Shougo/ddc.vim {
+  tag v1.0.0
+  rev abc123
+}`,
+			expectErr: "only one of branch, tag and rev can be set",
+		},
+		{
+			name: "branch and rev in a merge",
+			src: `merge Shougo/ddc.vim {
+  branch main
+  rev abc123
+}`,
+			expectErr: "only one of branch, tag and rev can be set",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := dsl.Parse("", []byte(tc.src))
+			if err == nil || !strings.Contains(err.Error(), tc.expectErr) {
+				t.Errorf("expected a parse error containing %q, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestParse_Clone(t *testing.T) {
+	src := `use nvim-treesitter/nvim-treesitter
+  clone shallow 10
+
+use Shougo/ddc.vim
+  clone blobless
+
+use thinca/vim-quickrun {
+  clone shallow
+}`
+	f, err := dsl.Parse("", []byte(src))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	expected := &ast.File{
+		Bundles: []ast.BundleDecl{
+			{
+				Use:   "nvim-treesitter/nvim-treesitter",
+				Clone: &ast.CloneSpec{Strategy: "shallow", Depth: 10},
+			},
+			{
+				Use:   "Shougo/ddc.vim",
+				Clone: &ast.CloneSpec{Strategy: "blobless"},
+			},
+			{
+				Use:   "thinca/vim-quickrun",
+				Clone: &ast.CloneSpec{Strategy: "shallow"},
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(f, expected) {
+		t.Errorf("expected %+v, got %+v", expected, f)
+	}
+}
+
 func TestParse_MultipleBundles(t *testing.T) {
 	src := `use Shougo/vimproc.vim
   as vimproc
@@ -270,7 +465,10 @@ func TestParseGraph_UseBlockForm(t *testing.T) {
 }
 
 func TestParseGraph_Replace(t *testing.T) {
-	src := `use Shougo/vimproc.vim
+	src := `use old/dep
+use foo/bar
+
+use Shougo/vimproc.vim
   as vimproc
   depends (
     old/dep
@@ -290,6 +488,8 @@ replace Shougo/vimproc.vim {
 
 	expected := &graph.Graph{
 		Bundles: []graph.Bundle{
+			remoteBundle("old/dep"),
+			remoteBundle("foo/bar"),
 			{
 				ID: "Shougo/vimproc.vim",
 				Source: graph.Source{
@@ -310,7 +510,9 @@ replace Shougo/vimproc.vim {
 }
 
 func TestParseGraph_Merge(t *testing.T) {
-	src := `use Shougo/vimproc.vim
+	src := `use old/dep
+
+use Shougo/vimproc.vim
   as vimproc
   depends (
     old/dep
@@ -327,6 +529,7 @@ merge Shougo/vimproc.vim {
 
 	expected := &graph.Graph{
 		Bundles: []graph.Bundle{
+			remoteBundle("old/dep"),
 			{
 				ID: "Shougo/vimproc.vim",
 				Source: graph.Source{
@@ -346,6 +549,118 @@ merge Shougo/vimproc.vim {
 	}
 }
 
+func TestParseGraph_MergeAndReplaceRef(t *testing.T) {
+	src := `use Shougo/ddc.vim
+  branch main
+
+use thinca/vim-quickrun
+  tag v1.0.0
+
+merge Shougo/ddc.vim {
+  tag v2.1.0
+}
+
+replace thinca/vim-quickrun {
+  as quickrun
+}`
+
+	g, err := dsl.ParseGraph("", []byte(src))
+	if err != nil {
+		t.Fatalf("ParseGraph error: %v", err)
+	}
+
+	if len(g.Bundles) != 2 {
+		t.Fatalf("expected 2 bundles, got %d", len(g.Bundles))
+	}
+
+	expectedMerged := graph.Ref{Type: graph.RefTypeTag, Name: "v2.1.0"}
+	if g.Bundles[0].Ref != expectedMerged {
+		t.Errorf("expected merged ref %+v, got %+v", expectedMerged, g.Bundles[0].Ref)
+	}
+
+	// replace discards every field that the replacement does not specify
+	if g.Bundles[1].Ref != (graph.Ref{}) {
+		t.Errorf("expected replaced ref to be cleared, got %+v", g.Bundles[1].Ref)
+	}
+}
+
+func TestParseGraph_MergeAndReplaceClone(t *testing.T) {
+	src := `use Shougo/ddc.vim
+  clone shallow
+
+use thinca/vim-quickrun
+  clone treeless
+
+merge Shougo/ddc.vim {
+  clone full
+}
+
+replace thinca/vim-quickrun {
+  as quickrun
+}`
+
+	g, err := dsl.ParseGraph("", []byte(src))
+	if err != nil {
+		t.Fatalf("ParseGraph error: %v", err)
+	}
+
+	expectedMerged := graph.Clone{Strategy: graph.CloneStrategyFull}
+	if g.Bundles[0].Clone != expectedMerged {
+		t.Errorf("expected merged clone %+v, got %+v", expectedMerged, g.Bundles[0].Clone)
+	}
+	if g.Bundles[1].Clone != (graph.Clone{}) {
+		t.Errorf("expected replaced clone to be cleared, got %+v", g.Bundles[1].Clone)
+	}
+}
+
+func TestParseGraph_MergeAndReplaceTriggers(t *testing.T) {
+	src := `use preservim/nerdtree
+  on_cmd (NERDTree)
+  on_map (<Leader>n)
+
+use fatih/vim-go
+  on_ft (go)
+
+merge preservim/nerdtree {
+  on_cmd (NERDTreeToggle)
+}
+
+replace fatih/vim-go {
+  as vim-go
+}`
+
+	g, err := dsl.ParseGraph("", []byte(src))
+	if err != nil {
+		t.Fatalf("ParseGraph error: %v", err)
+	}
+
+	// merge replaces the triggers it specifies and keeps the others
+	if !reflect.DeepEqual(g.Bundles[0].OnCmd, []string{"NERDTreeToggle"}) || !reflect.DeepEqual(g.Bundles[0].OnMap, []string{"<Leader>n"}) {
+		t.Errorf("expected merged triggers, got on_cmd %v, on_map %v", g.Bundles[0].OnCmd, g.Bundles[0].OnMap)
+	}
+	if g.Bundles[1].IsLazy() {
+		t.Errorf("expected replaced triggers to be cleared, got on_ft %v", g.Bundles[1].OnFt)
+	}
+}
+
+func TestParseGraph_InvalidTrigger(t *testing.T) {
+	src := `use preservim/nerdtree
+  on_cmd (nerdtree)`
+
+	if _, err := dsl.ParseGraph("", []byte(src)); err == nil {
+		t.Fatal("expected a validation error for a lowercase command, got nil")
+	}
+}
+
+func TestParseGraph_CloneDepthRequiresShallow(t *testing.T) {
+	src := `use Shougo/ddc.vim
+  clone blobless 10`
+
+	if _, err := dsl.ParseGraph("", []byte(src)); err == nil {
+		t.Fatal("expected a parse or validation error, got nil")
+	}
+}
+
 func TestParseGraph_MissingTarget(t *testing.T) {
 	srcReplace := `replace missing/plugin { source ./x }`
 	_, err := dsl.ParseGraph("", []byte(srcReplace))
@@ -550,3 +865,64 @@ use foo/bar {
 		t.Errorf("expected %+v, got %+v", expectedComplex, fComplex)
 	}
 }
+
+func TestParseGraph_Dependencies(t *testing.T) {
+	src := `use Shougo/vimproc.vim
+  as vimproc
+
+use thinca/vim-quickrun
+  as quickrun
+
+use osyo-manga/vim-watchdogs
+  depends (
+    quickrun
+    Shougo/vimproc.vim
+    vimproc
+  )`
+
+	g, err := dsl.ParseGraph("", []byte(src))
+	if err != nil {
+		t.Fatalf("ParseGraph error: %v", err)
+	}
+	want := []string{"thinca/vim-quickrun", "Shougo/vimproc.vim"}
+	if got := g.Bundles[2].Dependencies; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected dependencies resolved to %v, got %v", want, got)
+	}
+
+	// Unknown dependency
+	_, err = dsl.ParseGraph("", []byte(`use foo/bar
+  depends (
+    baz
+  )`))
+	var missing *graph.MissingDependencyError
+	if !errors.As(err, &missing) || missing.BundleID != "foo/bar" || missing.Dependency != "baz" {
+		t.Errorf("expected missing dependency error, got: %v", err)
+	}
+
+	// Dependency cycle through an alias
+	_, err = dsl.ParseGraph("", []byte(`use foo/a
+  as a
+  depends (
+    foo/b
+  )
+
+use foo/b
+  depends (
+    a
+  )`))
+	var cycle *graph.CycleError
+	if !errors.As(err, &cycle) || err.Error() != "dependency cycle: foo/a -> foo/b -> foo/a" {
+		t.Errorf("expected dependency cycle error, got: %v", err)
+	}
+}
+
+func remoteBundle(id string) graph.Bundle {
+	src, _ := dsl.ResolveSource(id)
+	return graph.Bundle{
+		ID:           id,
+		Source:       src,
+		Dependencies: []string{},
+		Build:        []graph.BuildStep{},
+		Aliases:      []string{},
+	}
+}
@@ -23,11 +23,40 @@ type enableIfOpt struct {
 	expr string
 }
 
+type enableIfLuaOpt struct {
+	expr string
+}
+
 type sourceOpt struct {
 	path string
 }
 
-func buildBundleDecl(name string, blockOpts []interface{}, inlineOpts []interface{}) ast.BundleDecl {
+type refOpt struct {
+	kind string
+	name string
+}
+
+type cloneOpt struct {
+	strategy string
+	depth    int
+}
+
+type triggerOpt struct {
+	kind  string
+	names []string
+}
+
+// setRef sets the ref of a bundle, which only one of branch, tag and rev can
+// select.
+func setRef(ref **ast.RefSpec, opt refOpt) error {
+	if *ref != nil {
+		return fmt.Errorf("only one of branch, tag and rev can be set, got %s %s and %s %s", (*ref).Kind, (*ref).Name, opt.kind, opt.name)
+	}
+	*ref = &ast.RefSpec{Kind: opt.kind, Name: opt.name}
+	return nil
+}
+
+func buildBundleDecl(name string, blockOpts []interface{}, inlineOpts []interface{}) (ast.BundleDecl, error) {
 	decl := ast.BundleDecl{
 		Use: name,
 	}
@@ -50,14 +79,33 @@ func buildBundleDecl(name string, blockOpts []interface{}, inlineOpts []interfac
 			decl.Depends = append(decl.Depends, v...)
 		case enableIfOpt:
 			decl.EnableIf = &v.expr
+		case enableIfLuaOpt:
+			decl.EnableIfLua = &v.expr
+		case refOpt:
+			if err := setRef(&decl.Ref, v); err != nil {
+				return decl, err
+			}
+		case cloneOpt:
+			decl.Clone = &ast.CloneSpec{Strategy: v.strategy, Depth: v.depth}
 		case []ast.BuildBlock:
 			decl.Build = append(decl.Build, v...)
+		case triggerOpt:
+			switch v.kind {
+			case "on_cmd":
+				decl.OnCmd = append(decl.OnCmd, v.names...)
+			case "on_ft":
+				decl.OnFt = append(decl.OnFt, v.names...)
+			case "on_map":
+				decl.OnMap = append(decl.OnMap, v.names...)
+			case "on_event":
+				decl.OnEvent = append(decl.OnEvent, v.names...)
+			}
 		}
 	}
-	return decl
+	return decl, nil
 }
 
-func buildPatch(opts []interface{}) ast.BundlePatch {
+func buildPatch(opts []interface{}) (ast.BundlePatch, error) {
 	var patch ast.BundlePatch
 	for _, opt := range opts {
 		switch v := opt.(type) {
@@ -69,49 +117,68 @@ func buildPatch(opts []interface{}) ast.BundlePatch {
 			patch.Depends = &v
 		case enableIfOpt:
 			patch.EnableIf = &v.expr
+		case enableIfLuaOpt:
+			patch.EnableIfLua = &v.expr
+		case refOpt:
+			if err := setRef(&patch.Ref, v); err != nil {
+				return patch, err
+			}
+		case cloneOpt:
+			patch.Clone = &ast.CloneSpec{Strategy: v.strategy, Depth: v.depth}
 		case []ast.BuildBlock:
 			patch.Build = &v
+		case triggerOpt:
+			switch v.kind {
+			case "on_cmd":
+				patch.OnCmd = &v.names
+			case "on_ft":
+				patch.OnFt = &v.names
+			case "on_map":
+				patch.OnMap = &v.names
+			case "on_event":
+				patch.OnEvent = &v.names
+			}
 		}
 	}
-	return patch
+	return patch, nil
 }
 
 var g = &grammar{
 	rules: []*rule{
 		{
 			name: "File",
-			pos:  position{line: 67, col: 1, offset: 1244},
+			pos:  position{line: 136, col: 1, offset: 2857},
 			expr: &actionExpr{
-				pos: position{line: 67, col: 8, offset: 1251},
+				pos: position{line: 136, col: 8, offset: 2864},
 				run: (*parser).callonFile1,
 				expr: &seqExpr{
-					pos: position{line: 67, col: 8, offset: 1251},
+					pos: position{line: 136, col: 8, offset: 2864},
 					exprs: []any{
 						&ruleRefExpr{
-							pos:  position{line: 67, col: 8, offset: 1251},
+							pos:  position{line: 136, col: 8, offset: 2864},
 							name: "__",
 						},
 						&labeledExpr{
-							pos:   position{line: 67, col: 11, offset: 1254},
+							pos:   position{line: 136, col: 11, offset: 2867},
 							label: "list",
 							expr: &zeroOrMoreExpr{
-								pos: position{line: 67, col: 16, offset: 1259},
+								pos: position{line: 136, col: 16, offset: 2872},
 								expr: &actionExpr{
-									pos: position{line: 67, col: 17, offset: 1260},
+									pos: position{line: 136, col: 17, offset: 2873},
 									run: (*parser).callonFile6,
 									expr: &seqExpr{
-										pos: position{line: 67, col: 17, offset: 1260},
+										pos: position{line: 136, col: 17, offset: 2873},
 										exprs: []any{
 											&labeledExpr{
-												pos:   position{line: 67, col: 17, offset: 1260},
+												pos:   position{line: 136, col: 17, offset: 2873},
 												label: "decl",
 												expr: &ruleRefExpr{
-													pos:  position{line: 67, col: 22, offset: 1265},
+													pos:  position{line: 136, col: 22, offset: 2878},
 													name: "Decl",
 												},
 											},
 											&ruleRefExpr{
-												pos:  position{line: 67, col: 27, offset: 1270},
+												pos:  position{line: 136, col: 27, offset: 2883},
 												name: "__",
 											},
 										},
@@ -120,7 +187,7 @@ var g = &grammar{
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 67, col: 53, offset: 1296},
+							pos:  position{line: 136, col: 53, offset: 2909},
 							name: "EOF",
 						},
 					},
@@ -129,24 +196,24 @@ var g = &grammar{
 		},
 		{
 			name: "Decl",
-			pos:  position{line: 89, col: 1, offset: 1860},
+			pos:  position{line: 158, col: 1, offset: 3473},
 			expr: &choiceExpr{
-				pos: position{line: 89, col: 8, offset: 1867},
+				pos: position{line: 158, col: 8, offset: 3480},
 				alternatives: []any{
 					&ruleRefExpr{
-						pos:  position{line: 89, col: 8, offset: 1867},
+						pos:  position{line: 158, col: 8, offset: 3480},
 						name: "BundleDecl",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 89, col: 21, offset: 1880},
+						pos:  position{line: 158, col: 21, offset: 3493},
 						name: "ReplaceDecl",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 89, col: 35, offset: 1894},
+						pos:  position{line: 158, col: 35, offset: 3507},
 						name: "MergeDecl",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 89, col: 47, offset: 1906},
+						pos:  position{line: 158, col: 47, offset: 3519},
 						name: "IncludeDecl",
 					},
 				},
@@ -154,75 +221,75 @@ var g = &grammar{
 		},
 		{
 			name: "BundleDecl",
-			pos:  position{line: 91, col: 1, offset: 1919},
+			pos:  position{line: 160, col: 1, offset: 3532},
 			expr: &actionExpr{
-				pos: position{line: 91, col: 14, offset: 1932},
+				pos: position{line: 160, col: 14, offset: 3545},
 				run: (*parser).callonBundleDecl1,
 				expr: &seqExpr{
-					pos: position{line: 91, col: 14, offset: 1932},
+					pos: position{line: 160, col: 14, offset: 3545},
 					exprs: []any{
 						&ruleRefExpr{
-							pos:  position{line: 91, col: 14, offset: 1932},
+							pos:  position{line: 160, col: 14, offset: 3545},
 							name: "_",
 						},
 						&litMatcher{
-							pos:        position{line: 91, col: 16, offset: 1934},
+							pos:        position{line: 160, col: 16, offset: 3547},
 							val:        "use",
 							ignoreCase: false,
 							want:       "\"use\"",
 						},
 						&ruleRefExpr{
-							pos:  position{line: 91, col: 22, offset: 1940},
+							pos:  position{line: 160, col: 22, offset: 3553},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 91, col: 24, offset: 1942},
+							pos:   position{line: 160, col: 24, offset: 3555},
 							label: "name",
 							expr: &ruleRefExpr{
-								pos:  position{line: 91, col: 29, offset: 1947},
+								pos:  position{line: 160, col: 29, offset: 3560},
 								name: "BundleName",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 91, col: 40, offset: 1958},
+							pos:  position{line: 160, col: 40, offset: 3571},
 							name: "__",
 						},
 						&labeledExpr{
-							pos:   position{line: 91, col: 43, offset: 1961},
+							pos:   position{line: 160, col: 43, offset: 3574},
 							label: "block",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 91, col: 49, offset: 1967},
+								pos: position{line: 160, col: 49, offset: 3580},
 								expr: &ruleRefExpr{
-									pos:  position{line: 91, col: 49, offset: 1967},
+									pos:  position{line: 160, col: 49, offset: 3580},
 									name: "BlockOptions",
 								},
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 91, col: 63, offset: 1981},
+							pos:  position{line: 160, col: 63, offset: 3594},
 							name: "__",
 						},
 						&labeledExpr{
-							pos:   position{line: 91, col: 66, offset: 1984},
+							pos:   position{line: 160, col: 66, offset: 3597},
 							label: "opts",
 							expr: &zeroOrMoreExpr{
-								pos: position{line: 91, col: 71, offset: 1989},
+								pos: position{line: 160, col: 71, offset: 3602},
 								expr: &actionExpr{
-									pos: position{line: 91, col: 72, offset: 1990},
+									pos: position{line: 160, col: 72, offset: 3603},
 									run: (*parser).callonBundleDecl15,
 									expr: &seqExpr{
-										pos: position{line: 91, col: 72, offset: 1990},
+										pos: position{line: 160, col: 72, offset: 3603},
 										exprs: []any{
 											&labeledExpr{
-												pos:   position{line: 91, col: 72, offset: 1990},
+												pos:   position{line: 160, col: 72, offset: 3603},
 												label: "opt",
 												expr: &ruleRefExpr{
-													pos:  position{line: 91, col: 76, offset: 1994},
+													pos:  position{line: 160, col: 76, offset: 3607},
 													name: "BundleOptions",
 												},
 											},
 											&ruleRefExpr{
-												pos:  position{line: 91, col: 90, offset: 2008},
+												pos:  position{line: 160, col: 90, offset: 3621},
 												name: "__",
 											},
 										},
@@ -236,44 +303,44 @@ var g = &grammar{
 		},
 		{
 			name: "BlockOptions",
-			pos:  position{line: 103, col: 1, offset: 2279},
+			pos:  position{line: 172, col: 1, offset: 3887},
 			expr: &actionExpr{
-				pos: position{line: 103, col: 16, offset: 2294},
+				pos: position{line: 172, col: 16, offset: 3902},
 				run: (*parser).callonBlockOptions1,
 				expr: &seqExpr{
-					pos: position{line: 103, col: 16, offset: 2294},
+					pos: position{line: 172, col: 16, offset: 3902},
 					exprs: []any{
 						&litMatcher{
-							pos:        position{line: 103, col: 16, offset: 2294},
+							pos:        position{line: 172, col: 16, offset: 3902},
 							val:        "{",
 							ignoreCase: false,
 							want:       "\"{\"",
 						},
 						&ruleRefExpr{
-							pos:  position{line: 103, col: 20, offset: 2298},
+							pos:  position{line: 172, col: 20, offset: 3906},
 							name: "__",
 						},
 						&labeledExpr{
-							pos:   position{line: 103, col: 23, offset: 2301},
+							pos:   position{line: 172, col: 23, offset: 3909},
 							label: "opts",
 							expr: &zeroOrMoreExpr{
-								pos: position{line: 103, col: 28, offset: 2306},
+								pos: position{line: 172, col: 28, offset: 3914},
 								expr: &actionExpr{
-									pos: position{line: 103, col: 29, offset: 2307},
+									pos: position{line: 172, col: 29, offset: 3915},
 									run: (*parser).callonBlockOptions7,
 									expr: &seqExpr{
-										pos: position{line: 103, col: 29, offset: 2307},
+										pos: position{line: 172, col: 29, offset: 3915},
 										exprs: []any{
 											&labeledExpr{
-												pos:   position{line: 103, col: 29, offset: 2307},
+												pos:   position{line: 172, col: 29, offset: 3915},
 												label: "opt",
 												expr: &ruleRefExpr{
-													pos:  position{line: 103, col: 33, offset: 2311},
+													pos:  position{line: 172, col: 33, offset: 3919},
 													name: "BlockOption",
 												},
 											},
 											&ruleRefExpr{
-												pos:  position{line: 103, col: 45, offset: 2323},
+												pos:  position{line: 172, col: 45, offset: 3931},
 												name: "__",
 											},
 										},
@@ -282,7 +349,7 @@ var g = &grammar{
 							},
 						},
 						&litMatcher{
-							pos:        position{line: 103, col: 70, offset: 2348},
+							pos:        position{line: 172, col: 70, offset: 3956},
 							val:        "}",
 							ignoreCase: false,
 							want:       "\"}\"",
@@ -293,69 +360,93 @@ var g = &grammar{
 		},
 		{
 			name: "BlockOption",
-			pos:  position{line: 107, col: 1, offset: 2375},
+			pos:  position{line: 176, col: 1, offset: 3983},
 			expr: &choiceExpr{
-				pos: position{line: 107, col: 15, offset: 2389},
+				pos: position{line: 176, col: 15, offset: 3997},
 				alternatives: []any{
 					&ruleRefExpr{
-						pos:  position{line: 107, col: 15, offset: 2389},
+						pos:  position{line: 176, col: 15, offset: 3997},
 						name: "SourceOption",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 107, col: 30, offset: 2404},
+						pos:  position{line: 176, col: 30, offset: 4012},
 						name: "AsOption",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 107, col: 41, offset: 2415},
+						pos:  position{line: 176, col: 41, offset: 4023},
 						name: "DependsOption",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 107, col: 57, offset: 2431},
+						pos:  position{line: 176, col: 57, offset: 4039},
+						name: "EnableIfLuaOption",
+					},
+					&ruleRefExpr{
+						pos:  position{line: 176, col: 77, offset: 4059},
 						name: "EnableIfOption",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 107, col: 74, offset: 2448},
+						pos:  position{line: 176, col: 94, offset: 4076},
 						name: "BuildOption",
 					},
+					&ruleRefExpr{
+						pos:  position{line: 176, col: 108, offset: 4090},
+						name: "BranchOption",
+					},
+					&ruleRefExpr{
+						pos:  position{line: 176, col: 123, offset: 4105},
+						name: "TagOption",
+					},
+					&ruleRefExpr{
+						pos:  position{line: 176, col: 135, offset: 4117},
+						name: "RevOption",
+					},
+					&ruleRefExpr{
+						pos:  position{line: 176, col: 147, offset: 4129},
+						name: "CloneOption",
+					},
+					&ruleRefExpr{
+						pos:  position{line: 176, col: 161, offset: 4143},
+						name: "TriggerOption",
+					},
 				},
 			},
 		},
 		{
 			name: "BundleOptions",
-			pos:  position{line: 109, col: 1, offset: 2461},
+			pos:  position{line: 178, col: 1, offset: 4158},
 			expr: &ruleRefExpr{
-				pos:  position{line: 109, col: 17, offset: 2477},
+				pos:  position{line: 178, col: 17, offset: 4174},
 				name: "BlockOption",
 			},
 		},
 		{
 			name: "SourceOption",
-			pos:  position{line: 111, col: 1, offset: 2490},
+			pos:  position{line: 180, col: 1, offset: 4187},
 			expr: &actionExpr{
-				pos: position{line: 111, col: 16, offset: 2505},
+				pos: position{line: 180, col: 16, offset: 4202},
 				run: (*parser).callonSourceOption1,
 				expr: &seqExpr{
-					pos: position{line: 111, col: 16, offset: 2505},
+					pos: position{line: 180, col: 16, offset: 4202},
 					exprs: []any{
 						&ruleRefExpr{
-							pos:  position{line: 111, col: 16, offset: 2505},
+							pos:  position{line: 180, col: 16, offset: 4202},
 							name: "_",
 						},
 						&litMatcher{
-							pos:        position{line: 111, col: 18, offset: 2507},
+							pos:        position{line: 180, col: 18, offset: 4204},
 							val:        "source",
 							ignoreCase: false,
 							want:       "\"source\"",
 						},
 						&ruleRefExpr{
-							pos:  position{line: 111, col: 27, offset: 2516},
+							pos:  position{line: 180, col: 27, offset: 4213},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 111, col: 29, offset: 2518},
+							pos:   position{line: 180, col: 29, offset: 4215},
 							label: "path",
 							expr: &ruleRefExpr{
-								pos:  position{line: 111, col: 34, offset: 2523},
+								pos:  position{line: 180, col: 34, offset: 4220},
 								name: "IncludePath",
 							},
 						},
@@ -365,32 +456,32 @@ var g = &grammar{
 		},
 		{
 			name: "AsOption",
-			pos:  position{line: 115, col: 1, offset: 2584},
+			pos:  position{line: 184, col: 1, offset: 4281},
 			expr: &actionExpr{
-				pos: position{line: 115, col: 12, offset: 2595},
+				pos: position{line: 184, col: 12, offset: 4292},
 				run: (*parser).callonAsOption1,
 				expr: &seqExpr{
-					pos: position{line: 115, col: 12, offset: 2595},
+					pos: position{line: 184, col: 12, offset: 4292},
 					exprs: []any{
 						&ruleRefExpr{
-							pos:  position{line: 115, col: 12, offset: 2595},
+							pos:  position{line: 184, col: 12, offset: 4292},
 							name: "_",
 						},
 						&litMatcher{
-							pos:        position{line: 115, col: 14, offset: 2597},
+							pos:        position{line: 184, col: 14, offset: 4294},
 							val:        "as",
 							ignoreCase: false,
 							want:       "\"as\"",
 						},
 						&ruleRefExpr{
-							pos:  position{line: 115, col: 19, offset: 2602},
+							pos:  position{line: 184, col: 19, offset: 4299},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 115, col: 21, offset: 2604},
+							pos:   position{line: 184, col: 21, offset: 4301},
 							label: "alias",
 							expr: &ruleRefExpr{
-								pos:  position{line: 115, col: 27, offset: 2610},
+								pos:  position{line: 184, col: 27, offset: 4307},
 								name: "BundleName",
 							},
 						},
@@ -400,51 +491,51 @@ var g = &grammar{
 		},
 		{
 			name: "DependsOption",
-			pos:  position{line: 119, col: 1, offset: 2654},
+			pos:  position{line: 188, col: 1, offset: 4351},
 			expr: &actionExpr{
-				pos: position{line: 119, col: 17, offset: 2670},
+				pos: position{line: 188, col: 17, offset: 4367},
 				run: (*parser).callonDependsOption1,
 				expr: &seqExpr{
-					pos: position{line: 119, col: 17, offset: 2670},
+					pos: position{line: 188, col: 17, offset: 4367},
 					exprs: []any{
 						&ruleRefExpr{
-							pos:  position{line: 119, col: 17, offset: 2670},
+							pos:  position{line: 188, col: 17, offset: 4367},
 							name: "_",
 						},
 						&litMatcher{
-							pos:        position{line: 119, col: 19, offset: 2672},
+							pos:        position{line: 188, col: 19, offset: 4369},
 							val:        "depends",
 							ignoreCase: false,
 							want:       "\"depends\"",
 						},
 						&ruleRefExpr{
-							pos:  position{line: 119, col: 29, offset: 2682},
+							pos:  position{line: 188, col: 29, offset: 4379},
 							name: "__",
 						},
 						&litMatcher{
-							pos:        position{line: 119, col: 32, offset: 2685},
+							pos:        position{line: 188, col: 32, offset: 4382},
 							val:        "(",
 							ignoreCase: false,
 							want:       "\"(\"",
 						},
 						&ruleRefExpr{
-							pos:  position{line: 119, col: 36, offset: 2689},
+							pos:  position{line: 188, col: 36, offset: 4386},
 							name: "__",
 						},
 						&labeledExpr{
-							pos:   position{line: 119, col: 39, offset: 2692},
+							pos:   position{line: 188, col: 39, offset: 4389},
 							label: "names",
 							expr: &ruleRefExpr{
-								pos:  position{line: 119, col: 45, offset: 2698},
+								pos:  position{line: 188, col: 45, offset: 4395},
 								name: "DependsList",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 119, col: 57, offset: 2710},
+							pos:  position{line: 188, col: 57, offset: 4407},
 							name: "__",
 						},
 						&litMatcher{
-							pos:        position{line: 119, col: 60, offset: 2713},
+							pos:        position{line: 188, col: 60, offset: 4410},
 							val:        ")",
 							ignoreCase: false,
 							want:       "\")\"",
@@ -455,31 +546,31 @@ var g = &grammar{
 		},
 		{
 			name: "DependsList",
-			pos:  position{line: 123, col: 1, offset: 2741},
+			pos:  position{line: 192, col: 1, offset: 4438},
 			expr: &actionExpr{
-				pos: position{line: 123, col: 15, offset: 2755},
+				pos: position{line: 192, col: 15, offset: 4452},
 				run: (*parser).callonDependsList1,
 				expr: &labeledExpr{
-					pos:   position{line: 123, col: 15, offset: 2755},
+					pos:   position{line: 192, col: 15, offset: 4452},
 					label: "list",
 					expr: &zeroOrMoreExpr{
-						pos: position{line: 123, col: 20, offset: 2760},
+						pos: position{line: 192, col: 20, offset: 4457},
 						expr: &actionExpr{
-							pos: position{line: 123, col: 21, offset: 2761},
+							pos: position{line: 192, col: 21, offset: 4458},
 							run: (*parser).callonDependsList4,
 							expr: &seqExpr{
-								pos: position{line: 123, col: 21, offset: 2761},
+								pos: position{line: 192, col: 21, offset: 4458},
 								exprs: []any{
 									&labeledExpr{
-										pos:   position{line: 123, col: 21, offset: 2761},
+										pos:   position{line: 192, col: 21, offset: 4458},
 										label: "name",
 										expr: &ruleRefExpr{
-											pos:  position{line: 123, col: 26, offset: 2766},
+											pos:  position{line: 192, col: 26, offset: 4463},
 											name: "BundleName",
 										},
 									},
 									&ruleRefExpr{
-										pos:  position{line: 123, col: 37, offset: 2777},
+										pos:  position{line: 192, col: 37, offset: 4474},
 										name: "__",
 									},
 								},
@@ -491,32 +582,32 @@ var g = &grammar{
 		},
 		{
 			name: "EnableIfOption",
-			pos:  position{line: 133, col: 1, offset: 2958},
+			pos:  position{line: 202, col: 1, offset: 4655},
 			expr: &actionExpr{
-				pos: position{line: 133, col: 18, offset: 2975},
+				pos: position{line: 202, col: 18, offset: 4672},
 				run: (*parser).callonEnableIfOption1,
 				expr: &seqExpr{
-					pos: position{line: 133, col: 18, offset: 2975},
+					pos: position{line: 202, col: 18, offset: 4672},
 					exprs: []any{
 						&ruleRefExpr{
-							pos:  position{line: 133, col: 18, offset: 2975},
+							pos:  position{line: 202, col: 18, offset: 4672},
 							name: "_",
 						},
 						&litMatcher{
-							pos:        position{line: 133, col: 20, offset: 2977},
+							pos:        position{line: 202, col: 20, offset: 4674},
 							val:        "enable_if",
 							ignoreCase: false,
 							want:       "\"enable_if\"",
 						},
 						&ruleRefExpr{
-							pos:  position{line: 133, col: 32, offset: 2989},
+							pos:  position{line: 202, col: 32, offset: 4686},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 133, col: 34, offset: 2991},
+							pos:   position{line: 202, col: 34, offset: 4688},
 							label: "expr",
 							expr: &ruleRefExpr{
-								pos:  position{line: 133, col: 39, offset: 2996},
+								pos:  position{line: 202, col: 39, offset: 4693},
 								name: "StringLiteral",
 							},
 						},
@@ -524,53 +615,503 @@ var g = &grammar{
 				},
 			},
 		},
+		{
+			name: "TriggerOption",
+			pos:  position{line: 206, col: 1, offset: 4758},
+			expr: &actionExpr{
+				pos: position{line: 206, col: 17, offset: 4774},
+				run: (*parser).callonTriggerOption1,
+				expr: &seqExpr{
+					pos: position{line: 206, col: 17, offset: 4774},
+					exprs: []any{
+						&ruleRefExpr{
+							pos:  position{line: 206, col: 17, offset: 4774},
+							name: "_",
+						},
+						&labeledExpr{
+							pos:   position{line: 206, col: 19, offset: 4776},
+							label: "kind",
+							expr: &ruleRefExpr{
+								pos:  position{line: 206, col: 24, offset: 4781},
+								name: "TriggerKind",
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 206, col: 36, offset: 4793},
+							name: "__",
+						},
+						&litMatcher{
+							pos:        position{line: 206, col: 39, offset: 4796},
+							val:        "(",
+							ignoreCase: false,
+							want:       "\"(\"",
+						},
+						&ruleRefExpr{
+							pos:  position{line: 206, col: 43, offset: 4800},
+							name: "__",
+						},
+						&labeledExpr{
+							pos:   position{line: 206, col: 46, offset: 4803},
+							label: "names",
+							expr: &ruleRefExpr{
+								pos:  position{line: 206, col: 52, offset: 4809},
+								name: "TriggerList",
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 206, col: 64, offset: 4821},
+							name: "__",
+						},
+						&litMatcher{
+							pos:        position{line: 206, col: 67, offset: 4824},
+							val:        ")",
+							ignoreCase: false,
+							want:       "\")\"",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "TriggerKind",
+			pos:  position{line: 210, col: 1, offset: 4903},
+			expr: &actionExpr{
+				pos: position{line: 210, col: 15, offset: 4917},
+				run: (*parser).callonTriggerKind1,
+				expr: &choiceExpr{
+					pos: position{line: 210, col: 16, offset: 4918},
+					alternatives: []any{
+						&litMatcher{
+							pos:        position{line: 210, col: 16, offset: 4918},
+							val:        "on_cmd",
+							ignoreCase: false,
+							want:       "\"on_cmd\"",
+						},
+						&litMatcher{
+							pos:        position{line: 210, col: 27, offset: 4929},
+							val:        "on_ft",
+							ignoreCase: false,
+							want:       "\"on_ft\"",
+						},
+						&litMatcher{
+							pos:        position{line: 210, col: 37, offset: 4939},
+							val:        "on_map",
+							ignoreCase: false,
+							want:       "\"on_map\"",
+						},
+						&litMatcher{
+							pos:        position{line: 210, col: 48, offset: 4950},
+							val:        "on_event",
+							ignoreCase: false,
+							want:       "\"on_event\"",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "TriggerList",
+			pos:  position{line: 214, col: 1, offset: 4995},
+			expr: &actionExpr{
+				pos: position{line: 214, col: 15, offset: 5009},
+				run: (*parser).callonTriggerList1,
+				expr: &labeledExpr{
+					pos:   position{line: 214, col: 15, offset: 5009},
+					label: "list",
+					expr: &zeroOrMoreExpr{
+						pos: position{line: 214, col: 20, offset: 5014},
+						expr: &actionExpr{
+							pos: position{line: 214, col: 21, offset: 5015},
+							run: (*parser).callonTriggerList4,
+							expr: &seqExpr{
+								pos: position{line: 214, col: 21, offset: 5015},
+								exprs: []any{
+									&labeledExpr{
+										pos:   position{line: 214, col: 21, offset: 5015},
+										label: "name",
+										expr: &ruleRefExpr{
+											pos:  position{line: 214, col: 26, offset: 5020},
+											name: "TriggerName",
+										},
+									},
+									&ruleRefExpr{
+										pos:  position{line: 214, col: 38, offset: 5032},
+										name: "__",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "TriggerName",
+			pos:  position{line: 224, col: 1, offset: 5213},
+			expr: &choiceExpr{
+				pos: position{line: 224, col: 15, offset: 5227},
+				alternatives: []any{
+					&ruleRefExpr{
+						pos:  position{line: 224, col: 15, offset: 5227},
+						name: "StringLiteral",
+					},
+					&ruleRefExpr{
+						pos:  position{line: 224, col: 31, offset: 5243},
+						name: "UnquotedTriggerName",
+					},
+				},
+			},
+		},
+		{
+			name: "UnquotedTriggerName",
+			pos:  position{line: 226, col: 1, offset: 5264},
+			expr: &actionExpr{
+				pos: position{line: 226, col: 23, offset: 5286},
+				run: (*parser).callonUnquotedTriggerName1,
+				expr: &labeledExpr{
+					pos:   position{line: 226, col: 23, offset: 5286},
+					label: "chars",
+					expr: &oneOrMoreExpr{
+						pos: position{line: 226, col: 29, offset: 5292},
+						expr: &charClassMatcher{
+							pos:        position{line: 226, col: 29, offset: 5292},
+							val:        "[^ \\t\\r\\n()\"'#]",
+							chars:      []rune{' ', '\t', '\r', '\n', '(', ')', '"', '\'', '#'},
+							ignoreCase: false,
+							inverted:   true,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "EnableIfLuaOption",
+			pos:  position{line: 230, col: 1, offset: 5342},
+			expr: &actionExpr{
+				pos: position{line: 230, col: 21, offset: 5362},
+				run: (*parser).callonEnableIfLuaOption1,
+				expr: &seqExpr{
+					pos: position{line: 230, col: 21, offset: 5362},
+					exprs: []any{
+						&ruleRefExpr{
+							pos:  position{line: 230, col: 21, offset: 5362},
+							name: "_",
+						},
+						&litMatcher{
+							pos:        position{line: 230, col: 23, offset: 5364},
+							val:        "enable_if_lua",
+							ignoreCase: false,
+							want:       "\"enable_if_lua\"",
+						},
+						&ruleRefExpr{
+							pos:  position{line: 230, col: 39, offset: 5380},
+							name: "_",
+						},
+						&labeledExpr{
+							pos:   position{line: 230, col: 41, offset: 5382},
+							label: "expr",
+							expr: &ruleRefExpr{
+								pos:  position{line: 230, col: 46, offset: 5387},
+								name: "StringLiteral",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "BranchOption",
+			pos:  position{line: 234, col: 1, offset: 5455},
+			expr: &actionExpr{
+				pos: position{line: 234, col: 16, offset: 5470},
+				run: (*parser).callonBranchOption1,
+				expr: &seqExpr{
+					pos: position{line: 234, col: 16, offset: 5470},
+					exprs: []any{
+						&ruleRefExpr{
+							pos:  position{line: 234, col: 16, offset: 5470},
+							name: "_",
+						},
+						&litMatcher{
+							pos:        position{line: 234, col: 18, offset: 5472},
+							val:        "branch",
+							ignoreCase: false,
+							want:       "\"branch\"",
+						},
+						&ruleRefExpr{
+							pos:  position{line: 234, col: 27, offset: 5481},
+							name: "WS",
+						},
+						&labeledExpr{
+							pos:   position{line: 234, col: 30, offset: 5484},
+							label: "name",
+							expr: &ruleRefExpr{
+								pos:  position{line: 234, col: 35, offset: 5489},
+								name: "RefName",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "TagOption",
+			pos:  position{line: 238, col: 1, offset: 5559},
+			expr: &actionExpr{
+				pos: position{line: 238, col: 13, offset: 5571},
+				run: (*parser).callonTagOption1,
+				expr: &seqExpr{
+					pos: position{line: 238, col: 13, offset: 5571},
+					exprs: []any{
+						&ruleRefExpr{
+							pos:  position{line: 238, col: 13, offset: 5571},
+							name: "_",
+						},
+						&litMatcher{
+							pos:        position{line: 238, col: 15, offset: 5573},
+							val:        "tag",
+							ignoreCase: false,
+							want:       "\"tag\"",
+						},
+						&ruleRefExpr{
+							pos:  position{line: 238, col: 21, offset: 5579},
+							name: "WS",
+						},
+						&labeledExpr{
+							pos:   position{line: 238, col: 24, offset: 5582},
+							label: "name",
+							expr: &ruleRefExpr{
+								pos:  position{line: 238, col: 29, offset: 5587},
+								name: "RefName",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "RevOption",
+			pos:  position{line: 242, col: 1, offset: 5654},
+			expr: &actionExpr{
+				pos: position{line: 242, col: 13, offset: 5666},
+				run: (*parser).callonRevOption1,
+				expr: &seqExpr{
+					pos: position{line: 242, col: 13, offset: 5666},
+					exprs: []any{
+						&ruleRefExpr{
+							pos:  position{line: 242, col: 13, offset: 5666},
+							name: "_",
+						},
+						&litMatcher{
+							pos:        position{line: 242, col: 15, offset: 5668},
+							val:        "rev",
+							ignoreCase: false,
+							want:       "\"rev\"",
+						},
+						&ruleRefExpr{
+							pos:  position{line: 242, col: 21, offset: 5674},
+							name: "WS",
+						},
+						&labeledExpr{
+							pos:   position{line: 242, col: 24, offset: 5677},
+							label: "name",
+							expr: &ruleRefExpr{
+								pos:  position{line: 242, col: 29, offset: 5682},
+								name: "RefName",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "RefName",
+			pos:  position{line: 246, col: 1, offset: 5749},
+			expr: &actionExpr{
+				pos: position{line: 246, col: 11, offset: 5759},
+				run: (*parser).callonRefName1,
+				expr: &labeledExpr{
+					pos:   position{line: 246, col: 11, offset: 5759},
+					label: "chars",
+					expr: &oneOrMoreExpr{
+						pos: position{line: 246, col: 17, offset: 5765},
+						expr: &charClassMatcher{
+							pos:        position{line: 246, col: 17, offset: 5765},
+							val:        "[a-zA-Z0-9_./@+-]",
+							chars:      []rune{'_', '.', '/', '@', '+', '-'},
+							ranges:     []rune{'a', 'z', 'A', 'Z', '0', '9'},
+							ignoreCase: false,
+							inverted:   false,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "CloneOption",
+			pos:  position{line: 250, col: 1, offset: 5817},
+			expr: &actionExpr{
+				pos: position{line: 250, col: 15, offset: 5831},
+				run: (*parser).callonCloneOption1,
+				expr: &seqExpr{
+					pos: position{line: 250, col: 15, offset: 5831},
+					exprs: []any{
+						&ruleRefExpr{
+							pos:  position{line: 250, col: 15, offset: 5831},
+							name: "_",
+						},
+						&litMatcher{
+							pos:        position{line: 250, col: 17, offset: 5833},
+							val:        "clone",
+							ignoreCase: false,
+							want:       "\"clone\"",
+						},
+						&ruleRefExpr{
+							pos:  position{line: 250, col: 25, offset: 5841},
+							name: "_",
+						},
+						&labeledExpr{
+							pos:   position{line: 250, col: 27, offset: 5843},
+							label: "strategy",
+							expr: &ruleRefExpr{
+								pos:  position{line: 250, col: 36, offset: 5852},
+								name: "CloneStrategy",
+							},
+						},
+						&labeledExpr{
+							pos:   position{line: 250, col: 50, offset: 5866},
+							label: "depth",
+							expr: &zeroOrOneExpr{
+								pos: position{line: 250, col: 56, offset: 5872},
+								expr: &actionExpr{
+									pos: position{line: 250, col: 57, offset: 5873},
+									run: (*parser).callonCloneOption10,
+									expr: &seqExpr{
+										pos: position{line: 250, col: 57, offset: 5873},
+										exprs: []any{
+											&ruleRefExpr{
+												pos:  position{line: 250, col: 57, offset: 5873},
+												name: "_",
+											},
+											&labeledExpr{
+												pos:   position{line: 250, col: 59, offset: 5875},
+												label: "d",
+												expr: &ruleRefExpr{
+													pos:  position{line: 250, col: 61, offset: 5877},
+													name: "CloneDepth",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "CloneStrategy",
+			pos:  position{line: 258, col: 1, offset: 6024},
+			expr: &actionExpr{
+				pos: position{line: 258, col: 17, offset: 6040},
+				run: (*parser).callonCloneStrategy1,
+				expr: &choiceExpr{
+					pos: position{line: 258, col: 18, offset: 6041},
+					alternatives: []any{
+						&litMatcher{
+							pos:        position{line: 258, col: 18, offset: 6041},
+							val:        "full",
+							ignoreCase: false,
+							want:       "\"full\"",
+						},
+						&litMatcher{
+							pos:        position{line: 258, col: 27, offset: 6050},
+							val:        "shallow",
+							ignoreCase: false,
+							want:       "\"shallow\"",
+						},
+						&litMatcher{
+							pos:        position{line: 258, col: 39, offset: 6062},
+							val:        "blobless",
+							ignoreCase: false,
+							want:       "\"blobless\"",
+						},
+						&litMatcher{
+							pos:        position{line: 258, col: 52, offset: 6075},
+							val:        "treeless",
+							ignoreCase: false,
+							want:       "\"treeless\"",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "CloneDepth",
+			pos:  position{line: 262, col: 1, offset: 6120},
+			expr: &actionExpr{
+				pos: position{line: 262, col: 14, offset: 6133},
+				run: (*parser).callonCloneDepth1,
+				expr: &oneOrMoreExpr{
+					pos: position{line: 262, col: 14, offset: 6133},
+					expr: &charClassMatcher{
+						pos:        position{line: 262, col: 14, offset: 6133},
+						val:        "[0-9]",
+						ranges:     []rune{'0', '9'},
+						ignoreCase: false,
+						inverted:   false,
+					},
+				},
+			},
+		},
 		{
 			name: "BuildOption",
-			pos:  position{line: 137, col: 1, offset: 3061},
+			pos:  position{line: 266, col: 1, offset: 6182},
 			expr: &actionExpr{
-				pos: position{line: 137, col: 15, offset: 3075},
+				pos: position{line: 266, col: 15, offset: 6196},
 				run: (*parser).callonBuildOption1,
 				expr: &seqExpr{
-					pos: position{line: 137, col: 15, offset: 3075},
+					pos: position{line: 266, col: 15, offset: 6196},
 					exprs: []any{
 						&ruleRefExpr{
-							pos:  position{line: 137, col: 15, offset: 3075},
+							pos:  position{line: 266, col: 15, offset: 6196},
 							name: "_",
 						},
 						&litMatcher{
-							pos:        position{line: 137, col: 17, offset: 3077},
+							pos:        position{line: 266, col: 17, offset: 6198},
 							val:        "build",
 							ignoreCase: false,
 							want:       "\"build\"",
 						},
 						&ruleRefExpr{
-							pos:  position{line: 137, col: 25, offset: 3085},
+							pos:  position{line: 266, col: 25, offset: 6206},
 							name: "__",
 						},
 						&litMatcher{
-							pos:        position{line: 137, col: 28, offset: 3088},
+							pos:        position{line: 266, col: 28, offset: 6209},
 							val:        "{",
 							ignoreCase: false,
 							want:       "\"{\"",
 						},
 						&ruleRefExpr{
-							pos:  position{line: 137, col: 32, offset: 3092},
+							pos:  position{line: 266, col: 32, offset: 6213},
 							name: "__",
 						},
 						&labeledExpr{
-							pos:   position{line: 137, col: 35, offset: 3095},
+							pos:   position{line: 266, col: 35, offset: 6216},
 							label: "blocks",
 							expr: &ruleRefExpr{
-								pos:  position{line: 137, col: 42, offset: 3102},
+								pos:  position{line: 266, col: 42, offset: 6223},
 								name: "BuildBlockList",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 137, col: 57, offset: 3117},
+							pos:  position{line: 266, col: 57, offset: 6238},
 							name: "__",
 						},
 						&litMatcher{
-							pos:        position{line: 137, col: 60, offset: 3120},
+							pos:        position{line: 266, col: 60, offset: 6241},
 							val:        "}",
 							ignoreCase: false,
 							want:       "\"}\"",
@@ -581,31 +1122,31 @@ var g = &grammar{
 		},
 		{
 			name: "BuildBlockList",
-			pos:  position{line: 141, col: 1, offset: 3149},
+			pos:  position{line: 270, col: 1, offset: 6270},
 			expr: &actionExpr{
-				pos: position{line: 141, col: 18, offset: 3166},
+				pos: position{line: 270, col: 18, offset: 6287},
 				run: (*parser).callonBuildBlockList1,
 				expr: &labeledExpr{
-					pos:   position{line: 141, col: 18, offset: 3166},
+					pos:   position{line: 270, col: 18, offset: 6287},
 					label: "list",
 					expr: &zeroOrMoreExpr{
-						pos: position{line: 141, col: 23, offset: 3171},
+						pos: position{line: 270, col: 23, offset: 6292},
 						expr: &actionExpr{
-							pos: position{line: 141, col: 24, offset: 3172},
+							pos: position{line: 270, col: 24, offset: 6293},
 							run: (*parser).callonBuildBlockList4,
 							expr: &seqExpr{
-								pos: position{line: 141, col: 24, offset: 3172},
+								pos: position{line: 270, col: 24, offset: 6293},
 								exprs: []any{
 									&labeledExpr{
-										pos:   position{line: 141, col: 24, offset: 3172},
+										pos:   position{line: 270, col: 24, offset: 6293},
 										label: "block",
 										expr: &ruleRefExpr{
-											pos:  position{line: 141, col: 30, offset: 3178},
+											pos:  position{line: 270, col: 30, offset: 6299},
 											name: "BuildBlock",
 										},
 									},
 									&ruleRefExpr{
-										pos:  position{line: 141, col: 41, offset: 3189},
+										pos:  position{line: 270, col: 41, offset: 6310},
 										name: "__",
 									},
 								},
@@ -617,40 +1158,40 @@ var g = &grammar{
 		},
 		{
 			name: "BuildBlock",
-			pos:  position{line: 151, col: 1, offset: 3391},
+			pos:  position{line: 280, col: 1, offset: 6512},
 			expr: &actionExpr{
-				pos: position{line: 151, col: 14, offset: 3404},
+				pos: position{line: 280, col: 14, offset: 6525},
 				run: (*parser).callonBuildBlock1,
 				expr: &seqExpr{
-					pos: position{line: 151, col: 14, offset: 3404},
+					pos: position{line: 280, col: 14, offset: 6525},
 					exprs: []any{
 						&litMatcher{
-							pos:        position{line: 151, col: 14, offset: 3404},
+							pos:        position{line: 280, col: 14, offset: 6525},
 							val:        "on",
 							ignoreCase: false,
 							want:       "\"on\"",
 						},
 						&ruleRefExpr{
-							pos:  position{line: 151, col: 19, offset: 3409},
+							pos:  position{line: 280, col: 19, offset: 6530},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 151, col: 21, offset: 3411},
+							pos:   position{line: 280, col: 21, offset: 6532},
 							label: "osName",
 							expr: &ruleRefExpr{
-								pos:  position{line: 151, col: 28, offset: 3418},
+								pos:  position{line: 280, col: 28, offset: 6539},
 								name: "OSName",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 151, col: 35, offset: 3425},
+							pos:  position{line: 280, col: 35, offset: 6546},
 							name: "__",
 						},
 						&labeledExpr{
-							pos:   position{line: 151, col: 38, offset: 3428},
+							pos:   position{line: 280, col: 38, offset: 6549},
 							label: "cmds",
 							expr: &ruleRefExpr{
-								pos:  position{line: 151, col: 43, offset: 3433},
+								pos:  position{line: 280, col: 43, offset: 6554},
 								name: "BuildCommandList",
 							},
 						},
@@ -660,31 +1201,31 @@ var g = &grammar{
 		},
 		{
 			name: "BuildCommandList",
-			pos:  position{line: 158, col: 1, offset: 3545},
+			pos:  position{line: 287, col: 1, offset: 6666},
 			expr: &actionExpr{
-				pos: position{line: 158, col: 20, offset: 3564},
+				pos: position{line: 287, col: 20, offset: 6685},
 				run: (*parser).callonBuildCommandList1,
 				expr: &labeledExpr{
-					pos:   position{line: 158, col: 20, offset: 3564},
+					pos:   position{line: 287, col: 20, offset: 6685},
 					label: "list",
 					expr: &oneOrMoreExpr{
-						pos: position{line: 158, col: 25, offset: 3569},
+						pos: position{line: 287, col: 25, offset: 6690},
 						expr: &actionExpr{
-							pos: position{line: 158, col: 26, offset: 3570},
+							pos: position{line: 287, col: 26, offset: 6691},
 							run: (*parser).callonBuildCommandList4,
 							expr: &seqExpr{
-								pos: position{line: 158, col: 26, offset: 3570},
+								pos: position{line: 287, col: 26, offset: 6691},
 								exprs: []any{
 									&labeledExpr{
-										pos:   position{line: 158, col: 26, offset: 3570},
+										pos:   position{line: 287, col: 26, offset: 6691},
 										label: "cmd",
 										expr: &ruleRefExpr{
-											pos:  position{line: 158, col: 30, offset: 3574},
+											pos:  position{line: 287, col: 30, offset: 6695},
 											name: "BuildCommand",
 										},
 									},
 									&ruleRefExpr{
-										pos:  position{line: 158, col: 43, offset: 3587},
+										pos:  position{line: 287, col: 43, offset: 6708},
 										name: "__",
 									},
 								},
@@ -696,28 +1237,28 @@ var g = &grammar{
 		},
 		{
 			name: "BuildCommand",
-			pos:  position{line: 168, col: 1, offset: 3763},
+			pos:  position{line: 297, col: 1, offset: 6884},
 			expr: &actionExpr{
-				pos: position{line: 168, col: 16, offset: 3778},
+				pos: position{line: 297, col: 16, offset: 6899},
 				run: (*parser).callonBuildCommand1,
 				expr: &seqExpr{
-					pos: position{line: 168, col: 16, offset: 3778},
+					pos: position{line: 297, col: 16, offset: 6899},
 					exprs: []any{
 						&litMatcher{
-							pos:        position{line: 168, col: 16, offset: 3778},
+							pos:        position{line: 297, col: 16, offset: 6899},
 							val:        "-",
 							ignoreCase: false,
 							want:       "\"-\"",
 						},
 						&ruleRefExpr{
-							pos:  position{line: 168, col: 20, offset: 3782},
+							pos:  position{line: 297, col: 20, offset: 6903},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 168, col: 22, offset: 3784},
+							pos:   position{line: 297, col: 22, offset: 6905},
 							label: "cmd",
 							expr: &ruleRefExpr{
-								pos:  position{line: 168, col: 26, offset: 3788},
+								pos:  position{line: 297, col: 26, offset: 6909},
 								name: "CommandLine",
 							},
 						},
@@ -727,14 +1268,14 @@ var g = &grammar{
 		},
 		{
 			name: "CommandLine",
-			pos:  position{line: 172, col: 1, offset: 3831},
+			pos:  position{line: 301, col: 1, offset: 6952},
 			expr: &actionExpr{
-				pos: position{line: 172, col: 15, offset: 3845},
+				pos: position{line: 301, col: 15, offset: 6966},
 				run: (*parser).callonCommandLine1,
 				expr: &oneOrMoreExpr{
-					pos: position{line: 172, col: 15, offset: 3845},
+					pos: position{line: 301, col: 15, offset: 6966},
 					expr: &charClassMatcher{
-						pos:        position{line: 172, col: 15, offset: 3845},
+						pos:        position{line: 301, col: 15, offset: 6966},
 						val:        "[^\\r\\n]",
 						chars:      []rune{'\r', '\n'},
 						ignoreCase: false,
@@ -745,32 +1286,32 @@ var g = &grammar{
 		},
 		{
 			name: "IncludeDecl",
-			pos:  position{line: 176, col: 1, offset: 3906},
+			pos:  position{line: 305, col: 1, offset: 7027},
 			expr: &actionExpr{
-				pos: position{line: 176, col: 15, offset: 3920},
+				pos: position{line: 305, col: 15, offset: 7041},
 				run: (*parser).callonIncludeDecl1,
 				expr: &seqExpr{
-					pos: position{line: 176, col: 15, offset: 3920},
+					pos: position{line: 305, col: 15, offset: 7041},
 					exprs: []any{
 						&ruleRefExpr{
-							pos:  position{line: 176, col: 15, offset: 3920},
+							pos:  position{line: 305, col: 15, offset: 7041},
 							name: "_",
 						},
 						&litMatcher{
-							pos:        position{line: 176, col: 17, offset: 3922},
+							pos:        position{line: 305, col: 17, offset: 7043},
 							val:        "include",
 							ignoreCase: false,
 							want:       "\"include\"",
 						},
 						&ruleRefExpr{
-							pos:  position{line: 176, col: 27, offset: 3932},
+							pos:  position{line: 305, col: 27, offset: 7053},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 176, col: 29, offset: 3934},
+							pos:   position{line: 305, col: 29, offset: 7055},
 							label: "path",
 							expr: &ruleRefExpr{
-								pos:  position{line: 176, col: 34, offset: 3939},
+								pos:  position{line: 305, col: 34, offset: 7060},
 								name: "IncludePath",
 							},
 						},
@@ -780,16 +1321,16 @@ var g = &grammar{
 		},
 		{
 			name: "IncludePath",
-			pos:  position{line: 180, col: 1, offset: 4006},
+			pos:  position{line: 309, col: 1, offset: 7127},
 			expr: &choiceExpr{
-				pos: position{line: 180, col: 15, offset: 4020},
+				pos: position{line: 309, col: 15, offset: 7141},
 				alternatives: []any{
 					&ruleRefExpr{
-						pos:  position{line: 180, col: 15, offset: 4020},
+						pos:  position{line: 309, col: 15, offset: 7141},
 						name: "QuotedIncludePath",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 180, col: 35, offset: 4040},
+						pos:  position{line: 309, col: 35, offset: 7161},
 						name: "UnquotedIncludePath",
 					},
 				},
@@ -797,25 +1338,25 @@ var g = &grammar{
 		},
 		{
 			name: "QuotedIncludePath",
-			pos:  position{line: 182, col: 1, offset: 4061},
+			pos:  position{line: 311, col: 1, offset: 7182},
 			expr: &ruleRefExpr{
-				pos:  position{line: 182, col: 21, offset: 4081},
+				pos:  position{line: 311, col: 21, offset: 7202},
 				name: "StringLiteral",
 			},
 		},
 		{
 			name: "UnquotedIncludePath",
-			pos:  position{line: 184, col: 1, offset: 4096},
+			pos:  position{line: 313, col: 1, offset: 7217},
 			expr: &actionExpr{
-				pos: position{line: 184, col: 23, offset: 4118},
+				pos: position{line: 313, col: 23, offset: 7239},
 				run: (*parser).callonUnquotedIncludePath1,
 				expr: &labeledExpr{
-					pos:   position{line: 184, col: 23, offset: 4118},
+					pos:   position{line: 313, col: 23, offset: 7239},
 					label: "chars",
 					expr: &oneOrMoreExpr{
-						pos: position{line: 184, col: 29, offset: 4124},
+						pos: position{line: 313, col: 29, offset: 7245},
 						expr: &charClassMatcher{
-							pos:        position{line: 184, col: 29, offset: 4124},
+							pos:        position{line: 313, col: 29, offset: 7245},
 							val:        "[a-zA-Z0-9_./\\\\*%$@:{}~-]",
 							chars:      []rune{'_', '.', '/', '\\', '*', '%', '$', '@', ':', '{', '}', '~', '-'},
 							ranges:     []rune{'a', 'z', 'A', 'Z', '0', '9'},
@@ -828,17 +1369,17 @@ var g = &grammar{
 		},
 		{
 			name: "BundleName",
-			pos:  position{line: 188, col: 1, offset: 4184},
+			pos:  position{line: 317, col: 1, offset: 7305},
 			expr: &actionExpr{
-				pos: position{line: 188, col: 14, offset: 4197},
+				pos: position{line: 317, col: 14, offset: 7318},
 				run: (*parser).callonBundleName1,
 				expr: &labeledExpr{
-					pos:   position{line: 188, col: 14, offset: 4197},
+					pos:   position{line: 317, col: 14, offset: 7318},
 					label: "chars",
 					expr: &oneOrMoreExpr{
-						pos: position{line: 188, col: 20, offset: 4203},
+						pos: position{line: 317, col: 20, offset: 7324},
 						expr: &charClassMatcher{
-							pos:        position{line: 188, col: 20, offset: 4203},
+							pos:        position{line: 317, col: 20, offset: 7324},
 							val:        "[a-zA-Z0-9_./\\\\*%$@:~-]",
 							chars:      []rune{'_', '.', '/', '\\', '*', '%', '$', '@', ':', '~', '-'},
 							ranges:     []rune{'a', 'z', 'A', 'Z', '0', '9'},
@@ -851,17 +1392,17 @@ var g = &grammar{
 		},
 		{
 			name: "OSName",
-			pos:  position{line: 192, col: 1, offset: 4261},
+			pos:  position{line: 321, col: 1, offset: 7382},
 			expr: &actionExpr{
-				pos: position{line: 192, col: 10, offset: 4270},
+				pos: position{line: 321, col: 10, offset: 7391},
 				run: (*parser).callonOSName1,
 				expr: &labeledExpr{
-					pos:   position{line: 192, col: 10, offset: 4270},
+					pos:   position{line: 321, col: 10, offset: 7391},
 					label: "chars",
 					expr: &oneOrMoreExpr{
-						pos: position{line: 192, col: 16, offset: 4276},
+						pos: position{line: 321, col: 16, offset: 7397},
 						expr: &charClassMatcher{
-							pos:        position{line: 192, col: 16, offset: 4276},
+							pos:        position{line: 321, col: 16, offset: 7397},
 							val:        "[a-zA-Z0-9_*.-]",
 							chars:      []rune{'_', '*', '.', '-'},
 							ranges:     []rune{'a', 'z', 'A', 'Z', '0', '9'},
@@ -874,70 +1415,70 @@ var g = &grammar{
 		},
 		{
 			name: "ReplaceDecl",
-			pos:  position{line: 196, col: 1, offset: 4326},
+			pos:  position{line: 325, col: 1, offset: 7447},
 			expr: &actionExpr{
-				pos: position{line: 196, col: 15, offset: 4340},
+				pos: position{line: 325, col: 15, offset: 7461},
 				run: (*parser).callonReplaceDecl1,
 				expr: &seqExpr{
-					pos: position{line: 196, col: 15, offset: 4340},
+					pos: position{line: 325, col: 15, offset: 7461},
 					exprs: []any{
 						&ruleRefExpr{
-							pos:  position{line: 196, col: 15, offset: 4340},
+							pos:  position{line: 325, col: 15, offset: 7461},
 							name: "_",
 						},
 						&litMatcher{
-							pos:        position{line: 196, col: 17, offset: 4342},
+							pos:        position{line: 325, col: 17, offset: 7463},
 							val:        "replace",
 							ignoreCase: false,
 							want:       "\"replace\"",
 						},
 						&ruleRefExpr{
-							pos:  position{line: 196, col: 27, offset: 4352},
+							pos:  position{line: 325, col: 27, offset: 7473},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 196, col: 29, offset: 4354},
+							pos:   position{line: 325, col: 29, offset: 7475},
 							label: "target",
 							expr: &ruleRefExpr{
-								pos:  position{line: 196, col: 36, offset: 4361},
+								pos:  position{line: 325, col: 36, offset: 7482},
 								name: "BundleName",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 196, col: 47, offset: 4372},
+							pos:  position{line: 325, col: 47, offset: 7493},
 							name: "__",
 						},
 						&litMatcher{
-							pos:        position{line: 196, col: 50, offset: 4375},
+							pos:        position{line: 325, col: 50, offset: 7496},
 							val:        "{",
 							ignoreCase: false,
 							want:       "\"{\"",
 						},
 						&ruleRefExpr{
-							pos:  position{line: 196, col: 54, offset: 4379},
+							pos:  position{line: 325, col: 54, offset: 7500},
 							name: "__",
 						},
 						&labeledExpr{
-							pos:   position{line: 196, col: 57, offset: 4382},
+							pos:   position{line: 325, col: 57, offset: 7503},
 							label: "opts",
 							expr: &zeroOrMoreExpr{
-								pos: position{line: 196, col: 62, offset: 4387},
+								pos: position{line: 325, col: 62, offset: 7508},
 								expr: &actionExpr{
-									pos: position{line: 196, col: 63, offset: 4388},
+									pos: position{line: 325, col: 63, offset: 7509},
 									run: (*parser).callonReplaceDecl13,
 									expr: &seqExpr{
-										pos: position{line: 196, col: 63, offset: 4388},
+										pos: position{line: 325, col: 63, offset: 7509},
 										exprs: []any{
 											&labeledExpr{
-												pos:   position{line: 196, col: 63, offset: 4388},
+												pos:   position{line: 325, col: 63, offset: 7509},
 												label: "opt",
 												expr: &ruleRefExpr{
-													pos:  position{line: 196, col: 67, offset: 4392},
+													pos:  position{line: 325, col: 67, offset: 7513},
 													name: "BlockOption",
 												},
 											},
 											&ruleRefExpr{
-												pos:  position{line: 196, col: 79, offset: 4404},
+												pos:  position{line: 325, col: 79, offset: 7525},
 												name: "__",
 											},
 										},
@@ -946,7 +1487,7 @@ var g = &grammar{
 							},
 						},
 						&litMatcher{
-							pos:        position{line: 196, col: 104, offset: 4429},
+							pos:        position{line: 325, col: 104, offset: 7550},
 							val:        "}",
 							ignoreCase: false,
 							want:       "\"}\"",
@@ -957,70 +1498,70 @@ var g = &grammar{
 		},
 		{
 			name: "MergeDecl",
-			pos:  position{line: 207, col: 1, offset: 4616},
+			pos:  position{line: 340, col: 1, offset: 7796},
 			expr: &actionExpr{
-				pos: position{line: 207, col: 13, offset: 4628},
+				pos: position{line: 340, col: 13, offset: 7808},
 				run: (*parser).callonMergeDecl1,
 				expr: &seqExpr{
-					pos: position{line: 207, col: 13, offset: 4628},
+					pos: position{line: 340, col: 13, offset: 7808},
 					exprs: []any{
 						&ruleRefExpr{
-							pos:  position{line: 207, col: 13, offset: 4628},
+							pos:  position{line: 340, col: 13, offset: 7808},
 							name: "_",
 						},
 						&litMatcher{
-							pos:        position{line: 207, col: 15, offset: 4630},
+							pos:        position{line: 340, col: 15, offset: 7810},
 							val:        "merge",
 							ignoreCase: false,
 							want:       "\"merge\"",
 						},
 						&ruleRefExpr{
-							pos:  position{line: 207, col: 23, offset: 4638},
+							pos:  position{line: 340, col: 23, offset: 7818},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 207, col: 25, offset: 4640},
+							pos:   position{line: 340, col: 25, offset: 7820},
 							label: "target",
 							expr: &ruleRefExpr{
-								pos:  position{line: 207, col: 32, offset: 4647},
+								pos:  position{line: 340, col: 32, offset: 7827},
 								name: "BundleName",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 207, col: 43, offset: 4658},
+							pos:  position{line: 340, col: 43, offset: 7838},
 							name: "__",
 						},
 						&litMatcher{
-							pos:        position{line: 207, col: 46, offset: 4661},
+							pos:        position{line: 340, col: 46, offset: 7841},
 							val:        "{",
 							ignoreCase: false,
 							want:       "\"{\"",
 						},
 						&ruleRefExpr{
-							pos:  position{line: 207, col: 50, offset: 4665},
+							pos:  position{line: 340, col: 50, offset: 7845},
 							name: "__",
 						},
 						&labeledExpr{
-							pos:   position{line: 207, col: 53, offset: 4668},
+							pos:   position{line: 340, col: 53, offset: 7848},
 							label: "opts",
 							expr: &zeroOrMoreExpr{
-								pos: position{line: 207, col: 58, offset: 4673},
+								pos: position{line: 340, col: 58, offset: 7853},
 								expr: &actionExpr{
-									pos: position{line: 207, col: 59, offset: 4674},
+									pos: position{line: 340, col: 59, offset: 7854},
 									run: (*parser).callonMergeDecl13,
 									expr: &seqExpr{
-										pos: position{line: 207, col: 59, offset: 4674},
+										pos: position{line: 340, col: 59, offset: 7854},
 										exprs: []any{
 											&labeledExpr{
-												pos:   position{line: 207, col: 59, offset: 4674},
+												pos:   position{line: 340, col: 59, offset: 7854},
 												label: "opt",
 												expr: &ruleRefExpr{
-													pos:  position{line: 207, col: 63, offset: 4678},
+													pos:  position{line: 340, col: 63, offset: 7858},
 													name: "BlockOption",
 												},
 											},
 											&ruleRefExpr{
-												pos:  position{line: 207, col: 75, offset: 4690},
+												pos:  position{line: 340, col: 75, offset: 7870},
 												name: "__",
 											},
 										},
@@ -1029,7 +1570,7 @@ var g = &grammar{
 							},
 						},
 						&litMatcher{
-							pos:        position{line: 207, col: 100, offset: 4715},
+							pos:        position{line: 340, col: 100, offset: 7895},
 							val:        "}",
 							ignoreCase: false,
 							want:       "\"}\"",
@@ -1040,16 +1581,16 @@ var g = &grammar{
 		},
 		{
 			name: "StringLiteral",
-			pos:  position{line: 218, col: 1, offset: 4900},
+			pos:  position{line: 355, col: 1, offset: 8139},
 			expr: &choiceExpr{
-				pos: position{line: 218, col: 17, offset: 4916},
+				pos: position{line: 355, col: 17, offset: 8155},
 				alternatives: []any{
 					&ruleRefExpr{
-						pos:  position{line: 218, col: 17, offset: 4916},
+						pos:  position{line: 355, col: 17, offset: 8155},
 						name: "DoubleQuotedString",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 218, col: 38, offset: 4937},
+						pos:  position{line: 355, col: 38, offset: 8176},
 						name: "SingleQuotedString",
 					},
 				},
@@ -1057,23 +1598,23 @@ var g = &grammar{
 		},
 		{
 			name: "DoubleQuotedString",
-			pos:  position{line: 220, col: 1, offset: 4957},
+			pos:  position{line: 357, col: 1, offset: 8196},
 			expr: &actionExpr{
-				pos: position{line: 220, col: 22, offset: 4978},
+				pos: position{line: 357, col: 22, offset: 8217},
 				run: (*parser).callonDoubleQuotedString1,
 				expr: &seqExpr{
-					pos: position{line: 220, col: 22, offset: 4978},
+					pos: position{line: 357, col: 22, offset: 8217},
 					exprs: []any{
 						&litMatcher{
-							pos:        position{line: 220, col: 22, offset: 4978},
+							pos:        position{line: 357, col: 22, offset: 8217},
 							val:        "\"",
 							ignoreCase: false,
 							want:       "\"\\\"\"",
 						},
 						&zeroOrMoreExpr{
-							pos: position{line: 220, col: 26, offset: 4982},
+							pos: position{line: 357, col: 26, offset: 8221},
 							expr: &charClassMatcher{
-								pos:        position{line: 220, col: 26, offset: 4982},
+								pos:        position{line: 357, col: 26, offset: 8221},
 								val:        "[^\"\\r\\n]",
 								chars:      []rune{'"', '\r', '\n'},
 								ignoreCase: false,
@@ -1081,7 +1622,7 @@ var g = &grammar{
 							},
 						},
 						&litMatcher{
-							pos:        position{line: 220, col: 36, offset: 4992},
+							pos:        position{line: 357, col: 36, offset: 8231},
 							val:        "\"",
 							ignoreCase: false,
 							want:       "\"\\\"\"",
@@ -1092,23 +1633,23 @@ var g = &grammar{
 		},
 		{
 			name: "SingleQuotedString",
-			pos:  position{line: 224, col: 1, offset: 5046},
+			pos:  position{line: 361, col: 1, offset: 8285},
 			expr: &actionExpr{
-				pos: position{line: 224, col: 22, offset: 5067},
+				pos: position{line: 361, col: 22, offset: 8306},
 				run: (*parser).callonSingleQuotedString1,
 				expr: &seqExpr{
-					pos: position{line: 224, col: 22, offset: 5067},
+					pos: position{line: 361, col: 22, offset: 8306},
 					exprs: []any{
 						&litMatcher{
-							pos:        position{line: 224, col: 22, offset: 5067},
+							pos:        position{line: 361, col: 22, offset: 8306},
 							val:        "'",
 							ignoreCase: false,
 							want:       "\"'\"",
 						},
 						&zeroOrMoreExpr{
-							pos: position{line: 224, col: 26, offset: 5071},
+							pos: position{line: 361, col: 26, offset: 8310},
 							expr: &charClassMatcher{
-								pos:        position{line: 224, col: 26, offset: 5071},
+								pos:        position{line: 361, col: 26, offset: 8310},
 								val:        "[^'\\r\\n]",
 								chars:      []rune{'\'', '\r', '\n'},
 								ignoreCase: false,
@@ -1116,7 +1657,7 @@ var g = &grammar{
 							},
 						},
 						&litMatcher{
-							pos:        position{line: 224, col: 36, offset: 5081},
+							pos:        position{line: 361, col: 36, offset: 8320},
 							val:        "'",
 							ignoreCase: false,
 							want:       "\"'\"",
@@ -1127,20 +1668,20 @@ var g = &grammar{
 		},
 		{
 			name: "Comment",
-			pos:  position{line: 228, col: 1, offset: 5135},
+			pos:  position{line: 365, col: 1, offset: 8374},
 			expr: &seqExpr{
-				pos: position{line: 228, col: 11, offset: 5145},
+				pos: position{line: 365, col: 11, offset: 8384},
 				exprs: []any{
 					&litMatcher{
-						pos:        position{line: 228, col: 11, offset: 5145},
+						pos:        position{line: 365, col: 11, offset: 8384},
 						val:        "#",
 						ignoreCase: false,
 						want:       "\"#\"",
 					},
 					&zeroOrMoreExpr{
-						pos: position{line: 228, col: 15, offset: 5149},
+						pos: position{line: 365, col: 15, offset: 8388},
 						expr: &charClassMatcher{
-							pos:        position{line: 228, col: 15, offset: 5149},
+							pos:        position{line: 365, col: 15, offset: 8388},
 							val:        "[^\\r\\n]",
 							chars:      []rune{'\r', '\n'},
 							ignoreCase: false,
@@ -1152,11 +1693,25 @@ var g = &grammar{
 		},
 		{
 			name: "_",
-			pos:  position{line: 230, col: 1, offset: 5159},
+			pos:  position{line: 367, col: 1, offset: 8398},
 			expr: &zeroOrMoreExpr{
-				pos: position{line: 230, col: 5, offset: 5163},
+				pos: position{line: 367, col: 5, offset: 8402},
 				expr: &charClassMatcher{
-					pos:        position{line: 230, col: 5, offset: 5163},
+					pos:        position{line: 367, col: 5, offset: 8402},
+					val:        "[ \\t]",
+					chars:      []rune{' ', '\t'},
+					ignoreCase: false,
+					inverted:   false,
+				},
+			},
+		},
+		{
+			name: "WS",
+			pos:  position{line: 369, col: 1, offset: 8410},
+			expr: &oneOrMoreExpr{
+				pos: position{line: 369, col: 6, offset: 8415},
+				expr: &charClassMatcher{
+					pos:        position{line: 369, col: 6, offset: 8415},
 					val:        "[ \\t]",
 					chars:      []rune{' ', '\t'},
 					ignoreCase: false,
@@ -1166,16 +1721,16 @@ var g = &grammar{
 		},
 		{
 			name: "__",
-			pos:  position{line: 232, col: 1, offset: 5171},
+			pos:  position{line: 371, col: 1, offset: 8423},
 			expr: &zeroOrMoreExpr{
-				pos: position{line: 232, col: 6, offset: 5176},
+				pos: position{line: 371, col: 6, offset: 8428},
 				expr: &choiceExpr{
-					pos: position{line: 232, col: 8, offset: 5178},
+					pos: position{line: 371, col: 8, offset: 8430},
 					alternatives: []any{
 						&oneOrMoreExpr{
-							pos: position{line: 232, col: 8, offset: 5178},
+							pos: position{line: 371, col: 8, offset: 8430},
 							expr: &charClassMatcher{
-								pos:        position{line: 232, col: 8, offset: 5178},
+								pos:        position{line: 371, col: 8, offset: 8430},
 								val:        "[ \\t\\r\\n]",
 								chars:      []rune{' ', '\t', '\r', '\n'},
 								ignoreCase: false,
@@ -1183,7 +1738,7 @@ var g = &grammar{
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 232, col: 21, offset: 5191},
+							pos:  position{line: 371, col: 21, offset: 8443},
 							name: "Comment",
 						},
 					},
@@ -1192,11 +1747,11 @@ var g = &grammar{
 		},
 		{
 			name: "EOF",
-			pos:  position{line: 234, col: 1, offset: 5203},
+			pos:  position{line: 373, col: 1, offset: 8455},
 			expr: &notExpr{
-				pos: position{line: 234, col: 7, offset: 5209},
+				pos: position{line: 373, col: 7, offset: 8461},
 				expr: &anyMatcher{
-					line: 234, col: 8, offset: 5210,
+					line: 373, col: 8, offset: 8462,
 				},
 			},
 		},
@@ -1260,7 +1815,7 @@ func (c *current) onBundleDecl1(name, block, opts any) (any, error) {
 	if opts != nil {
 		inlineOpts = opts.([]interface{})
 	}
-	return buildBundleDecl(name.(string), blockOpts, inlineOpts), nil
+	return buildBundleDecl(name.(string), blockOpts, inlineOpts)
 }
 
 func (p *parser) callonBundleDecl1() (any, error) {
@@ -1355,6 +1910,156 @@ func (p *parser) callonEnableIfOption1() (any, error) {
 	return p.cur.onEnableIfOption1(stack["expr"])
 }
 
+func (c *current) onTriggerOption1(kind, names any) (any, error) {
+	return triggerOpt{kind: kind.(string), names: names.([]string)}, nil
+}
+
+func (p *parser) callonTriggerOption1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onTriggerOption1(stack["kind"], stack["names"])
+}
+
+func (c *current) onTriggerKind1() (any, error) {
+	return string(c.text), nil
+}
+
+func (p *parser) callonTriggerKind1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onTriggerKind1()
+}
+
+func (c *current) onTriggerList4(name any) (any, error) {
+	return name, nil
+}
+
+func (p *parser) callonTriggerList4() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onTriggerList4(stack["name"])
+}
+
+func (c *current) onTriggerList1(list any) (any, error) {
+	var names []string
+	if list != nil {
+		for _, item := range list.([]interface{}) {
+			names = append(names, item.(string))
+		}
+	}
+	return names, nil
+}
+
+func (p *parser) callonTriggerList1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onTriggerList1(stack["list"])
+}
+
+func (c *current) onUnquotedTriggerName1(chars any) (any, error) {
+	return string(c.text), nil
+}
+
+func (p *parser) callonUnquotedTriggerName1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onUnquotedTriggerName1(stack["chars"])
+}
+
+func (c *current) onEnableIfLuaOption1(expr any) (any, error) {
+	return enableIfLuaOpt{expr: expr.(string)}, nil
+}
+
+func (p *parser) callonEnableIfLuaOption1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onEnableIfLuaOption1(stack["expr"])
+}
+
+func (c *current) onBranchOption1(name any) (any, error) {
+	return refOpt{kind: "branch", name: name.(string)}, nil
+}
+
+func (p *parser) callonBranchOption1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onBranchOption1(stack["name"])
+}
+
+func (c *current) onTagOption1(name any) (any, error) {
+	return refOpt{kind: "tag", name: name.(string)}, nil
+}
+
+func (p *parser) callonTagOption1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onTagOption1(stack["name"])
+}
+
+func (c *current) onRevOption1(name any) (any, error) {
+	return refOpt{kind: "rev", name: name.(string)}, nil
+}
+
+func (p *parser) callonRevOption1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onRevOption1(stack["name"])
+}
+
+func (c *current) onRefName1(chars any) (any, error) {
+	return string(c.text), nil
+}
+
+func (p *parser) callonRefName1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onRefName1(stack["chars"])
+}
+
+func (c *current) onCloneOption10(d any) (any, error) {
+	return d, nil
+}
+
+func (p *parser) callonCloneOption10() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onCloneOption10(stack["d"])
+}
+
+func (c *current) onCloneOption1(strategy, depth any) (any, error) {
+	opt := cloneOpt{strategy: strategy.(string)}
+	if depth != nil {
+		opt.depth = depth.(int)
+	}
+	return opt, nil
+}
+
+func (p *parser) callonCloneOption1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onCloneOption1(stack["strategy"], stack["depth"])
+}
+
+func (c *current) onCloneStrategy1() (any, error) {
+	return string(c.text), nil
+}
+
+func (p *parser) callonCloneStrategy1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onCloneStrategy1()
+}
+
+func (c *current) onCloneDepth1() (any, error) {
+	return strconv.Atoi(string(c.text))
+}
+
+func (p *parser) callonCloneDepth1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onCloneDepth1()
+}
+
 func (c *current) onBuildOption1(blocks any) (any, error) {
 	return blocks, nil
 }
@@ -1505,9 +2210,13 @@ func (c *current) onReplaceDecl1(target, opts any) (any, error) {
 	if opts != nil {
 		blockOpts = opts.([]interface{})
 	}
+	patch, err := buildPatch(blockOpts)
+	if err != nil {
+		return nil, err
+	}
 	return ast.ReplaceDecl{
 		Target: target.(string),
-		Bundle: buildPatch(blockOpts),
+		Bundle: patch,
 	}, nil
 }
 
@@ -1532,9 +2241,13 @@ func (c *current) onMergeDecl1(target, opts any) (any, error) {
 	if opts != nil {
 		blockOpts = opts.([]interface{})
 	}
+	patch, err := buildPatch(blockOpts)
+	if err != nil {
+		return nil, err
+	}
 	return ast.MergeDecl{
 		Target: target.(string),
-		Patch:  buildPatch(blockOpts),
+		Patch:  patch,
 	}, nil
 }
 
@@ -0,0 +1,203 @@
+package hariti
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ErrLabelNotFound is returned when untagging a label no generation has.
+var ErrLabelNotFound = errors.New("label not found")
+
+// labelPattern restricts labels to names that are safe in file names and
+// cannot be mistaken for options or relative rollback targets.
+var labelPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// generationLabels is the content of the labels.json of a generation.
+type generationLabels struct {
+	Labels []string `json:"labels"`
+}
+
+// TagOptions holds options for TagGeneration.
+type TagOptions struct {
+	// Force moves the label when another generation already has it.
+	Force bool
+}
+
+// TagGeneration labels a generation, named by ID or by one of its labels, and
+// returns its ID. A label names a single generation.
+func (h *Hariti) TagGeneration(ref, label string, opts TagOptions) (string, error) {
+	if !labelPattern.MatchString(label) {
+		return "", fmt.Errorf("invalid label %q: labels start with a letter or digit and contain only letters, digits, '.', '_' and '-'", label)
+	}
+	if _, err := h.readGenerationMetadata(label); err == nil {
+		return "", fmt.Errorf("invalid label %q: a generation has the same ID", label)
+	} else if !errors.Is(err, ErrGenerationNotFound) {
+		return "", err
+	}
+
+	genID, err := h.ResolveGeneration(ref)
+	if err != nil {
+		return "", err
+	}
+
+	index, err := h.labelIndex()
+	if err != nil {
+		return "", err
+	}
+	if owner, exists := index[label]; exists {
+		if owner == genID {
+			return genID, nil
+		}
+		if !opts.Force {
+			return "", fmt.Errorf("label %s already names generation %s", label, owner)
+		}
+		if err := h.removeLabel(owner, label); err != nil {
+			return "", err
+		}
+		h.logger.Infof("label %s removed from generation %s", label, owner)
+	}
+
+	labels, err := h.readLabels(genID)
+	if err != nil {
+		return "", err
+	}
+	labels = append(labels, label)
+	slices.Sort(labels)
+	if err := h.writeLabels(genID, labels); err != nil {
+		return "", err
+	}
+	h.logger.Infof("generation %s labeled %s", genID, label)
+	return genID, nil
+}
+
+// UntagGeneration removes a label and returns the ID of the generation that
+// had it.
+func (h *Hariti) UntagGeneration(label string) (string, error) {
+	index, err := h.labelIndex()
+	if err != nil {
+		return "", err
+	}
+	genID, exists := index[label]
+	if !exists {
+		return "", fmt.Errorf("%w: %s", ErrLabelNotFound, label)
+	}
+	if err := h.removeLabel(genID, label); err != nil {
+		return "", err
+	}
+	h.logger.Infof("label %s removed from generation %s", label, genID)
+	return genID, nil
+}
+
+// ResolveGeneration turns a generation ID or label into a generation ID.
+// IDs take precedence over labels.
+func (h *Hariti) ResolveGeneration(ref string) (string, error) {
+	if _, err := h.readGenerationMetadata(ref); err == nil {
+		return ref, nil
+	} else if !errors.Is(err, ErrGenerationNotFound) {
+		return "", err
+	}
+
+	index, err := h.labelIndex()
+	if err != nil {
+		return "", err
+	}
+	if genID, exists := index[ref]; exists {
+		return genID, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrGenerationNotFound, ref)
+}
+
+// describeGeneration formats a generation ID together with its labels for
+// log messages.
+func (h *Hariti) describeGeneration(genID string) string {
+	labels, err := h.readLabels(genID)
+	if err != nil || len(labels) == 0 {
+		return genID
+	}
+	return fmt.Sprintf("%s (%s)", genID, strings.Join(labels, ", "))
+}
+
+// labelIndex maps every label to the generation that has it.
+func (h *Hariti) labelIndex() (map[string]string, error) {
+	dirEntries, err := os.ReadDir(h.GenerationsDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]string{}, nil
+		}
+		return nil, fmt.Errorf("failed to read generations directory: %w", err)
+	}
+
+	index := make(map[string]string)
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.IsDir() {
+			continue
+		}
+		labels, err := h.readLabels(dirEntry.Name())
+		if err != nil {
+			return nil, err
+		}
+		for _, label := range labels {
+			index[label] = dirEntry.Name()
+		}
+	}
+	return index, nil
+}
+
+// readLabels reads the labels of a generation, sorted. A generation without
+// labels.json has no labels.
+func (h *Hariti) readLabels(genID string) ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(h.GenerationsDir(), genID, "labels.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read labels of generation %s: %w", genID, err)
+	}
+	var labels generationLabels
+	if err := json.Unmarshal(data, &labels); err != nil {
+		return nil, fmt.Errorf("failed to parse labels of generation %s: %w", genID, err)
+	}
+	return labels.Labels, nil
+}
+
+// writeLabels replaces the labels of a generation, removing labels.json when
+// none are left.
+func (h *Hariti) writeLabels(genID string, labels []string) error {
+	path := filepath.Join(h.GenerationsDir(), genID, "labels.json")
+	if len(labels) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove labels of generation %s: %w", genID, err)
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(&generationLabels{Labels: labels}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize labels of generation %s: %w", genID, err)
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write labels of generation %s: %w", genID, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to write labels of generation %s: %w", genID, err)
+	}
+	return nil
+}
+
+func (h *Hariti) removeLabel(genID, label string) error {
+	labels, err := h.readLabels(genID)
+	if err != nil {
+		return err
+	}
+	return h.writeLabels(genID, slices.DeleteFunc(labels, func(l string) bool {
+		return l == label
+	}))
+}
@@ -0,0 +1,140 @@
+package hariti_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kamichidu/go-hariti"
+	"github.com/kamichidu/go-hariti/graph"
+)
+
+func TestHariti_TagGeneration(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	xdgHome := filepath.Join(tmpDir, "xdg_home")
+	_ = os.Setenv("XDG_DATA_HOME", xdgHome)
+	defer func() {
+		_ = os.Unsetenv("XDG_DATA_HOME")
+	}()
+
+	cfg := &hariti.HaritiConfig{
+		Paths: hariti.Paths{
+			ConfigFile: filepath.Join(tmpDir, "hariti_home", "bundles.hariti"),
+			ConfigDir:  filepath.Join(tmpDir, "hariti_home"),
+			DataDir:    filepath.Join(xdgHome, "hariti"),
+		},
+		Writer:    io.Discard,
+		ErrWriter: io.Discard,
+	}
+	har := hariti.NewHariti(cfg)
+	ctx := context.Background()
+
+	// Deploy two generations from different local bundles
+	deploy := func(name string) string {
+		localDir := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(localDir, 0755); err != nil {
+			t.Fatalf("failed to create local bundle dir: %v", err)
+		}
+		g := &graph.Graph{
+			Bundles: []graph.Bundle{
+				{ID: "my/local", Source: graph.Source{Type: graph.SourceTypeLocal, Path: localDir}},
+			},
+		}
+		if _, err := har.Sync(ctx, g, hariti.SyncOptions{}); err != nil {
+			t.Fatalf("Sync failed: %v", err)
+		}
+		genID, err := har.Deploy(ctx, g, hariti.DeployOptions{})
+		if err != nil {
+			t.Fatalf("Deploy failed: %v", err)
+		}
+		return genID
+	}
+	firstID := deploy("first")
+	secondID := deploy("second")
+
+	// Step 1: Label a generation and resolve the label
+	if genID, err := har.TagGeneration(firstID, "stable", hariti.TagOptions{}); err != nil || genID != firstID {
+		t.Fatalf("TagGeneration failed: %s, %v", genID, err)
+	}
+	if genID, err := har.ResolveGeneration("stable"); err != nil || genID != firstID {
+		t.Errorf("expected stable to resolve to %s, got %s, %v", firstID, genID, err)
+	}
+	if genID, err := har.ResolveGeneration(secondID); err != nil || genID != secondID {
+		t.Errorf("expected %s to resolve to itself, got %s, %v", secondID, genID, err)
+	}
+	if _, err := har.ResolveGeneration("unknown"); !errors.Is(err, hariti.ErrGenerationNotFound) {
+		t.Errorf("expected ErrGenerationNotFound, got: %v", err)
+	}
+	generations, err := har.Generations()
+	if err != nil {
+		t.Fatalf("Generations failed: %v", err)
+	}
+	if len(generations[0].Labels) != 1 || generations[0].Labels[0] != "stable" || len(generations[1].Labels) != 0 {
+		t.Errorf("expected only %s to be labeled stable, got %+v", firstID, generations)
+	}
+
+	// Step 2: A label names a single generation unless forced to move
+	if _, err := har.TagGeneration(secondID, "stable", hariti.TagOptions{}); err == nil {
+		t.Error("expected tagging another generation with stable to fail, got nil")
+	}
+	if _, err := har.TagGeneration(secondID, "stable", hariti.TagOptions{Force: true}); err != nil {
+		t.Fatalf("TagGeneration with Force failed: %v", err)
+	}
+	if genID, _ := har.ResolveGeneration("stable"); genID != secondID {
+		t.Errorf("expected stable to move to %s, got %s", secondID, genID)
+	}
+	if genID, err := har.TagGeneration("stable", "known-good", hariti.TagOptions{}); err != nil || genID != secondID {
+		t.Errorf("expected tagging by label to label %s, got %s, %v", secondID, genID, err)
+	}
+
+	// Step 3: Invalid labels are rejected
+	for _, label := range []string{"", "-1", "a/b", "has space", firstID} {
+		if _, err := har.TagGeneration(firstID, label, hariti.TagOptions{}); err == nil {
+			t.Errorf("expected label %q to be rejected, got nil", label)
+		}
+	}
+
+	// Step 4: Rollback accepts labels
+	if _, err := har.TagGeneration(firstID, "before-migration", hariti.TagOptions{}); err != nil {
+		t.Fatalf("TagGeneration failed: %v", err)
+	}
+	if genID, err := har.Rollback("before-migration", hariti.RollbackOptions{}); err != nil || genID != firstID {
+		t.Fatalf("expected rollback to %s, got %s, %v", firstID, genID, err)
+	}
+
+	// Step 5: GC keeps labeled generations until their labels are removed
+	report, err := har.GC(&graph.Graph{}, hariti.GCOptions{})
+	if err != nil {
+		t.Fatalf("GC failed: %v", err)
+	}
+	for _, entry := range report.Removed {
+		if entry.Kind == hariti.GCKindGeneration {
+			t.Errorf("expected labeled generations to be kept, removed %s", entry.Name)
+		}
+	}
+	for _, label := range []string{"stable", "known-good"} {
+		if genID, err := har.UntagGeneration(label); err != nil || genID != secondID {
+			t.Errorf("expected %s to be removed from %s, got %s, %v", label, secondID, genID, err)
+		}
+	}
+	if _, err := har.UntagGeneration("stable"); !errors.Is(err, hariti.ErrLabelNotFound) {
+		t.Errorf("expected ErrLabelNotFound, got: %v", err)
+	}
+	report, err = har.GC(&graph.Graph{}, hariti.GCOptions{})
+	if err != nil {
+		t.Fatalf("GC failed: %v", err)
+	}
+	removed := false
+	for _, entry := range report.Removed {
+		if entry.Kind == hariti.GCKindGeneration && entry.Name == secondID {
+			removed = true
+		}
+	}
+	if !removed {
+		t.Errorf("expected unlabeled generation %s to be removed, got %+v", secondID, report.Removed)
+	}
+}
@@ -0,0 +1,211 @@
+package hariti
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/kamichidu/go-hariti/graph"
+)
+
+// lazyHelpers are the Vim script functions shared by the stubs of lazily
+// loaded bundles. Each stub calls the loader of its bundle, then re-dispatches
+// the command, key sequence or event that triggered it.
+const lazyHelpers = `
+let s:lazy_loaded = {}
+
+function! s:lazy_source(path) abort
+  augroup filetypedetect
+    for l:file in glob(a:path . '/ftdetect/**/*.vim', 1, 1)
+      execute 'source' fnameescape(l:file)
+    endfor
+  augroup END
+  for l:file in glob(a:path . '/plugin/**/*.vim', 1, 1) + glob(a:path . '/after/plugin/**/*.vim', 1, 1)
+    execute 'source' fnameescape(l:file)
+  endfor
+endfunction
+
+function! s:lazy_cmd(load, cmd, bang, range, line1, line2, args) abort
+  call call(a:load, [])
+  let l:range = a:range == 0 ? '' : a:range == 1 ? a:line1 : a:line1 . ',' . a:line2
+  execute l:range . a:cmd . a:bang . ' ' . a:args
+endfunction
+
+function! s:lazy_keys(notation) abort
+  let l:keys = substitute(a:notation, '\c<leader>', '\=get(g:, "mapleader", "\\")', 'g')
+  let l:keys = substitute(l:keys, '\c<localleader>', '\=get(g:, "maplocalleader", "\\")', 'g')
+  return eval('"' . substitute(escape(l:keys, '\"'), '<[^<>]\+>', '\\&', 'g') . '"')
+endfunction
+
+function! s:lazy_map(load, notation, prefix) abort
+  call call(a:load, [])
+  let l:prefix = v:count ? v:count : ''
+  let l:prefix .= '"' . v:register . a:prefix
+  if mode(1) ==# 'no'
+    if v:operator ==# 'c'
+      let l:prefix = "\<Esc>" . l:prefix
+    endif
+    let l:prefix .= v:operator
+  endif
+  " Insert before any typeahead, prefix first
+  call feedkeys(s:lazy_keys(a:notation), 'i')
+  call feedkeys(l:prefix, 'ni')
+endfunction
+
+function! s:lazy_ft(load) abort
+  call call(a:load, [])
+  execute 'doautocmd <nomodeline> FileType' &filetype
+endfunction
+
+function! s:lazy_event(load, event) abort
+  call call(a:load, [])
+  execute 'doautocmd <nomodeline>' a:event
+endfunction
+`
+
+// lazyMapModes are the modes stub mappings are defined in, with the keys
+// that restore the mode before the original key sequence is replayed.
+var lazyMapModes = []struct {
+	mode   string
+	prefix string
+}{
+	{"n", ""},
+	{"x", "gv"},
+	{"o", ""},
+}
+
+// lazyBundles returns the IDs of the bundles loaded by their triggers rather
+// than at startup. A bundle that some bundle loaded at startup depends on,
+// directly or transitively, is loaded at startup too, even when it declares
+// triggers. bundles must be in dependency order with dependencies resolved to
+// canonical IDs.
+func lazyBundles(bundles []graph.Bundle) map[string]bool {
+	eager := make(map[string]bool, len(bundles))
+	// Dependents come after their dependencies, so walking backwards marks a
+	// bundle before its dependencies are visited
+	for i := len(bundles) - 1; i >= 0; i-- {
+		bundle := bundles[i]
+		if !bundle.IsLazy() {
+			eager[bundle.ID] = true
+		}
+		if eager[bundle.ID] {
+			for _, dep := range bundle.Dependencies {
+				eager[dep] = true
+			}
+		}
+	}
+
+	lazy := make(map[string]bool)
+	for _, bundle := range bundles {
+		if !eager[bundle.ID] {
+			lazy[bundle.ID] = true
+		}
+	}
+	return lazy
+}
+
+// lazyLoaderIndexes numbers the loaders of the lazily loaded bundles in
+// dependency order. Every projection names loaders and their autocmd groups
+// after these numbers.
+func lazyLoaderIndexes(bundles []graph.Bundle, lazy map[string]bool) map[string]int {
+	indexes := make(map[string]int, len(lazy))
+	for _, bundle := range bundles {
+		if lazy[bundle.ID] {
+			indexes[bundle.ID] = len(indexes) + 1
+		}
+	}
+	return indexes
+}
+
+// writeLazyLoaders appends the loader function of every lazily loaded
+// bundle to a packadd script, followed by the stubs that call it on first
+// use. A loader removes the stubs of its bundle, loads the lazily loaded
+// bundles it depends on, then the bundle itself.
+func (h *Hariti) writeLazyLoaders(w *strings.Builder, bundles []graph.Bundle, lazy map[string]bool) {
+	if len(lazy) == 0 {
+		return
+	}
+	w.WriteString(lazyHelpers)
+
+	indexes := lazyLoaderIndexes(bundles, lazy)
+	loaders := make(map[string]string, len(indexes))
+	for id, index := range indexes {
+		loaders[id] = fmt.Sprintf("lazy_%d", index)
+	}
+
+	for _, bundle := range bundles {
+		if !lazy[bundle.ID] {
+			continue
+		}
+		loader := loaders[bundle.ID]
+		h.logger.Debugf("bundle %s is loaded lazily by %s()", bundle.ID, loader)
+
+		fmt.Fprintf(w, "\n\" %s\nfunction! s:%s() abort\n", bundle.ID, loader)
+		fmt.Fprintf(w, "  if has_key(s:lazy_loaded, %s)\n    return\n  endif\n", vimString(bundle.ID))
+		fmt.Fprintf(w, "  let s:lazy_loaded[%s] = 1\n", vimString(bundle.ID))
+		for _, cmd := range bundle.OnCmd {
+			fmt.Fprintf(w, "  silent! delcommand %s\n", cmd)
+		}
+		for _, m := range bundle.OnMap {
+			for _, mode := range lazyMapModes {
+				fmt.Fprintf(w, "  silent! %sunmap %s\n", mode.mode, m)
+			}
+		}
+		if len(bundle.OnFt) > 0 || len(bundle.OnEvent) > 0 {
+			fmt.Fprintf(w, "  silent! autocmd! hariti_%s\n", loader)
+		}
+		for _, dep := range bundle.Dependencies {
+			if lazy[dep] {
+				fmt.Fprintf(w, "  call s:%s()\n", loaders[dep])
+			}
+		}
+		if bundle.Source.Type == graph.SourceTypeLocal {
+			localPath := filepath.ToSlash(bundle.Source.Path)
+			if afterPath := afterDir(bundle); afterPath != "" {
+				fmt.Fprintf(w, "  call s:add_rtp(%q, %q)\n", localPath, filepath.ToSlash(afterPath))
+			} else {
+				fmt.Fprintf(w, "  call s:add_rtp(%q, '')\n", localPath)
+			}
+			fmt.Fprintf(w, "  call s:lazy_source(%q)\n", localPath)
+		} else {
+			fmt.Fprintf(w, "  packadd %s\n", getExportedBundleDirName(bundle.ID))
+		}
+		w.WriteString("endfunction\n")
+
+		indent := ""
+		if bundle.EffectiveEnableIf != "" {
+			fmt.Fprintf(w, "if %s\n", bundle.EffectiveEnableIf)
+			indent = "  "
+		}
+		for _, cmd := range bundle.OnCmd {
+			fmt.Fprintf(w, "%scommand! -nargs=* -range -bang -complete=file %s call s:lazy_cmd(function('s:%s'), %s, '<bang>', <range>, <line1>, <line2>, <q-args>)\n", indent, cmd, loader, vimString(cmd))
+		}
+		for _, m := range bundle.OnMap {
+			// <lt> keeps the key notation from being translated, so that
+			// lazy_map receives it as text
+			notation := vimString(strings.ReplaceAll(m, "<", "<lt>"))
+			for _, mode := range lazyMapModes {
+				fmt.Fprintf(w, "%s%snoremap <silent> %s :<C-U>call <SID>lazy_map(function('<SID>%s'), %s, '%s')<CR>\n", indent, mode.mode, m, loader, notation, mode.prefix)
+			}
+		}
+		if len(bundle.OnFt) > 0 || len(bundle.OnEvent) > 0 {
+			fmt.Fprintf(w, "%saugroup hariti_%s\n", indent, loader)
+			fmt.Fprintf(w, "%s  autocmd!\n", indent)
+			if len(bundle.OnFt) > 0 {
+				fmt.Fprintf(w, "%s  autocmd FileType %s call s:lazy_ft(function('s:%s'))\n", indent, strings.Join(bundle.OnFt, ","), loader)
+			}
+			for _, event := range bundle.OnEvent {
+				fmt.Fprintf(w, "%s  autocmd %s * call s:lazy_event(function('s:%s'), %s)\n", indent, event, loader, vimString(event))
+			}
+			fmt.Fprintf(w, "%saugroup END\n", indent)
+		}
+		if bundle.EffectiveEnableIf != "" {
+			w.WriteString("endif\n")
+		}
+	}
+}
+
+// vimString quotes s as a Vim single-quoted string literal.
+func vimString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
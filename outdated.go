@@ -0,0 +1,143 @@
+package hariti
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kamichidu/go-hariti/graph"
+	"github.com/kamichidu/go-hariti/vcs"
+	"golang.org/x/sync/errgroup"
+)
+
+type OutdatedOptions struct {
+	Parallelism int
+}
+
+// OutdatedBundle describes a remote bundle whose locked revision differs from
+// the upstream tip of its tracked ref, or from the revision it is pinned to.
+type OutdatedBundle struct {
+	BundleID         string `json:"id"`
+	LockedRevision   string `json:"locked_revision"`
+	UpstreamRevision string `json:"upstream_revision"`
+	// Behind is the number of commits between the locked and the upstream
+	// revision, or -1 when the repository cache does not hold both.
+	Behind int `json:"behind"`
+}
+
+// Outdated reports the remote bundles whose upstream has moved past the
+// revision recorded in hariti.lock, and the bundles pinned to a revision other
+// than the locked one. It only queries remotes and never modifies the
+// repository cache or the lockfile, so the number of commits a bundle is
+// behind is only known when the cache already holds the upstream revision.
+func (h *Hariti) Outdated(ctx context.Context, g *graph.Graph, opts OutdatedOptions) ([]OutdatedBundle, error) {
+	rg := h.newRuntimeGraph(g)
+
+	parallelism := opts.Parallelism
+	if parallelism <= 0 {
+		parallelism = 8
+	}
+
+	lock, err := h.loadLockfile()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lockfile: %w", err)
+	}
+	entries := make(map[string]LockfileEntry, len(lock.Bundles))
+	for _, entry := range lock.Bundles {
+		entries[entry.ID] = entry
+	}
+
+	h.logger.Infof("outdated check started")
+
+	results := make([]*OutdatedBundle, len(rg.bundles))
+	sem := make(chan struct{}, parallelism)
+	eg, egCtx := errgroup.WithContext(ctx)
+
+	for i, bundle := range rg.bundles {
+		i, bundle := i, bundle
+		if bundle.Source.Type != graph.SourceTypeRemote {
+			continue
+		}
+
+		// Bundles without a lock entry for their current source are pending as a whole
+		lockedRevision := ""
+		if entry, exists := entries[bundle.ID]; exists && entry.Source == getSourceString(bundle) {
+			lockedRevision = entry.Revision
+		}
+
+		eg.Go(func() error {
+			select {
+			case sem <- struct{}{}:
+			case <-egCtx.Done():
+				return egCtx.Err()
+			}
+			defer func() {
+				<-sem
+			}()
+
+			result, err := h.checkOutdated(egCtx, bundle, lockedRevision)
+			if err != nil {
+				return err
+			}
+			results[i] = result
+			return nil
+		})
+	}
+
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+
+	outdated := make([]OutdatedBundle, 0, len(results))
+	for _, result := range results {
+		if result != nil {
+			outdated = append(outdated, *result)
+		}
+	}
+
+	h.logger.Infof("outdated check completed: %d of %d bundles outdated", len(outdated), len(rg.bundles))
+
+	return outdated, nil
+}
+
+func (h *Hariti) checkOutdated(ctx context.Context, bundle graph.Bundle, lockedRevision string) (*OutdatedBundle, error) {
+	v := vcs.Detect(bundle.Source.URL)
+	if v == nil {
+		return nil, fmt.Errorf("failed to detect VCS for bundle %s with URL %s", bundle.ID, bundle.Source.URL)
+	}
+
+	var vcsOutput bytes.Buffer
+	vcsCtx := vcs.WithLogger(ctx, h.logger)
+	vcsCtx = vcs.WithWriter(vcsCtx, &vcsOutput)
+	vcsCtx = vcs.WithErrWriter(vcsCtx, &vcsOutput)
+
+	upstream, err := v.RemoteRevision(vcsCtx, bundle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query upstream revision for bundle %s: %w\n%s", bundle.ID, err, strings.TrimSpace(vcsOutput.String()))
+	}
+	h.logger.Debugf("resolved upstream revision for bundle %s to %s", bundle.ID, upstream)
+
+	if upstream == lockedRevision {
+		return nil, nil
+	}
+	// A pinned revision may be abbreviated, while the lockfile records it in full
+	if bundle.Ref.Type == graph.RefTypeRevision && lockedRevision != "" && strings.HasPrefix(lockedRevision, upstream) {
+		return nil, nil
+	}
+
+	behind := -1
+	if lockedRevision != "" {
+		behind, err = v.CountCommits(vcsCtx, bundle, lockedRevision, upstream)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count commits for bundle %s: %w", bundle.ID, err)
+		}
+	}
+
+	return &OutdatedBundle{
+		BundleID:         bundle.ID,
+		LockedRevision:   lockedRevision,
+		UpstreamRevision: upstream,
+		Behind:           behind,
+	}, nil
+}
@@ -0,0 +1,156 @@
+package hariti_test
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kamichidu/go-hariti"
+	"github.com/kamichidu/go-hariti/graph"
+	"github.com/kamichidu/go-hariti/vcs"
+)
+
+func TestHariti_Outdated(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	xdgHome := filepath.Join(tmpDir, "xdg_home")
+	_ = os.Setenv("XDG_DATA_HOME", xdgHome)
+	defer func() {
+		_ = os.Unsetenv("XDG_DATA_HOME")
+	}()
+
+	// 1. Setup mock Git remote repo with an annotated tag
+	remoteRepoDir := filepath.Join(tmpDir, "remote_repo")
+	if err := os.MkdirAll(remoteRepoDir, 0755); err != nil {
+		t.Fatalf("failed to create mock remote dir: %v", err)
+	}
+
+	_ = runGitCmdInDir(t, remoteRepoDir, "init", "--initial-branch=main")
+	_ = runGitCmdInDir(t, remoteRepoDir, "config", "user.email", "test@hariti.io")
+	_ = runGitCmdInDir(t, remoteRepoDir, "config", "user.name", "Test Hariti")
+	_ = runGitCmdInDir(t, remoteRepoDir, "commit", "--allow-empty", "-m", "initial commit")
+	_ = runGitCmdInDir(t, remoteRepoDir, "tag", "-a", "v1.0.0", "-m", "v1.0.0")
+	rev1 := runGitCmdInDir(t, remoteRepoDir, "rev-parse", "HEAD")
+
+	remoteURL, err := url.Parse("file://" + filepath.ToSlash(remoteRepoDir))
+	if err != nil {
+		t.Fatalf("failed to parse remote URL: %v", err)
+	}
+
+	cacheRepoPath := filepath.Join(xdgHome, "hariti", "repos", url.QueryEscape("my-tracking-plugin"))
+	g := &graph.Graph{
+		Bundles: []graph.Bundle{
+			{
+				ID: "my-tracking-plugin",
+				Source: graph.Source{
+					Type: graph.SourceTypeRemote,
+					URL:  remoteURL,
+					Path: cacheRepoPath,
+				},
+			},
+			{
+				ID: "my-tagged-plugin",
+				Source: graph.Source{
+					Type: graph.SourceTypeRemote,
+					URL:  remoteURL,
+					Path: filepath.Join(xdgHome, "hariti", "repos", url.QueryEscape("my-tagged-plugin")),
+				},
+				Ref: graph.Ref{Type: graph.RefTypeTag, Name: "v1.0.0"},
+			},
+			{
+				ID: "my-pinned-plugin",
+				Source: graph.Source{
+					Type: graph.SourceTypeRemote,
+					URL:  remoteURL,
+					Path: filepath.Join(xdgHome, "hariti", "repos", url.QueryEscape("my-pinned-plugin")),
+				},
+				Ref: graph.Ref{Type: graph.RefTypeRevision, Name: rev1[:12]},
+			},
+		},
+	}
+
+	cfg := &hariti.HaritiConfig{
+		Paths: hariti.Paths{
+			ConfigFile: filepath.Join(tmpDir, "hariti_home", "bundles.hariti"),
+			ConfigDir:  filepath.Join(tmpDir, "hariti_home"),
+			DataDir:    filepath.Join(xdgHome, "hariti"),
+		},
+		Writer:    io.Discard,
+		ErrWriter: io.Discard,
+	}
+	har := hariti.NewHariti(cfg)
+
+	ctx := context.Background()
+	ctx = vcs.WithWriter(ctx, io.Discard)
+	ctx = vcs.WithErrWriter(ctx, io.Discard)
+
+	if _, err := har.Sync(ctx, g, hariti.SyncOptions{}); err != nil {
+		t.Fatalf("initial Sync failed: %v", err)
+	}
+
+	// Step 1: Nothing is outdated right after sync
+	outdated, err := har.Outdated(ctx, g, hariti.OutdatedOptions{})
+	if err != nil {
+		t.Fatalf("Outdated failed: %v", err)
+	}
+	if len(outdated) != 0 {
+		t.Errorf("expected no outdated bundles, got %+v", outdated)
+	}
+
+	// Step 2: Advance remote by 2 commits; only the tracking bundle is outdated,
+	// the pinned one stays at its abbreviated pin
+	_ = runGitCmdInDir(t, remoteRepoDir, "commit", "--allow-empty", "-m", "remote advance commit 1")
+	_ = runGitCmdInDir(t, remoteRepoDir, "commit", "--allow-empty", "-m", "remote advance commit 2")
+	rev3 := runGitCmdInDir(t, remoteRepoDir, "rev-parse", "HEAD")
+
+	outdated, err = har.Outdated(ctx, g, hariti.OutdatedOptions{})
+	if err != nil {
+		t.Fatalf("Outdated failed: %v", err)
+	}
+	expected := hariti.OutdatedBundle{
+		BundleID:         "my-tracking-plugin",
+		LockedRevision:   rev1,
+		UpstreamRevision: rev3,
+		Behind:           -1,
+	}
+	if len(outdated) != 1 || outdated[0] != expected {
+		t.Fatalf("expected %+v, got %+v", expected, outdated)
+	}
+
+	// The repository cache must be left untouched
+	if head := runGitCmdInDir(t, cacheRepoPath, "rev-parse", "HEAD"); head != rev1 {
+		t.Errorf("expected cache HEAD to stay at %s, got %s", rev1, head)
+	}
+	if ref := runGitCmdInDir(t, cacheRepoPath, "rev-parse", "origin/main"); ref != rev1 {
+		t.Errorf("expected cache remote ref to stay at %s, got %s", rev1, ref)
+	}
+
+	// Step 3: A pin moved away from the locked revision is reported until synced
+	g.Bundles[2].Ref.Name = rev3
+	outdated, err = har.Outdated(ctx, g, hariti.OutdatedOptions{})
+	if err != nil {
+		t.Fatalf("Outdated failed: %v", err)
+	}
+	expectedPinned := hariti.OutdatedBundle{
+		BundleID:         "my-pinned-plugin",
+		LockedRevision:   rev1,
+		UpstreamRevision: rev3,
+		Behind:           -1,
+	}
+	if len(outdated) != 2 || outdated[1] != expectedPinned {
+		t.Fatalf("expected %+v and %+v, got %+v", expected, expectedPinned, outdated)
+	}
+
+	// Step 4: Once the cache holds the upstream commits, the distance is reported
+	_ = runGitCmdInDir(t, cacheRepoPath, "fetch", "--all")
+	outdated, err = har.Outdated(ctx, g, hariti.OutdatedOptions{})
+	if err != nil {
+		t.Fatalf("Outdated failed: %v", err)
+	}
+	if len(outdated) != 2 || outdated[0].Behind != 2 || outdated[1].Behind != -1 {
+		t.Errorf("expected my-tracking-plugin to be 2 commits behind, got %+v", outdated)
+	}
+}
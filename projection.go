@@ -0,0 +1,167 @@
+package hariti
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/kamichidu/go-hariti/graph"
+)
+
+// Projection names a runtime script Deploy generates in a generation.
+type Projection string
+
+const (
+	// ProjectionVim generates packadd.vim. It is always generated, since
+	// inspection, diff and rollback read it.
+	ProjectionVim Projection = "vim"
+	// ProjectionLua generates packadd.lua for Neovim.
+	ProjectionLua Projection = "lua"
+	// ProjectionStatic generates packadd.static.vim, which sets up the
+	// bundles without a condition from a runtimepath and a list of scripts
+	// computed at deploy time.
+	ProjectionStatic Projection = "static"
+)
+
+// projectionOrder is the order projections are generated and recorded in.
+var projectionOrder = []Projection{ProjectionVim, ProjectionLua, ProjectionStatic}
+
+// FileName returns the name of the script the projection generates.
+func (p Projection) FileName() string {
+	switch p {
+	case ProjectionLua:
+		return "packadd.lua"
+	case ProjectionStatic:
+		return "packadd.static.vim"
+	default:
+		return "packadd.vim"
+	}
+}
+
+// normalizeProjections validates the projections of a deploy and returns
+// them without duplicates, in generation order, with ProjectionVim added.
+func normalizeProjections(projections []Projection) ([]Projection, error) {
+	for _, p := range projections {
+		if !slices.Contains(projectionOrder, p) {
+			return nil, fmt.Errorf("invalid projection: %s", p)
+		}
+	}
+	normalized := make([]Projection, 0, len(projectionOrder))
+	for _, p := range projectionOrder {
+		if p == ProjectionVim || slices.Contains(projections, p) {
+			normalized = append(normalized, p)
+		}
+	}
+	return normalized, nil
+}
+
+// writeProjections generates the runtime scripts of a generation from its
+// bundles, which must be in dependency order.
+func (h *Hariti) writeProjections(genDir string, bundles []graph.Bundle, projections []Projection) error {
+	lazy := lazyBundles(bundles)
+	for _, bundle := range bundles {
+		if bundle.IsLazy() && !lazy[bundle.ID] {
+			h.logger.Infof("bundle %s is loaded at startup, since a bundle loaded at startup depends on it", bundle.ID)
+		}
+	}
+
+	for _, p := range projections {
+		var content string
+		var err error
+		switch p {
+		case ProjectionVim:
+			content = h.vimProjection(bundles, lazy)
+		case ProjectionLua:
+			content, err = h.luaProjection(bundles, lazy)
+		case ProjectionStatic:
+			content, err = h.staticProjection(genDir, bundles, lazy)
+		}
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(genDir, p.FileName())
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", p.FileName(), err)
+		}
+		h.logger.Debugf("generated file path: %s", path)
+	}
+	return nil
+}
+
+// afterDir returns the after directory of a local bundle, or an empty string
+// when it has none.
+func afterDir(bundle graph.Bundle) string {
+	afterPath := filepath.Join(bundle.Source.Path, "after")
+	if _, err := os.Stat(afterPath); err != nil {
+		return ""
+	}
+	return afterPath
+}
+
+// vimAddRtp adds a directory to &runtimepath before the first after
+// directory, and its own after directory, if any, at the end.
+const vimAddRtp = `function! s:add_rtp(path, after_path) abort
+  let l:rtps = split(&runtimepath, ',')
+  let l:idx = -1
+  for l:i in range(len(l:rtps))
+    if fnamemodify(l:rtps[l:i], ':t') ==# 'after'
+      let l:idx = l:i
+      break
+    endif
+  endfor
+  if l:idx >= 0
+    call insert(l:rtps, a:path, l:idx)
+  else
+    call add(l:rtps, a:path)
+  endif
+  if a:after_path !=# ''
+    call add(l:rtps, a:after_path)
+  endif
+  let &runtimepath = join(l:rtps, ',')
+endfunction
+
+`
+
+// vimProjection generates packadd.vim, which adds every bundle loaded at
+// startup in turn and defines the loaders of the lazily loaded ones.
+func (h *Hariti) vimProjection(bundles []graph.Bundle, lazy map[string]bool) string {
+	var packaddContent strings.Builder
+	packaddContent.WriteString(vimAddRtp)
+	for _, bundle := range bundles {
+		if !lazy[bundle.ID] {
+			writeVimBundle(&packaddContent, bundle)
+		}
+	}
+	h.writeLazyLoaders(&packaddContent, bundles, lazy)
+	return packaddContent.String()
+}
+
+// writeVimBundle appends the lines adding a bundle at startup to a packadd
+// script, guarded by its effective condition.
+func writeVimBundle(w *strings.Builder, bundle graph.Bundle) {
+	if bundle.Source.Type == graph.SourceTypeLocal {
+		localPath := bundle.Source.Path
+		if bundle.EffectiveEnableIf != "" {
+			if afterPath := afterDir(bundle); afterPath != "" {
+				fmt.Fprintf(w, "if %s\n  call s:add_rtp(%q, %q)\nendif\n", bundle.EffectiveEnableIf, filepath.ToSlash(localPath), filepath.ToSlash(afterPath))
+			} else {
+				fmt.Fprintf(w, "if %s\n  call s:add_rtp(%q, '')\nendif\n", bundle.EffectiveEnableIf, filepath.ToSlash(localPath))
+			}
+		} else {
+			if afterPath := afterDir(bundle); afterPath != "" {
+				fmt.Fprintf(w, "call s:add_rtp(%q, %q)\n", filepath.ToSlash(localPath), filepath.ToSlash(afterPath))
+			} else {
+				fmt.Fprintf(w, "call s:add_rtp(%q, '')\n", filepath.ToSlash(localPath))
+			}
+		}
+	} else {
+		bundleName := getExportedBundleDirName(bundle.ID)
+		if bundle.EffectiveEnableIf != "" {
+			fmt.Fprintf(w, "if %s\n  packadd %s\nendif\n", bundle.EffectiveEnableIf, bundleName)
+		} else {
+			fmt.Fprintf(w, "packadd %s\n", bundleName)
+		}
+	}
+}
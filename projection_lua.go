@@ -0,0 +1,248 @@
+package hariti
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/kamichidu/go-hariti/graph"
+)
+
+// luaAddRtp mirrors s:add_rtp of packadd.vim.
+const luaAddRtp = `local function add_rtp(path, after_path)
+  local rtps = vim.o.runtimepath == '' and {} or vim.split(vim.o.runtimepath, ',', { plain = true })
+  local idx
+  for i, rtp in ipairs(rtps) do
+    if vim.fn.fnamemodify(rtp, ':t') == 'after' then
+      idx = i
+      break
+    end
+  end
+  if idx then
+    table.insert(rtps, idx, path)
+  else
+    table.insert(rtps, path)
+  end
+  if after_path ~= '' then
+    table.insert(rtps, after_path)
+  end
+  vim.o.runtimepath = table.concat(rtps, ',')
+end
+
+`
+
+// luaLazyHelpers mirror lazyHelpers of packadd.vim. Mappings run their Lua
+// callback without leaving the current mode, so only normal mode needs the
+// count and register replayed.
+const luaLazyHelpers = `
+local lazy_loaded = {}
+local loaders = {}
+
+local function lazy_source(path)
+  local function source(pattern)
+    for _, ext in ipairs({ '.vim', '.lua' }) do
+      for _, file in ipairs(vim.fn.glob(path .. pattern .. ext, true, true)) do
+        vim.cmd('source ' .. vim.fn.fnameescape(file))
+      end
+    end
+  end
+  vim.cmd('augroup filetypedetect')
+  source('/ftdetect/**/*')
+  vim.cmd('augroup END')
+  source('/plugin/**/*')
+  source('/after/plugin/**/*')
+end
+
+local function lazy_cmd(load, cmd, opts)
+  load()
+  local range = ''
+  if opts.range == 1 then
+    range = tostring(opts.line1)
+  elseif opts.range == 2 then
+    range = opts.line1 .. ',' .. opts.line2
+  end
+  vim.cmd(range .. cmd .. (opts.bang and '!' or '') .. ' ' .. opts.args)
+end
+
+local function lazy_map(load, lhs)
+  load()
+  local prefix = ''
+  if vim.fn.mode(1) == 'n' then
+    prefix = (vim.v.count > 0 and vim.v.count or '') .. '"' .. vim.v.register
+  end
+  -- Insert before any typeahead, prefix first
+  vim.api.nvim_feedkeys(vim.api.nvim_replace_termcodes(lhs, true, true, true), 'i', false)
+  vim.api.nvim_feedkeys(prefix, 'ni', false)
+end
+
+local function lazy_ft(load)
+  load()
+  vim.cmd('doautocmd <nomodeline> FileType ' .. vim.bo.filetype)
+end
+
+local function lazy_event(load, event)
+  load()
+  vim.cmd('doautocmd <nomodeline> ' .. event)
+end
+`
+
+// luaConditions returns the condition guarding each bundle in packadd.lua:
+// the Lua conditions of the bundle's condition chain, combined like
+// EffectiveEnableIf. The Lua condition of a bundle is its enable_if_lua, or
+// else its enable_if evaluated through vim.fn.eval.
+func luaConditions(bundles []graph.Bundle) (map[string]string, error) {
+	chains, err := graph.Graph{Bundles: bundles}.ConditionChains()
+	if err != nil {
+		return nil, err
+	}
+	own := make(map[string]string, len(bundles))
+	for _, bundle := range bundles {
+		switch {
+		case bundle.EnableIfLua != "":
+			own[bundle.ID] = bundle.EnableIfLua
+		case bundle.EnableIf != "":
+			own[bundle.ID] = fmt.Sprintf("vim.fn.eval(%s) == 1", luaString("("+bundle.EnableIf+") ? 1 : 0"))
+		}
+	}
+
+	combined := make(map[string]string, len(bundles))
+	for _, bundle := range bundles {
+		var conds []string
+		seen := make(map[string]bool)
+		for _, id := range chains[bundle.ID] {
+			if cond := own[id]; cond != "" && !seen[cond] {
+				seen[cond] = true
+				conds = append(conds, cond)
+			}
+		}
+		if len(conds) == 1 {
+			combined[bundle.ID] = conds[0]
+		} else if len(conds) > 1 {
+			parts := make([]string, len(conds))
+			for i, cond := range conds {
+				parts[i] = "(" + cond + ")"
+			}
+			combined[bundle.ID] = strings.Join(parts, " and ")
+		}
+	}
+	return combined, nil
+}
+
+// luaProjection generates packadd.lua, which loads the same bundles as
+// packadd.vim through the Neovim Lua API.
+func (h *Hariti) luaProjection(bundles []graph.Bundle, lazy map[string]bool) (string, error) {
+	conditions, err := luaConditions(bundles)
+	if err != nil {
+		return "", err
+	}
+
+	var w strings.Builder
+	w.WriteString(luaAddRtp)
+	for _, bundle := range bundles {
+		if lazy[bundle.ID] {
+			continue
+		}
+		indent := ""
+		if cond := conditions[bundle.ID]; cond != "" {
+			fmt.Fprintf(&w, "if %s then\n", cond)
+			indent = "  "
+		}
+		if bundle.Source.Type == graph.SourceTypeLocal {
+			fmt.Fprintf(&w, "%sadd_rtp(%s, %s)\n", indent, luaString(filepath.ToSlash(bundle.Source.Path)), luaString(filepath.ToSlash(afterDir(bundle))))
+		} else {
+			fmt.Fprintf(&w, "%svim.cmd(%s)\n", indent, luaString("packadd "+getExportedBundleDirName(bundle.ID)))
+		}
+		if indent != "" {
+			w.WriteString("end\n")
+		}
+	}
+
+	if len(lazy) == 0 {
+		return w.String(), nil
+	}
+	w.WriteString(luaLazyHelpers)
+	loaders := lazyLoaderIndexes(bundles, lazy)
+	for _, bundle := range bundles {
+		if !lazy[bundle.ID] {
+			continue
+		}
+		loader := loaders[bundle.ID]
+		group := luaString(fmt.Sprintf("hariti_lazy_%d", loader))
+
+		fmt.Fprintf(&w, "\n-- %s\nloaders[%d] = function()\n", bundle.ID, loader)
+		fmt.Fprintf(&w, "  if lazy_loaded[%s] then\n    return\n  end\n", luaString(bundle.ID))
+		fmt.Fprintf(&w, "  lazy_loaded[%s] = true\n", luaString(bundle.ID))
+		for _, cmd := range bundle.OnCmd {
+			fmt.Fprintf(&w, "  pcall(vim.api.nvim_del_user_command, %s)\n", luaString(cmd))
+		}
+		for _, m := range bundle.OnMap {
+			fmt.Fprintf(&w, "  pcall(vim.keymap.del, { 'n', 'x', 'o' }, %s)\n", luaString(m))
+		}
+		if len(bundle.OnFt) > 0 || len(bundle.OnEvent) > 0 {
+			fmt.Fprintf(&w, "  pcall(vim.api.nvim_clear_autocmds, { group = %s })\n", group)
+		}
+		for _, dep := range bundle.Dependencies {
+			if lazy[dep] {
+				fmt.Fprintf(&w, "  loaders[%d]()\n", loaders[dep])
+			}
+		}
+		if bundle.Source.Type == graph.SourceTypeLocal {
+			localPath := luaString(filepath.ToSlash(bundle.Source.Path))
+			fmt.Fprintf(&w, "  add_rtp(%s, %s)\n", localPath, luaString(filepath.ToSlash(afterDir(bundle))))
+			fmt.Fprintf(&w, "  lazy_source(%s)\n", localPath)
+		} else {
+			fmt.Fprintf(&w, "  vim.cmd(%s)\n", luaString("packadd "+getExportedBundleDirName(bundle.ID)))
+		}
+		w.WriteString("end\n")
+
+		indent := ""
+		if cond := conditions[bundle.ID]; cond != "" {
+			fmt.Fprintf(&w, "if %s then\n", cond)
+			indent = "  "
+		}
+		for _, cmd := range bundle.OnCmd {
+			fmt.Fprintf(&w, "%svim.api.nvim_create_user_command(%s, function(opts) lazy_cmd(loaders[%d], %s, opts) end, { nargs = '*', range = true, bang = true, complete = 'file' })\n", indent, luaString(cmd), loader, luaString(cmd))
+		}
+		for _, m := range bundle.OnMap {
+			fmt.Fprintf(&w, "%svim.keymap.set({ 'n', 'x', 'o' }, %s, function() lazy_map(loaders[%d], %s) end, { silent = true })\n", indent, luaString(m), loader, luaString(m))
+		}
+		if len(bundle.OnFt) > 0 || len(bundle.OnEvent) > 0 {
+			fmt.Fprintf(&w, "%svim.api.nvim_create_augroup(%s, { clear = true })\n", indent, group)
+			if len(bundle.OnFt) > 0 {
+				fts := make([]string, len(bundle.OnFt))
+				for i, ft := range bundle.OnFt {
+					fts[i] = luaString(ft)
+				}
+				fmt.Fprintf(&w, "%svim.api.nvim_create_autocmd('FileType', { group = %s, pattern = { %s }, callback = function() lazy_ft(loaders[%d]) end })\n", indent, group, strings.Join(fts, ", "), loader)
+			}
+			for _, event := range bundle.OnEvent {
+				fmt.Fprintf(&w, "%svim.api.nvim_create_autocmd(%s, { group = %s, pattern = '*', callback = function() lazy_event(loaders[%d], %s) end })\n", indent, luaString(event), group, loader, luaString(event))
+			}
+		}
+		if indent != "" {
+			w.WriteString("end\n")
+		}
+	}
+	return w.String(), nil
+}
+
+// luaString quotes s as a Lua string literal.
+func luaString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' || c == '"':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c == '\n':
+			b.WriteString(`\n`)
+		case c < 0x20 || c == 0x7f:
+			fmt.Fprintf(&b, `\%03d`, c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,183 @@
+package hariti
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kamichidu/go-hariti/graph"
+)
+
+// staticBundle is a bundle packadd.static.vim adds at startup: its
+// runtimepath entries, and for remote bundles the scripts packadd would source,
+// as paths inside the published generation.
+type staticBundle struct {
+	remote   bool
+	path     string
+	after    string
+	plugins  []string
+	ftdetect []string
+}
+
+// vimSpliceRtp puts the entries of s:rtp into &runtimepath before its first
+// after directory and appends those of s:after, as s:add_rtp would one bundle
+// at a time.
+const vimSpliceRtp = `let s:idx = match(&runtimepath, '\v%(^|,)\zs[^,]*[/\\]after%(,|$)')
+let s:idx = s:idx < 0 ? len(&runtimepath) + 1 : s:idx
+let &runtimepath = join(filter([strpart(&runtimepath, 0, s:idx - 1)] + s:rtp + [strpart(&runtimepath, s:idx)] + s:after, 'v:val !=# ""'), ',')
+unlet s:rtp s:after s:idx
+`
+
+// staticProjection generates packadd.static.vim. The runtimepath entries of
+// the bundles loaded at startup are computed at deploy time and put into
+// &runtimepath by a single assignment, where local bundles with an effective
+// condition are only added to the entries under their condition. The plugin
+// and ftdetect scripts of remote bundles without a condition are sourced from
+// a list collected at deploy time instead of by packadd. Conditional remote
+// bundles are still added by packadd, and lazily loaded bundles are set up as
+// in packadd.vim.
+func (h *Hariti) staticProjection(genDir string, bundles []graph.Bundle, lazy map[string]bool) (string, error) {
+	// Scripts are sourced from where the generation is published
+	publishedDir := filepath.Join(h.GenerationsDir(), filepath.Base(genDir))
+
+	var static []staticBundle
+	var conditional, packadds []graph.Bundle
+	for _, bundle := range bundles {
+		switch {
+		case lazy[bundle.ID]:
+		case bundle.EffectiveEnableIf == "":
+			sb, err := newStaticBundle(genDir, publishedDir, bundle)
+			if err != nil {
+				return "", err
+			}
+			static = append(static, sb)
+		case bundle.Source.Type == graph.SourceTypeLocal:
+			conditional = append(conditional, bundle)
+		default:
+			packadds = append(packadds, bundle)
+		}
+	}
+
+	var w strings.Builder
+	if len(static) > 0 || len(conditional) > 0 {
+		// Where packadd.vim would put them: bundles before the first after
+		// directory, followed by the after directories packadd inserts there
+		// in turn, while s:add_rtp appends those of local bundles. Conditional
+		// local bundles follow the unconditional ones, which never depend on
+		// them
+		var paths, packAfters, afters, plugins, ftdetect []string
+		for _, sb := range static {
+			paths = append(paths, sb.path)
+			switch {
+			case sb.after == "":
+			case sb.remote:
+				packAfters = append([]string{sb.after}, packAfters...)
+			default:
+				afters = append(afters, sb.after)
+			}
+			plugins = append(plugins, sb.plugins...)
+			ftdetect = append(ftdetect, sb.ftdetect...)
+		}
+		fmt.Fprintf(&w, "let s:rtp = [%s]\n", vimStrings(paths))
+		fmt.Fprintf(&w, "let s:after = [%s]\n", vimStrings(afters))
+		for _, bundle := range conditional {
+			fmt.Fprintf(&w, "if %s\n  call add(s:rtp, %s)\n", bundle.EffectiveEnableIf, vimString(rtpEntry(bundle.Source.Path)))
+			if afterPath := afterDir(bundle); afterPath != "" {
+				fmt.Fprintf(&w, "  call add(s:after, %s)\n", vimString(rtpEntry(afterPath)))
+			}
+			w.WriteString("endif\n")
+		}
+		if len(packAfters) > 0 {
+			fmt.Fprintf(&w, "let s:rtp += [%s]\n", vimStrings(packAfters))
+		}
+		w.WriteString(vimSpliceRtp)
+		if len(plugins) > 0 {
+			fmt.Fprintf(&w, "for s:file in [%s]\n  execute 'source' fnameescape(s:file)\nendfor\n", vimStrings(plugins))
+		}
+		if len(ftdetect) > 0 {
+			// packadd only sources ftdetect scripts once filetype detection is
+			// enabled; otherwise enabling it finds them in &runtimepath
+			fmt.Fprintf(&w, "if exists('g:did_load_filetypes')\n  augroup filetypedetect\n    for s:file in [%s]\n      execute 'source' fnameescape(s:file)\n    endfor\n  augroup END\nendif\n", vimStrings(ftdetect))
+		}
+	}
+
+	for _, bundle := range packadds {
+		fmt.Fprintf(&w, "if %s\n  packadd %s\nendif\n", bundle.EffectiveEnableIf, getExportedBundleDirName(bundle.ID))
+	}
+
+	if len(lazy) > 0 {
+		w.WriteString("\n")
+		w.WriteString(vimAddRtp)
+		h.writeLazyLoaders(&w, bundles, lazy)
+	}
+	return w.String(), nil
+}
+
+// newStaticBundle collects the runtimepath entries of a bundle and, for a
+// remote bundle, the scripts packadd would source from its directory. The
+// directory is read in the staged generation and named by its path in the
+// published one.
+func newStaticBundle(genDir, publishedDir string, bundle graph.Bundle) (staticBundle, error) {
+	if bundle.Source.Type == graph.SourceTypeLocal {
+		sb := staticBundle{path: rtpEntry(bundle.Source.Path)}
+		if afterPath := afterDir(bundle); afterPath != "" {
+			sb.after = rtpEntry(afterPath)
+		}
+		return sb, nil
+	}
+
+	rel := filepath.Join("pack", "hariti", "opt", getExportedBundleDirName(bundle.ID))
+	bundleDir := filepath.Join(genDir, rel)
+	publishedBundleDir := filepath.Join(publishedDir, rel)
+	sb := staticBundle{remote: true, path: rtpEntry(publishedBundleDir)}
+	if info, err := os.Stat(filepath.Join(bundleDir, "after")); err == nil && info.IsDir() {
+		sb.after = rtpEntry(filepath.Join(publishedBundleDir, "after"))
+	}
+
+	// The same scripts as packadd: plugin/**/*.vim and ftdetect/*.vim
+	pluginDir := filepath.Join(bundleDir, "plugin")
+	err := filepath.WalkDir(pluginDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(p, ".vim") {
+			relPath, err := filepath.Rel(bundleDir, p)
+			if err != nil {
+				return err
+			}
+			sb.plugins = append(sb.plugins, filepath.ToSlash(filepath.Join(publishedBundleDir, relPath)))
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return staticBundle{}, fmt.Errorf("failed to list plugin scripts of bundle %s: %w", bundle.ID, err)
+	}
+	matches, err := filepath.Glob(filepath.Join(bundleDir, "ftdetect", "*.vim"))
+	if err != nil {
+		return staticBundle{}, fmt.Errorf("failed to list ftdetect scripts of bundle %s: %w", bundle.ID, err)
+	}
+	sort.Strings(matches)
+	for _, match := range matches {
+		sb.ftdetect = append(sb.ftdetect, filepath.ToSlash(filepath.Join(publishedBundleDir, "ftdetect", filepath.Base(match))))
+	}
+	return sb, nil
+}
+
+// rtpEntry formats p as a runtimepath entry, escaping the commas that would
+// otherwise separate it.
+func rtpEntry(p string) string {
+	return strings.ReplaceAll(filepath.ToSlash(p), ",", "\\,")
+}
+
+// vimStrings quotes each of ss as a Vim string literal and joins them as the
+// items of a list literal.
+func vimStrings(ss []string) string {
+	quoted := make([]string, len(ss))
+	for i, s := range ss {
+		quoted[i] = vimString(s)
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,96 @@
+package hariti
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// RollbackOptions holds options for Rollback.
+type RollbackOptions struct {
+	// RestoreLock replaces the project hariti.lock with the lock snapshot of
+	// the target generation.
+	RestoreLock bool
+}
+
+// Rollback points the current link at a previously deployed generation and
+// returns its ID. The target is a generation ID, a label, or "-N", the Nth
+// generation before the current one in creation order; an empty target
+// means "-1".
+func (h *Hariti) Rollback(target string, opts RollbackOptions) (string, error) {
+	genID, err := h.resolveRollbackTarget(target)
+	if err != nil {
+		return "", err
+	}
+
+	_, lock, err := h.readGeneration(genID)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(filepath.Join(h.GenerationsDir(), genID, "packadd.vim")); err != nil {
+		return "", fmt.Errorf("generation %s is incomplete: %w", genID, err)
+	}
+
+	if opts.RestoreLock {
+		data, err := os.ReadFile(filepath.Join(h.GenerationsDir(), genID, "lock.json"))
+		if err != nil {
+			return "", fmt.Errorf("failed to read lock snapshot of generation %s: %w", genID, err)
+		}
+		if err := h.replaceLockfile(data); err != nil {
+			return "", fmt.Errorf("failed to restore lockfile: %w", err)
+		}
+		h.logger.Infof("lockfile restored from generation %s (%d bundles)", genID, len(lock.Bundles))
+	}
+
+	current, err := h.CurrentGeneration()
+	if err != nil {
+		return "", err
+	}
+	if current == genID {
+		h.logger.Infof("generation %s is already current", h.describeGeneration(genID))
+		return genID, nil
+	}
+
+	if err := h.switchCurrentGeneration(genID); err != nil {
+		return "", err
+	}
+	h.logger.Infof("current generation switched to: %s", h.describeGeneration(genID))
+
+	return genID, nil
+}
+
+// resolveRollbackTarget turns a rollback target into a generation ID.
+func (h *Hariti) resolveRollbackTarget(target string) (string, error) {
+	if target == "" {
+		target = "-1"
+	}
+	if !strings.HasPrefix(target, "-") {
+		return h.ResolveGeneration(target)
+	}
+
+	steps, err := strconv.Atoi(target[1:])
+	if err != nil || steps < 1 {
+		return "", fmt.Errorf("invalid rollback target %q: expected a generation ID, a label or -N", target)
+	}
+
+	generations, err := h.Generations()
+	if err != nil {
+		return "", err
+	}
+	currentIdx := -1
+	for i, gen := range generations {
+		if gen.Current {
+			currentIdx = i
+			break
+		}
+	}
+	if currentIdx < 0 {
+		return "", fmt.Errorf("cannot resolve %s: no current generation", target)
+	}
+	if currentIdx-steps < 0 {
+		return "", fmt.Errorf("cannot resolve %s: only %d generations precede the current one", target, currentIdx)
+	}
+	return generations[currentIdx-steps].ID, nil
+}
@@ -0,0 +1,192 @@
+package hariti_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kamichidu/go-hariti"
+	"github.com/kamichidu/go-hariti/graph"
+	"github.com/kamichidu/go-hariti/vcs"
+	_ "github.com/kamichidu/go-hariti/vcs/git"
+)
+
+func TestHariti_Rollback(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	xdgHome := filepath.Join(tmpDir, "xdg_home")
+	_ = os.Setenv("XDG_DATA_HOME", xdgHome)
+	defer func() {
+		_ = os.Unsetenv("XDG_DATA_HOME")
+	}()
+
+	// 1. Setup mock remote repository
+	remoteRepoDir := filepath.Join(tmpDir, "remote_repo")
+	if err := os.MkdirAll(remoteRepoDir, 0755); err != nil {
+		t.Fatalf("failed to create mock remote dir: %v", err)
+	}
+
+	_ = runGitCmdInDir(t, remoteRepoDir, "init", "--initial-branch=main")
+	_ = runGitCmdInDir(t, remoteRepoDir, "config", "user.email", "test@hariti.io")
+	_ = runGitCmdInDir(t, remoteRepoDir, "config", "user.name", "Test Hariti")
+
+	remoteURL, err := url.Parse("file://" + filepath.ToSlash(remoteRepoDir))
+	if err != nil {
+		t.Fatalf("failed to parse remote URL: %v", err)
+	}
+
+	g := &graph.Graph{
+		Bundles: []graph.Bundle{
+			{
+				ID: "my/remote-plugin",
+				Source: graph.Source{
+					Type: graph.SourceTypeRemote,
+					URL:  remoteURL,
+					Path: filepath.Join(xdgHome, "hariti", "repos", url.QueryEscape("my/remote-plugin")),
+				},
+			},
+		},
+	}
+
+	cfg := &hariti.HaritiConfig{
+		Paths: hariti.Paths{
+			ConfigFile: filepath.Join(tmpDir, "hariti_home", "bundles.hariti"),
+			ConfigDir:  filepath.Join(tmpDir, "hariti_home"),
+			DataDir:    filepath.Join(xdgHome, "hariti"),
+		},
+		Writer:    io.Discard,
+		ErrWriter: io.Discard,
+	}
+	har := hariti.NewHariti(cfg)
+
+	ctx := context.Background()
+	ctx = vcs.WithWriter(ctx, io.Discard)
+	ctx = vcs.WithErrWriter(ctx, io.Discard)
+
+	// Step 1: Rolling back before anything is deployed fails
+	if _, err := har.Rollback("", hariti.RollbackOptions{}); err == nil {
+		t.Error("expected rollback without current generation to fail, got nil")
+	}
+
+	// Step 2: Deploy three generations
+	var genIDs, revs []string
+	for _, msg := range []string{"first", "second", "third"} {
+		_ = runGitCmdInDir(t, remoteRepoDir, "commit", "--allow-empty", "-m", msg)
+		revs = append(revs, runGitCmdInDir(t, remoteRepoDir, "rev-parse", "HEAD"))
+		if _, err := har.Sync(ctx, g, hariti.SyncOptions{}); err != nil {
+			t.Fatalf("Sync failed: %v", err)
+		}
+		genID, err := har.Deploy(ctx, g, hariti.DeployOptions{})
+		if err != nil {
+			t.Fatalf("Deploy failed: %v", err)
+		}
+		genIDs = append(genIDs, genID)
+	}
+
+	assertCurrent := func(want string) {
+		t.Helper()
+		current, err := har.CurrentGeneration()
+		if err != nil {
+			t.Fatalf("CurrentGeneration failed: %v", err)
+		}
+		if current != want {
+			t.Errorf("expected current generation %s, got %s", want, current)
+		}
+	}
+
+	// Step 3: Without a target, roll back to the previous generation
+	genID, err := har.Rollback("", hariti.RollbackOptions{})
+	if err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	if genID != genIDs[1] {
+		t.Errorf("expected rollback to %s, got %s", genIDs[1], genID)
+	}
+	assertCurrent(genIDs[1])
+
+	// Step 4: -N counts back from the current generation
+	if _, err := har.Rollback("-1", hariti.RollbackOptions{}); err != nil {
+		t.Fatalf("Rollback -1 failed: %v", err)
+	}
+	assertCurrent(genIDs[0])
+
+	if _, err := har.Rollback("-1", hariti.RollbackOptions{}); err == nil {
+		t.Error("expected rollback past the oldest generation to fail, got nil")
+	}
+	assertCurrent(genIDs[0])
+
+	// Step 5: An explicit ID can roll forward, too
+	if _, err := har.Rollback(genIDs[2], hariti.RollbackOptions{}); err != nil {
+		t.Fatalf("Rollback to %s failed: %v", genIDs[2], err)
+	}
+	assertCurrent(genIDs[2])
+
+	// Step 6: Invalid, unknown and incomplete targets leave current untouched
+	if _, err := har.Rollback("-x", hariti.RollbackOptions{}); err == nil {
+		t.Error("expected invalid target to fail, got nil")
+	}
+	if _, err := har.Rollback("no-such-generation", hariti.RollbackOptions{}); !errors.Is(err, hariti.ErrGenerationNotFound) {
+		t.Errorf("expected ErrGenerationNotFound, got: %v", err)
+	}
+	incompleteDir := filepath.Join(har.GenerationsDir(), "incomplete")
+	if err := os.MkdirAll(incompleteDir, 0755); err != nil {
+		t.Fatalf("failed to create incomplete generation: %v", err)
+	}
+	for _, name := range []string{"metadata.json", "lock.json"} {
+		data, err := os.ReadFile(filepath.Join(har.GenerationsDir(), genIDs[0], name))
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", name, err)
+		}
+		if err := os.WriteFile(filepath.Join(incompleteDir, name), data, 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	if _, err := har.Rollback("incomplete", hariti.RollbackOptions{}); err == nil {
+		t.Error("expected rollback to an incomplete generation to fail, got nil")
+	}
+	assertCurrent(genIDs[2])
+	if err := os.RemoveAll(incompleteDir); err != nil {
+		t.Fatalf("failed to remove incomplete generation: %v", err)
+	}
+
+	// Step 7: --restore-lock restores the generation's lock snapshot
+	if _, err := har.Rollback("-2", hariti.RollbackOptions{RestoreLock: true}); err != nil {
+		t.Fatalf("Rollback -2 failed: %v", err)
+	}
+	assertCurrent(genIDs[0])
+
+	readRevision := func(path string) string {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed to read lockfile: %v", err)
+		}
+		var lock hariti.Lockfile
+		if err := json.Unmarshal(data, &lock); err != nil {
+			t.Fatalf("failed to parse lockfile: %v", err)
+		}
+		if len(lock.Bundles) != 1 {
+			t.Fatalf("expected 1 locked bundle, got %+v", lock)
+		}
+		return lock.Bundles[0].Revision
+	}
+	if rev := readRevision(har.LockfilePath()); rev != revs[0] {
+		t.Errorf("expected restored lockfile at %s, got %s", revs[0], rev)
+	}
+	if rev := readRevision(har.PreviousLockfilePath()); rev != revs[2] {
+		t.Errorf("expected previous lockfile at %s, got %s", revs[2], rev)
+	}
+
+	// Step 8: Restoring the same lock snapshot again keeps the previous lockfile
+	if _, err := har.Rollback(genIDs[0], hariti.RollbackOptions{RestoreLock: true}); err != nil {
+		t.Fatalf("Rollback %s failed: %v", genIDs[0], err)
+	}
+	if rev := readRevision(har.PreviousLockfilePath()); rev != revs[2] {
+		t.Errorf("expected previous lockfile to stay at %s, got %s", revs[2], rev)
+	}
+}
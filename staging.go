@@ -0,0 +1,149 @@
+package hariti
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/kamichidu/go-hariti/graph"
+)
+
+// StagingDir returns where generations and store entries are built before
+// they are renamed into place.
+func (h *Hariti) StagingDir() string {
+	return filepath.Join(h.config.Paths.DataDir, "staging")
+}
+
+// DataDirLockPath returns the lock file that serializes the processes writing
+// to the staging directory and the store.
+func (h *Hariti) DataDirLockPath() string {
+	return filepath.Join(h.config.Paths.DataDir, "lock")
+}
+
+// lockDataDir takes the data directory lock, waiting while another process
+// holds it, and returns the function that releases it. Deploys and garbage
+// collections hold it for as long as they use the staging directory or the
+// store. The lock is released by the system when its holder exits.
+func (h *Hariti) lockDataDir() (func(), error) {
+	if err := os.MkdirAll(h.DataDir(), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+	f, err := os.OpenFile(h.DataDirLockPath(), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open data directory lock: %w", err)
+	}
+	locked, err := tryLockFile(f)
+	if err == nil && !locked {
+		h.logger.Infof("waiting for another hariti process to release %s", h.DataDirLockPath())
+		err = lockFile(f)
+	}
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("failed to lock data directory: %w", err)
+	}
+	return func() {
+		_ = unlockFile(f)
+		_ = f.Close()
+	}, nil
+}
+
+// createStagingDir creates the staging directory of a new generation and
+// returns its ID. An existing generation is never reused: a numeric suffix is
+// added when a generation with the same ID was already deployed.
+func (h *Hariti) createStagingDir(baseID string) (string, error) {
+	if err := os.MkdirAll(h.StagingDir(), 0755); err != nil {
+		return "", fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	genID := baseID
+	for i := 1; ; i++ {
+		if _, err := os.Lstat(filepath.Join(h.GenerationsDir(), genID)); err == nil {
+			genID = fmt.Sprintf("%s-%d", baseID, i)
+			continue
+		} else if !os.IsNotExist(err) {
+			return "", fmt.Errorf("failed to inspect generation %s: %w", genID, err)
+		}
+
+		err := os.Mkdir(filepath.Join(h.StagingDir(), genID), 0755)
+		if err == nil {
+			return genID, nil
+		}
+		if !os.IsExist(err) {
+			return "", fmt.Errorf("failed to create generation directory: %w", err)
+		}
+		genID = fmt.Sprintf("%s-%d", baseID, i)
+	}
+}
+
+// stagingLeftovers lists the entries of the staging directory. Staging
+// entries are only written under the data directory lock and removed before it
+// is released, so any entry found by the holder of the lock was left behind by
+// an interrupted run.
+func (h *Hariti) stagingLeftovers() ([]string, error) {
+	dirEntries, err := os.ReadDir(h.StagingDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read staging directory: %w", err)
+	}
+	leftovers := make([]string, 0, len(dirEntries))
+	for _, dirEntry := range dirEntries {
+		leftovers = append(leftovers, dirEntry.Name())
+	}
+	return leftovers, nil
+}
+
+// cleanStaging removes the leftovers of interrupted runs from the staging
+// directory. The caller must hold the data directory lock.
+func (h *Hariti) cleanStaging() error {
+	leftovers, err := h.stagingLeftovers()
+	if err != nil {
+		return err
+	}
+	for _, name := range leftovers {
+		if err := os.RemoveAll(filepath.Join(h.StagingDir(), name)); err != nil {
+			return fmt.Errorf("failed to remove staging leftover %s: %w", name, err)
+		}
+		h.logger.Debugf("removed staging leftover %s", name)
+	}
+	return nil
+}
+
+// validateStagedGeneration checks that a staged generation is complete before
+// it is published.
+func (h *Hariti) validateStagedGeneration(rg *runtimeGraph, stagingDir string, projections []Projection) error {
+	names := []string{"lock.json", "metadata.json"}
+	for _, p := range projections {
+		names = append(names, p.FileName())
+	}
+	for _, name := range names {
+		if _, err := os.Stat(filepath.Join(stagingDir, name)); err != nil {
+			return fmt.Errorf("staged generation is incomplete: %w", err)
+		}
+	}
+	for _, bundle := range rg.bundles {
+		if bundle.Source.Type != graph.SourceTypeRemote {
+			continue
+		}
+		// Stat follows the link into the store
+		bundleDir := filepath.Join(stagingDir, "pack", "hariti", "opt", getExportedBundleDirName(bundle.ID))
+		if info, err := os.Stat(bundleDir); err != nil {
+			return fmt.Errorf("staged generation is incomplete: bundle %s: %w", bundle.ID, err)
+		} else if !info.IsDir() {
+			return fmt.Errorf("staged generation is incomplete: bundle %s is not a directory", bundle.ID)
+		}
+	}
+	return nil
+}
+
+// publishGeneration moves a complete staged generation into the generations
+// directory.
+func (h *Hariti) publishGeneration(genID string) error {
+	if err := os.MkdirAll(h.GenerationsDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create generations directory: %w", err)
+	}
+	if err := os.Rename(filepath.Join(h.StagingDir(), genID), filepath.Join(h.GenerationsDir(), genID)); err != nil {
+		return fmt.Errorf("failed to publish generation %s: %w", genID, err)
+	}
+	return nil
+}
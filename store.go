@@ -0,0 +1,293 @@
+package hariti
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/kamichidu/go-hariti/graph"
+	"github.com/kamichidu/go-hariti/vcs"
+)
+
+// StoreManifest describes the built bundle held by a store entry.
+type StoreManifest struct {
+	BundleID string `json:"bundle_id"`
+	Source   string `json:"source"`
+	Revision string `json:"revision"`
+	// Tree and ContentHash are the hashes of the export before build steps
+	// ran, as recorded in the lockfile.
+	Tree        string `json:"tree"`
+	ContentHash string `json:"content_hash"`
+	// BuiltHash is the hash of the entry's files once built, checked
+	// whenever the entry is reused. Entries built before it existed leave it
+	// empty and are not checked.
+	BuiltHash string            `json:"built_hash,omitempty"`
+	Build     []graph.BuildStep `json:"build,omitempty"`
+	// BuildSteps are the results of the build steps run when the entry was
+	// built.
+	BuildSteps []BuildStepResult `json:"build_steps,omitempty"`
+	CreatedAt  string            `json:"created_at"`
+}
+
+// storeEntry is a store entry found on disk.
+type storeEntry struct {
+	Key  string
+	Path string
+	Refs []string
+}
+
+func (h *Hariti) StoreDir() string {
+	return filepath.Join(h.config.Paths.DataDir, "store")
+}
+
+// buildStepsFor returns the build steps of a bundle that run on this OS.
+func buildStepsFor(bundle graph.Bundle) []graph.BuildStep {
+	var steps []graph.BuildStep
+	for _, step := range bundle.Build {
+		if matchOS(step.OS, runtime.GOOS) {
+			steps = append(steps, step)
+		}
+	}
+	return steps
+}
+
+// storeKey addresses the built bundle of a source at a revision with the
+// build steps that run on this OS.
+func storeKey(bundle graph.Bundle, revision string) (string, error) {
+	key := struct {
+		Source   string            `json:"source"`
+		Revision string            `json:"revision"`
+		Build    []graph.BuildStep `json:"build"`
+	}{
+		Source:   getSourceString(bundle),
+		Revision: revision,
+		Build:    buildStepsFor(bundle),
+	}
+	data, err := json.Marshal(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize store key of bundle %s: %w", bundle.ID, err)
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data)), nil
+}
+
+// ensureStoreEntry returns the key, the path of the built bundle and the
+// manifest of the store entry for a lockfile entry, exporting and building it
+// into the store when no deploy did before, and whether an existing entry was
+// reused. A reused entry is checked against the hashes recorded in the
+// lockfile entry, and its files against the hash recorded when it was built.
+func (h *Hariti) ensureStoreEntry(ctx context.Context, v vcs.VCS, bundle graph.Bundle, entry LockfileEntry, buildTimeout time.Duration, onPhase func(Phase), output io.Writer) (string, string, *StoreManifest, bool, error) {
+	key, err := storeKey(bundle, entry.Revision)
+	if err != nil {
+		return "", "", nil, false, err
+	}
+	entryDir := filepath.Join(h.StoreDir(), key)
+
+	if _, err := os.Stat(entryDir); err == nil {
+		manifest, err := h.verifyStoreEntry(bundle, entry, entryDir)
+		if err != nil {
+			return "", "", nil, false, err
+		}
+		h.logger.Debugf("reused store entry %s for bundle %s", key, bundle.ID)
+		return key, filepath.Join(entryDir, "bundle"), manifest, true, nil
+	} else if !os.IsNotExist(err) {
+		return "", "", nil, false, fmt.Errorf("failed to inspect store entry of bundle %s: %w", bundle.ID, err)
+	}
+
+	for _, dir := range []string{h.StoreDir(), h.StagingDir()} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", "", nil, false, fmt.Errorf("failed to create directory %s: %w", dir, err)
+		}
+	}
+	tmpDir, err := os.MkdirTemp(h.StagingDir(), "store-"+key[:12]+"-")
+	if err != nil {
+		return "", "", nil, false, fmt.Errorf("failed to create store entry of bundle %s: %w", bundle.ID, err)
+	}
+	defer func() {
+		_ = os.RemoveAll(tmpDir) // no-op once the entry is published
+	}()
+
+	manifest, err := h.buildStoreEntry(ctx, v, bundle, entry, tmpDir, buildTimeout, onPhase, output)
+	if err != nil {
+		return "", "", nil, false, err
+	}
+
+	if err := os.Rename(tmpDir, entryDir); err != nil {
+		// A concurrent deploy may have published the same entry first
+		if _, statErr := os.Stat(entryDir); statErr != nil {
+			return "", "", nil, false, fmt.Errorf("failed to publish store entry of bundle %s: %w", bundle.ID, err)
+		}
+		h.logger.Debugf("store entry %s was published concurrently", key)
+	} else {
+		h.logger.Debugf("published store entry %s for bundle %s", key, bundle.ID)
+	}
+	return key, filepath.Join(entryDir, "bundle"), manifest, false, nil
+}
+
+// buildStoreEntry exports a bundle into an unpublished store entry, verifies
+// the export, runs its build steps and generates its help tags. It returns the
+// manifest written into the entry.
+func (h *Hariti) buildStoreEntry(ctx context.Context, v vcs.VCS, bundle graph.Bundle, entry LockfileEntry, entryDir string, buildTimeout time.Duration, onPhase func(Phase), output io.Writer) (*StoreManifest, error) {
+	destDir := filepath.Join(entryDir, "bundle")
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory for bundle %s: %w", bundle.ID, err)
+	}
+
+	onPhase(PhaseArchive)
+	h.logger.Debugf("archive source %s/destination %s", bundle.ID, destDir)
+	if err := v.Archive(ctx, bundle, entry.Revision, destDir); err != nil {
+		return nil, fmt.Errorf("failed to archive remote bundle %s at revision %s: %w", bundle.ID, entry.Revision, err)
+	}
+
+	// Verify the export before build steps modify it
+	tree, err := v.TreeHash(ctx, bundle, entry.Revision)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve tree hash for bundle %s at revision %s: %w", bundle.ID, entry.Revision, err)
+	}
+	contentHash, err := hashExportedFiles(destDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash exported files of bundle %s: %w", bundle.ID, err)
+	}
+	if err := h.verifyHashes(bundle.ID, entry, tree, contentHash); err != nil {
+		return nil, err
+	}
+
+	// Run build steps inside the exported bundle directory in the store
+	var results []BuildStepResult
+	if len(buildStepsFor(bundle)) > 0 {
+		onPhase(PhaseBuild)
+		results, err = h.runBuildSteps(ctx, bundle, destDir, buildTimeout, output)
+		if err != nil {
+			return nil, err
+		}
+	}
+	onPhase(PhaseHelptags)
+	if err := h.buildHelpTags(ctx, bundle.ID, filepath.Join(destDir, "doc")); err != nil {
+		return nil, err
+	}
+
+	builtHash, err := hashExportedFiles(destDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash built files of bundle %s: %w", bundle.ID, err)
+	}
+
+	manifest := &StoreManifest{
+		BundleID:    bundle.ID,
+		Source:      getSourceString(bundle),
+		Revision:    entry.Revision,
+		Tree:        tree,
+		ContentHash: contentHash,
+		BuiltHash:   builtHash,
+		Build:       buildStepsFor(bundle),
+		BuildSteps:  results,
+		CreatedAt:   time.Now().Format(time.RFC3339Nano),
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize store manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(entryDir, "manifest.json"), data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write store manifest: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Join(entryDir, "refs"), 0755); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// verifyStoreEntry checks the hashes recorded for a store entry against the
+// ones recorded in the lockfile entry, re-hashes the built files of the entry
+// against its manifest, and returns the manifest of the entry.
+func (h *Hariti) verifyStoreEntry(bundle graph.Bundle, entry LockfileEntry, entryDir string) (*StoreManifest, error) {
+	data, err := os.ReadFile(filepath.Join(entryDir, "manifest.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read store manifest of bundle %s: %w", bundle.ID, err)
+	}
+	manifest := new(StoreManifest)
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse store manifest of bundle %s: %w", bundle.ID, err)
+	}
+
+	if err := h.verifyHashes(bundle.ID, entry, manifest.Tree, manifest.ContentHash); err != nil {
+		return nil, err
+	}
+
+	if manifest.BuiltHash == "" {
+		h.logger.Debugf("no built hash recorded for store entry of bundle %s, skipped re-hashing", bundle.ID)
+		return manifest, nil
+	}
+	builtHash, err := hashExportedFiles(filepath.Join(entryDir, "bundle"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash store entry of bundle %s: %w", bundle.ID, err)
+	}
+	if builtHash != manifest.BuiltHash {
+		return nil, &IntegrityError{
+			BundleID: bundle.ID,
+			Kind:     HashKindBuilt,
+			Expected: manifest.BuiltHash,
+			Actual:   builtHash,
+		}
+	}
+	return manifest, nil
+}
+
+// linkStoreEntry links a built bundle into a generation and records the
+// generation as a referrer of the store entry.
+func (h *Hariti) linkStoreEntry(key, bundleDir, genID, destDir string) error {
+	if err := os.MkdirAll(filepath.Dir(destDir), 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", filepath.Dir(destDir), err)
+	}
+	// A relative link keeps the generation valid when the data directory moves
+	target, err := filepath.Rel(filepath.Dir(destDir), bundleDir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve store link target: %w", err)
+	}
+	if err := createGenerationLink(target, destDir); err != nil {
+		return fmt.Errorf("failed to link store entry %s: %w", key, err)
+	}
+
+	refPath := filepath.Join(h.StoreDir(), key, "refs", genID)
+	if err := os.WriteFile(refPath, nil, 0644); err != nil {
+		return fmt.Errorf("failed to record reference to store entry %s: %w", key, err)
+	}
+	return nil
+}
+
+// storeEntries lists the published store entries and the generations
+// referring to them.
+func (h *Hariti) storeEntries() ([]storeEntry, error) {
+	dirEntries, err := os.ReadDir(h.StoreDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read store directory: %w", err)
+	}
+
+	var entries []storeEntry
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.IsDir() {
+			continue
+		}
+		entryDir := filepath.Join(h.StoreDir(), dirEntry.Name())
+		refEntries, err := os.ReadDir(filepath.Join(entryDir, "refs"))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read references of store entry %s: %w", dirEntry.Name(), err)
+		}
+		refs := make([]string, 0, len(refEntries))
+		for _, refEntry := range refEntries {
+			refs = append(refs, refEntry.Name())
+		}
+		entries = append(entries, storeEntry{
+			Key:  dirEntry.Name(),
+			Path: entryDir,
+			Refs: refs,
+		})
+	}
+	return entries, nil
+}
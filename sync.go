@@ -5,7 +5,9 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"sync/atomic"
+	"time"
 
 	"github.com/kamichidu/go-hariti/graph"
 	"github.com/kamichidu/go-hariti/vcs"
@@ -19,6 +21,7 @@ const (
 	SyncEventBundleStarted   SyncEventType = "bundle-started"
 	SyncEventBundleCompleted SyncEventType = "bundle-completed"
 	SyncEventBundleFailed    SyncEventType = "bundle-failed"
+	SyncEventBundleRetrying  SyncEventType = "bundle-retrying"
 	SyncEventCompleted       SyncEventType = "completed"
 	SyncEventFailed          SyncEventType = "failed"
 )
@@ -31,11 +34,79 @@ type SyncProgressEvent struct {
 	Parallelism int
 	Err         error
 	Output      string
+	// Attempt is the number of the upcoming attempt of a retrying bundle,
+	// starting at 2 for the first retry.
+	Attempt int
 }
 
 type SyncOptions struct {
 	Parallelism int
 	OnProgress  func(event SyncProgressEvent)
+	// FromLockfile restores every bundle to the revision recorded in hariti.lock
+	// instead of advancing it, and leaves hariti.lock untouched.
+	FromLockfile bool
+	// Only limits the update to the given bundle IDs or aliases. Every other
+	// bundle is restored to the revision recorded in hariti.lock, so only the
+	// selected entries change in the rewritten lockfile.
+	Only []string
+	// KeepGoing lets the remaining bundles finish when one of them fails. The
+	// lockfile keeps the previously locked revision of every failed bundle, and
+	// the failures are reported together as a *SyncError.
+	KeepGoing bool
+	// Retries is the number of additional attempts for a bundle whose VCS
+	// operation failed with an error the VCS adapter classifies as retryable.
+	Retries int
+	// RetryBackoff is the delay before the first retry, doubled for every
+	// further attempt. It defaults to 1 second.
+	RetryBackoff time.Duration
+	// BundleTimeout bounds the time spent on a single bundle, including its
+	// retries. A bundle exceeding it fails with a *TimeoutError.
+	BundleTimeout time.Duration
+	// Clone is the clone strategy of remote bundles that do not configure one.
+	// The zero value clones the full history.
+	Clone graph.Clone
+}
+
+// BundleSyncError is the failure of a single bundle during a keep-going sync.
+type BundleSyncError struct {
+	BundleID string
+	Err      error
+	Output   string
+}
+
+func (e *BundleSyncError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *BundleSyncError) Unwrap() error {
+	return e.Err
+}
+
+// SyncError aggregates every bundle failure of a keep-going sync.
+type SyncError struct {
+	Failures []*BundleSyncError
+}
+
+func (e *SyncError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "sync failed for %d bundles:", len(e.Failures))
+	for _, failure := range e.Failures {
+		fmt.Fprintf(&b, "\n  %s: %v", failure.BundleID, failure.Err)
+		for _, line := range strings.Split(strings.TrimSpace(failure.Output), "\n") {
+			if line != "" {
+				fmt.Fprintf(&b, "\n    %s", line)
+			}
+		}
+	}
+	return b.String()
+}
+
+func (e *SyncError) Unwrap() []error {
+	errs := make([]error, len(e.Failures))
+	for i, failure := range e.Failures {
+		errs[i] = failure
+	}
+	return errs
 }
 
 func (h *Hariti) Sync(ctx context.Context, g *graph.Graph, opts SyncOptions) ([]RepositoryFact, error) {
@@ -45,6 +116,56 @@ func (h *Hariti) Sync(ctx context.Context, g *graph.Graph, opts SyncOptions) ([]
 	if parallelism <= 0 {
 		parallelism = 8
 	}
+	backoff := opts.RetryBackoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+
+	if opts.FromLockfile && len(opts.Only) > 0 {
+		return nil, fmt.Errorf("frozen sync cannot be limited to selected bundles")
+	}
+	if err := opts.Clone.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid clone option: %w", err)
+	}
+
+	previousEntries, err := h.lockedEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	var lockedRevisions map[string]string
+	if opts.FromLockfile {
+		if _, err := os.Stat(h.LockfilePath()); err != nil {
+			return nil, fmt.Errorf("failed to read lockfile: %w", err)
+		}
+		lockedRevisions, err = resolveLockedRevisions(previousEntries, rg.bundles)
+		if err != nil {
+			return nil, err
+		}
+	} else if len(opts.Only) > 0 {
+		selected, err := selectBundles(rg.bundles, opts.Only)
+		if err != nil {
+			return nil, err
+		}
+		// Bundles that were never locked have no revision to stay at, so they
+		// are synced as usual along with the selected ones
+		pinned := make([]graph.Bundle, 0, len(rg.bundles))
+		for _, bundle := range rg.bundles {
+			if selected[bundle.ID] {
+				continue
+			}
+			if _, locked := previousEntries[bundle.ID]; !locked {
+				h.logger.Debugf("selective sync: bundle %s is not locked yet, syncing it", bundle.ID)
+				continue
+			}
+			pinned = append(pinned, bundle)
+		}
+		h.logger.Debugf("selective sync: updating %d of %d bundles", len(rg.bundles)-len(pinned), len(rg.bundles))
+		lockedRevisions, err = resolveLockedRevisions(previousEntries, pinned)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	h.logger.Infof("sync started")
 	if opts.OnProgress != nil {
@@ -57,12 +178,16 @@ func (h *Hariti) Sync(ctx context.Context, g *graph.Graph, opts SyncOptions) ([]
 
 	sem := make(chan struct{}, parallelism)
 	facts := make([]RepositoryFact, len(rg.bundles))
+	failures := make([]*BundleSyncError, len(rg.bundles))
 	var completedCount int32
 
 	eg, egCtx := errgroup.WithContext(ctx)
 
 	for i, bundle := range rg.bundles {
 		i, bundle := i, bundle
+		if bundle.Source.Type == graph.SourceTypeRemote && bundle.Clone == (graph.Clone{}) {
+			bundle.Clone = opts.Clone
+		}
 		eg.Go(func() error {
 			select {
 			case sem <- struct{}{}:
@@ -82,8 +207,41 @@ func (h *Hariti) Sync(ctx context.Context, g *graph.Graph, opts SyncOptions) ([]
 				})
 			}
 
+			retry := retryPolicy{
+				retries: opts.Retries,
+				backoff: backoff,
+			}
+			retry.onRetry = func(attempt int, err error, output string) {
+				h.logger.Infof("retrying bundle %s (attempt %d/%d): %v", bundle.ID, attempt, opts.Retries+1, err)
+				if opts.OnProgress != nil {
+					opts.OnProgress(SyncProgressEvent{
+						Type:        SyncEventBundleRetrying,
+						BundleID:    bundle.ID,
+						Total:       len(rg.bundles),
+						Parallelism: parallelism,
+						Err:         err,
+						Output:      output,
+						Attempt:     attempt,
+					})
+				}
+			}
+
+			bundleCtx, cancel := withBundleTimeout(egCtx, opts.BundleTimeout)
+			defer cancel()
+
 			var gitOutput bytes.Buffer
-			err := h.syncOneBundle(egCtx, bundle, &facts[i], &gitOutput)
+			phase := PhaseSync
+			if lockedRevisions[bundle.ID] != "" {
+				phase = PhaseCheckout
+			}
+			err := h.syncOneBundle(bundleCtx, bundle, lockedRevisions[bundle.ID], previousEntries[bundle.ID], retry, &facts[i], &phase, &gitOutput)
+			if err != nil && isBundleTimeout(bundleCtx) {
+				err = &TimeoutError{
+					BundleID: bundle.ID,
+					Phase:    phase,
+					Timeout:  opts.BundleTimeout,
+				}
+			}
 			if err != nil {
 				num := atomic.AddInt32(&completedCount, 1)
 				if opts.OnProgress != nil {
@@ -97,6 +255,14 @@ func (h *Hariti) Sync(ctx context.Context, g *graph.Graph, opts SyncOptions) ([]
 						Output:      gitOutput.String(),
 					})
 				}
+				if opts.KeepGoing {
+					failures[i] = &BundleSyncError{
+						BundleID: bundle.ID,
+						Err:      err,
+						Output:   gitOutput.String(),
+					}
+					return nil
+				}
 				return err
 			}
 
@@ -127,9 +293,40 @@ func (h *Hariti) Sync(ctx context.Context, g *graph.Graph, opts SyncOptions) ([]
 		return nil, err
 	}
 
+	var syncErr *SyncError
+	for _, failure := range failures {
+		if failure != nil {
+			if syncErr == nil {
+				syncErr = &SyncError{}
+			}
+			syncErr.Failures = append(syncErr.Failures, failure)
+		}
+	}
+	if syncErr != nil {
+		h.restoreFailedFacts(rg.bundles, failures, previousEntries, facts)
+	}
+
 	// Write hariti.lock
-	if err := h.writeLockfile(facts, g); err != nil {
-		return nil, fmt.Errorf("failed to write lockfile: %w", err)
+	if opts.FromLockfile {
+		h.logger.Debugf("frozen sync: skipped lockfile update")
+	} else {
+		if err := h.writeLockfile(facts, g); err != nil {
+			return nil, fmt.Errorf("failed to write lockfile: %w", err)
+		}
+	}
+
+	if syncErr != nil {
+		h.logger.Infof("sync completed with %d failed bundles", len(syncErr.Failures))
+		if opts.OnProgress != nil {
+			opts.OnProgress(SyncProgressEvent{
+				Type:        SyncEventFailed,
+				Total:       len(rg.bundles),
+				Num:         len(rg.bundles),
+				Parallelism: parallelism,
+				Err:         syncErr,
+			})
+		}
+		return facts, syncErr
 	}
 
 	h.logger.Infof("sync completed")
@@ -145,7 +342,102 @@ func (h *Hariti) Sync(ctx context.Context, g *graph.Graph, opts SyncOptions) ([]
 	return facts, nil
 }
 
-func (h *Hariti) syncOneBundle(ctx context.Context, bundle graph.Bundle, fact *RepositoryFact, gitOutput *bytes.Buffer) error {
+// restoreFailedFacts fills the facts of failed bundles with the revision
+// recorded in the current lockfile, so that a partial lockfile update keeps
+// them where they were. Bundles that were never locked are left without a
+// revision and get no lockfile entry.
+func (h *Hariti) restoreFailedFacts(bundles []graph.Bundle, failures []*BundleSyncError, entries map[string]LockfileEntry, facts []RepositoryFact) {
+	for i, failure := range failures {
+		if failure == nil {
+			continue
+		}
+		fact := RepositoryFact{BundleID: bundles[i].ID}
+		if entry, exists := entries[bundles[i].ID]; exists && entry.Source == getSourceString(bundles[i]) {
+			fact.Revision = entry.Revision
+			fact.Tree = entry.Tree
+			fact.ContentHash = entry.ContentHash
+		}
+		h.logger.Debugf("keeping locked revision %q for failed bundle %s", fact.Revision, bundles[i].ID)
+		facts[i] = fact
+	}
+}
+
+// resolveLockedRevisions maps every bundle to its locked revision, failing when
+// the lockfile does not describe the graph.
+func resolveLockedRevisions(entries map[string]LockfileEntry, bundles []graph.Bundle) (map[string]string, error) {
+	revisions := make(map[string]string, len(bundles))
+	for _, bundle := range bundles {
+		entry, exists := entries[bundle.ID]
+		if !exists {
+			return nil, fmt.Errorf("bundle %s is not recorded in lockfile", bundle.ID)
+		}
+		if currentSource := getSourceString(bundle); entry.Source != currentSource {
+			return nil, fmt.Errorf("locked source %s differs from graph source %s for bundle %s", entry.Source, currentSource, bundle.ID)
+		}
+		if entry.Revision == "" {
+			return nil, fmt.Errorf("no revision found in lockfile for bundle %s", bundle.ID)
+		}
+		revisions[bundle.ID] = entry.Revision
+	}
+	return revisions, nil
+}
+
+// selectBundles resolves bundle IDs or aliases to the set of canonical IDs they
+// refer to, failing on names that match no bundle.
+func selectBundles(bundles []graph.Bundle, names []string) (map[string]bool, error) {
+	ids := make(map[string]string, len(bundles))
+	for _, bundle := range bundles {
+		for _, alias := range bundle.Aliases {
+			ids[alias] = bundle.ID
+		}
+	}
+	for _, bundle := range bundles {
+		ids[bundle.ID] = bundle.ID
+	}
+
+	selected := make(map[string]bool, len(names))
+	for _, name := range names {
+		id, exists := ids[name]
+		if !exists {
+			return nil, fmt.Errorf("no bundle matches %s", name)
+		}
+		selected[id] = true
+	}
+	return selected, nil
+}
+
+// retryPolicy retries VCS operations that fail with retryable errors, backing
+// off exponentially between attempts.
+type retryPolicy struct {
+	retries int
+	backoff time.Duration
+	onRetry func(attempt int, err error, output string)
+}
+
+func (p retryPolicy) do(ctx context.Context, v vcs.VCS, output *bytes.Buffer, fn func() error) error {
+	delay := p.backoff
+	for attempt := 1; ; attempt++ {
+		start := output.Len()
+		err := fn()
+		if err == nil || attempt > p.retries || !vcs.IsRetryable(v, err) {
+			return err
+		}
+
+		if p.onRetry != nil {
+			p.onRetry(attempt+1, err, output.String()[start:])
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		delay *= 2
+	}
+}
+
+// syncOneBundle fetches or checks out one bundle and records its fact. phase is
+// advanced when the bundle moves on to hashing, so a timeout names that step.
+func (h *Hariti) syncOneBundle(ctx context.Context, bundle graph.Bundle, lockedRevision string, previous LockfileEntry, retry retryPolicy, fact *RepositoryFact, phase *Phase, gitOutput *bytes.Buffer) error {
 	currentSource := getSourceString(bundle)
 
 	switch bundle.Source.Type {
@@ -169,6 +461,7 @@ func (h *Hariti) syncOneBundle(ctx context.Context, bundle graph.Bundle, fact *R
 			return err
 		}
 
+		currentStrategy := cloneStrategy(bundle.Clone)
 		if storedMeta != nil && storedMeta.Source != currentSource {
 			h.logger.Infof("source mismatch detected for bundle %s, removing stale repository directory", bundle.ID)
 			// remove stale repo directory
@@ -176,6 +469,13 @@ func (h *Hariti) syncOneBundle(ctx context.Context, bundle graph.Bundle, fact *R
 			if err != nil {
 				return fmt.Errorf("failed to remove repo directory on source mismatch for %s: %w", bundle.ID, err)
 			}
+		} else if storedMeta != nil && cloneStrategy(graph.Clone{Strategy: storedMeta.CloneStrategy}) != currentStrategy {
+			// Shallow and partial clones cannot be converted in place reliably
+			h.logger.Infof("clone strategy of bundle %s changed to %s, removing stale repository directory", bundle.ID, currentStrategy)
+			err := os.RemoveAll(bundle.Source.Path)
+			if err != nil {
+				return fmt.Errorf("failed to remove repo directory on clone strategy change for %s: %w", bundle.ID, err)
+			}
 		}
 
 		v := vcs.Detect(bundle.Source.URL)
@@ -194,9 +494,21 @@ func (h *Hariti) syncOneBundle(ctx context.Context, bundle graph.Bundle, fact *R
 		vcsCtx = vcs.WithWriter(vcsCtx, gitOutput)
 		vcsCtx = vcs.WithErrWriter(vcsCtx, gitOutput)
 
-		err = v.Sync(vcsCtx, bundle)
-		if err != nil {
-			return fmt.Errorf("failed to sync bundle %s: %w", bundle.ID, err)
+		if lockedRevision != "" {
+			h.logger.Debugf("resolved locked revision for bundle %s to %s", bundle.ID, lockedRevision)
+			err = retry.do(ctx, v, gitOutput, func() error {
+				return v.Checkout(vcsCtx, bundle, lockedRevision)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to checkout bundle %s at revision %s: %w", bundle.ID, lockedRevision, err)
+			}
+		} else {
+			err = retry.do(ctx, v, gitOutput, func() error {
+				return v.Sync(vcsCtx, bundle)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to sync bundle %s: %w", bundle.ID, err)
+			}
 		}
 
 		// Revision observation
@@ -206,19 +518,46 @@ func (h *Hariti) syncOneBundle(ctx context.Context, bundle graph.Bundle, fact *R
 		}
 		h.logger.Debugf("resolved repository revision for bundle %s to %s", bundle.ID, rev)
 
+		// Hashes are only computed for revisions the lockfile does not describe yet
+		tree, contentHash := previous.Tree, previous.ContentHash
+		if previous.Revision != rev || previous.Source != currentSource || tree == "" || contentHash == "" {
+			*phase = PhaseHash
+			tree, err = v.TreeHash(vcsCtx, bundle, rev)
+			if err != nil {
+				return fmt.Errorf("failed to resolve tree hash for bundle %s: %w", bundle.ID, err)
+			}
+			contentHash, err = h.exportContentHash(vcsCtx, v, bundle, rev)
+			if err != nil {
+				return fmt.Errorf("failed to compute content hash for bundle %s: %w", bundle.ID, err)
+			}
+			h.logger.Debugf("resolved content hash for bundle %s to %s", bundle.ID, contentHash)
+		}
+
 		// Write repository metadata
 		meta := &RepositoryMetadata{
-			BundleID: bundle.ID,
-			Source:   currentSource,
+			BundleID:      bundle.ID,
+			Source:        currentSource,
+			CloneStrategy: currentStrategy,
 		}
 		if err := h.writeRepositoryMetadata(bundle.ID, meta); err != nil {
 			return fmt.Errorf("failed to write repository metadata for %s: %w", bundle.ID, err)
 		}
 
 		*fact = RepositoryFact{
-			BundleID: bundle.ID,
-			Revision: rev,
+			BundleID:    bundle.ID,
+			Revision:    rev,
+			Tree:        tree,
+			ContentHash: contentHash,
 		}
 	}
 	return nil
 }
+
+// cloneStrategy returns the strategy a repository cache is cloned with, where
+// an unset strategy means a full clone.
+func cloneStrategy(clone graph.Clone) graph.CloneStrategy {
+	if clone.Strategy == "" {
+		return graph.CloneStrategyFull
+	}
+	return clone.Strategy
+}
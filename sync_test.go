@@ -3,6 +3,7 @@ package hariti_test
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/url"
@@ -10,7 +11,10 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/kamichidu/go-hariti"
 	"github.com/kamichidu/go-hariti/graph"
@@ -701,3 +705,932 @@ func TestHariti_Sync_Parallelism(t *testing.T) {
 		}
 	}
 }
+
+func TestHariti_Sync_FromLockfile(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	xdgHome := filepath.Join(tmpDir, "xdg_home")
+	_ = os.Setenv("XDG_DATA_HOME", xdgHome)
+	defer func() {
+		_ = os.Unsetenv("XDG_DATA_HOME")
+	}()
+
+	// 1. Setup mock Git remote repo
+	remoteRepoDir := filepath.Join(tmpDir, "remote_repo")
+	if err := os.MkdirAll(remoteRepoDir, 0755); err != nil {
+		t.Fatalf("failed to create mock remote dir: %v", err)
+	}
+
+	_ = runGitCmdInDir(t, remoteRepoDir, "init", "--initial-branch=main")
+	_ = runGitCmdInDir(t, remoteRepoDir, "config", "user.email", "test@hariti.io")
+	_ = runGitCmdInDir(t, remoteRepoDir, "config", "user.name", "Test Hariti")
+	_ = runGitCmdInDir(t, remoteRepoDir, "commit", "--allow-empty", "-m", "initial commit")
+	rev1 := runGitCmdInDir(t, remoteRepoDir, "rev-parse", "HEAD")
+
+	remoteURL, err := url.Parse("file://" + filepath.ToSlash(remoteRepoDir))
+	if err != nil {
+		t.Fatalf("failed to parse remote URL: %v", err)
+	}
+
+	cacheRepoPath := filepath.Join(xdgHome, "hariti", "repos", url.QueryEscape("my-frozen-plugin"))
+	g := &graph.Graph{
+		Bundles: []graph.Bundle{
+			{
+				ID: "my-frozen-plugin",
+				Source: graph.Source{
+					Type: graph.SourceTypeRemote,
+					URL:  remoteURL,
+					Path: cacheRepoPath,
+				},
+			},
+		},
+	}
+
+	cfg := &hariti.HaritiConfig{
+		Paths: hariti.Paths{
+			ConfigFile: filepath.Join(tmpDir, "hariti_home", "bundles.hariti"),
+			ConfigDir:  filepath.Join(tmpDir, "hariti_home"),
+			DataDir:    filepath.Join(xdgHome, "hariti"),
+		},
+		Writer:    io.Discard,
+		ErrWriter: io.Discard,
+	}
+	har := hariti.NewHariti(cfg)
+
+	ctx := context.Background()
+	ctx = vcs.WithWriter(ctx, io.Discard)
+	ctx = vcs.WithErrWriter(ctx, io.Discard)
+
+	// Step 1: Regular sync locks rev1
+	if _, err := har.Sync(ctx, g, hariti.SyncOptions{}); err != nil {
+		t.Fatalf("initial Sync failed: %v", err)
+	}
+	lockBefore, err := os.ReadFile(har.LockfilePath())
+	if err != nil {
+		t.Fatalf("failed to read lockfile: %v", err)
+	}
+
+	// Step 2: Advance remote and move the cache forward to rev2 behind hariti's back
+	_ = runGitCmdInDir(t, remoteRepoDir, "commit", "--allow-empty", "-m", "remote advance commit")
+	_ = runGitCmdInDir(t, cacheRepoPath, "fetch", "--all")
+	_ = runGitCmdInDir(t, cacheRepoPath, "reset", "--hard", "@{upstream}")
+
+	// Step 3: Frozen sync must restore rev1 and keep hariti.lock untouched
+	facts, err := har.Sync(ctx, g, hariti.SyncOptions{FromLockfile: true})
+	if err != nil {
+		t.Fatalf("frozen Sync failed: %v", err)
+	}
+	if facts[0].Revision != rev1 {
+		t.Errorf("expected frozen revision %s, got %s", rev1, facts[0].Revision)
+	}
+	if head := runGitCmdInDir(t, cacheRepoPath, "rev-parse", "HEAD"); head != rev1 {
+		t.Errorf("expected cache HEAD %s, got %s", rev1, head)
+	}
+	lockAfter, err := os.ReadFile(har.LockfilePath())
+	if err != nil {
+		t.Fatalf("failed to read lockfile: %v", err)
+	}
+	if string(lockBefore) != string(lockAfter) {
+		t.Errorf("expected frozen sync not to rewrite lockfile\nBefore:\n%s\nAfter:\n%s", lockBefore, lockAfter)
+	}
+
+	// Step 4: Frozen sync must clone a missing cache and check out the locked revision
+	if err := os.RemoveAll(cacheRepoPath); err != nil {
+		t.Fatalf("failed to remove cache repo: %v", err)
+	}
+	if _, err := har.Sync(ctx, g, hariti.SyncOptions{FromLockfile: true}); err != nil {
+		t.Fatalf("frozen Sync from empty cache failed: %v", err)
+	}
+	if head := runGitCmdInDir(t, cacheRepoPath, "rev-parse", "HEAD"); head != rev1 {
+		t.Errorf("expected re-cloned cache HEAD %s, got %s", rev1, head)
+	}
+
+	// Step 5: Bundles missing from the lockfile are rejected
+	missing := &graph.Graph{
+		Bundles: append(append([]graph.Bundle{}, g.Bundles...), graph.Bundle{
+			ID: "my-unlocked-plugin",
+			Source: graph.Source{
+				Type: graph.SourceTypeRemote,
+				URL:  remoteURL,
+				Path: filepath.Join(xdgHome, "hariti", "repos", url.QueryEscape("my-unlocked-plugin")),
+			},
+		}),
+	}
+	_, err = har.Sync(ctx, missing, hariti.SyncOptions{FromLockfile: true})
+	if err == nil || !strings.Contains(err.Error(), "bundle my-unlocked-plugin is not recorded in lockfile") {
+		t.Errorf("expected missing lock entry error, got: %v", err)
+	}
+
+	// Step 6: Source differences between the lockfile and the graph are rejected
+	otherURL, _ := url.Parse("file://" + filepath.ToSlash(filepath.Join(tmpDir, "other_remote")))
+	mismatch := &graph.Graph{
+		Bundles: []graph.Bundle{
+			{
+				ID: "my-frozen-plugin",
+				Source: graph.Source{
+					Type: graph.SourceTypeRemote,
+					URL:  otherURL,
+					Path: cacheRepoPath,
+				},
+			},
+		},
+	}
+	_, err = har.Sync(ctx, mismatch, hariti.SyncOptions{FromLockfile: true})
+	if err == nil || !strings.Contains(err.Error(), "differs from graph source") {
+		t.Errorf("expected source mismatch error, got: %v", err)
+	}
+}
+
+func TestHariti_Sync_Ref(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	xdgHome := filepath.Join(tmpDir, "xdg_home")
+	_ = os.Setenv("XDG_DATA_HOME", xdgHome)
+	defer func() {
+		_ = os.Unsetenv("XDG_DATA_HOME")
+	}()
+
+	// 1. Setup mock Git remote repo with a tag on main and a dev branch
+	remoteRepoDir := filepath.Join(tmpDir, "remote_repo")
+	if err := os.MkdirAll(remoteRepoDir, 0755); err != nil {
+		t.Fatalf("failed to create mock remote dir: %v", err)
+	}
+
+	_ = runGitCmdInDir(t, remoteRepoDir, "init", "--initial-branch=main")
+	_ = runGitCmdInDir(t, remoteRepoDir, "config", "user.email", "test@hariti.io")
+	_ = runGitCmdInDir(t, remoteRepoDir, "config", "user.name", "Test Hariti")
+	_ = runGitCmdInDir(t, remoteRepoDir, "commit", "--allow-empty", "-m", "initial commit")
+	revInitial := runGitCmdInDir(t, remoteRepoDir, "rev-parse", "HEAD")
+	_ = runGitCmdInDir(t, remoteRepoDir, "commit", "--allow-empty", "-m", "release commit")
+	_ = runGitCmdInDir(t, remoteRepoDir, "tag", "-a", "v1.0.0", "-m", "v1.0.0")
+	revTag := runGitCmdInDir(t, remoteRepoDir, "rev-parse", "HEAD")
+	_ = runGitCmdInDir(t, remoteRepoDir, "checkout", "-b", "dev")
+	_ = runGitCmdInDir(t, remoteRepoDir, "commit", "--allow-empty", "-m", "dev commit")
+	revDev := runGitCmdInDir(t, remoteRepoDir, "rev-parse", "HEAD")
+	_ = runGitCmdInDir(t, remoteRepoDir, "checkout", "main")
+	_ = runGitCmdInDir(t, remoteRepoDir, "commit", "--allow-empty", "-m", "main advance commit")
+	revMain := runGitCmdInDir(t, remoteRepoDir, "rev-parse", "HEAD")
+
+	remoteURL, err := url.Parse("file://" + filepath.ToSlash(remoteRepoDir))
+	if err != nil {
+		t.Fatalf("failed to parse remote URL: %v", err)
+	}
+
+	newBundle := func(id string, ref graph.Ref) graph.Bundle {
+		return graph.Bundle{
+			ID: id,
+			Source: graph.Source{
+				Type: graph.SourceTypeRemote,
+				URL:  remoteURL,
+				Path: filepath.Join(xdgHome, "hariti", "repos", url.QueryEscape(id)),
+			},
+			Ref: ref,
+		}
+	}
+	g := &graph.Graph{
+		Bundles: []graph.Bundle{
+			newBundle("my-default-plugin", graph.Ref{}),
+			newBundle("my-branch-plugin", graph.Ref{Type: graph.RefTypeBranch, Name: "dev"}),
+			newBundle("my-tag-plugin", graph.Ref{Type: graph.RefTypeTag, Name: "v1.0.0"}),
+			newBundle("my-rev-plugin", graph.Ref{Type: graph.RefTypeRevision, Name: revInitial}),
+		},
+	}
+
+	cfg := &hariti.HaritiConfig{
+		Paths: hariti.Paths{
+			ConfigFile: filepath.Join(tmpDir, "hariti_home", "bundles.hariti"),
+			ConfigDir:  filepath.Join(tmpDir, "hariti_home"),
+			DataDir:    filepath.Join(xdgHome, "hariti"),
+		},
+		Writer:    io.Discard,
+		ErrWriter: io.Discard,
+	}
+	har := hariti.NewHariti(cfg)
+
+	ctx := context.Background()
+	ctx = vcs.WithWriter(ctx, io.Discard)
+	ctx = vcs.WithErrWriter(ctx, io.Discard)
+
+	expected := map[string]string{
+		"my-default-plugin": revMain,
+		"my-branch-plugin":  revDev,
+		"my-tag-plugin":     revTag,
+		"my-rev-plugin":     revInitial,
+	}
+
+	// Step 1: Fresh clones check out the configured ref
+	facts, err := har.Sync(ctx, g, hariti.SyncOptions{})
+	if err != nil {
+		t.Fatalf("initial Sync failed: %v", err)
+	}
+	for _, fact := range facts {
+		if fact.Revision != expected[fact.BundleID] {
+			t.Errorf("bundle %s: expected revision %s, got %s", fact.BundleID, expected[fact.BundleID], fact.Revision)
+		}
+	}
+
+	// Step 2: Advance the dev branch and move the tag upstream; the rev-pinned
+	// and default bundles stay where they are
+	_ = runGitCmdInDir(t, remoteRepoDir, "checkout", "dev")
+	_ = runGitCmdInDir(t, remoteRepoDir, "commit", "--allow-empty", "-m", "dev advance commit")
+	expected["my-branch-plugin"] = runGitCmdInDir(t, remoteRepoDir, "rev-parse", "HEAD")
+	_ = runGitCmdInDir(t, remoteRepoDir, "checkout", "main")
+	_ = runGitCmdInDir(t, remoteRepoDir, "tag", "-f", "-a", "v1.0.0", "-m", "v1.0.0 re-released")
+	expected["my-tag-plugin"] = revMain
+
+	facts, err = har.Sync(ctx, g, hariti.SyncOptions{})
+	if err != nil {
+		t.Fatalf("second Sync failed: %v", err)
+	}
+	for _, fact := range facts {
+		if fact.Revision != expected[fact.BundleID] {
+			t.Errorf("bundle %s: expected revision %s after update, got %s", fact.BundleID, expected[fact.BundleID], fact.Revision)
+		}
+	}
+
+	// Step 3: Unknown refs are reported
+	missing := &graph.Graph{
+		Bundles: []graph.Bundle{
+			newBundle("my-branch-plugin", graph.Ref{Type: graph.RefTypeBranch, Name: "no-such-branch"}),
+		},
+	}
+	_, err = har.Sync(ctx, missing, hariti.SyncOptions{})
+	if err == nil || !strings.Contains(err.Error(), "failed to resolve branch no-such-branch") {
+		t.Errorf("expected unresolved branch error, got: %v", err)
+	}
+}
+
+func TestHariti_Sync_Clone(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	xdgHome := filepath.Join(tmpDir, "xdg_home")
+	_ = os.Setenv("XDG_DATA_HOME", xdgHome)
+	defer func() {
+		_ = os.Unsetenv("XDG_DATA_HOME")
+	}()
+
+	// 1. Setup mock Git remote repo that serves partial clones
+	remoteRepoDir := filepath.Join(tmpDir, "remote_repo")
+	if err := os.MkdirAll(remoteRepoDir, 0755); err != nil {
+		t.Fatalf("failed to create mock remote dir: %v", err)
+	}
+
+	_ = runGitCmdInDir(t, remoteRepoDir, "init", "--initial-branch=main")
+	_ = runGitCmdInDir(t, remoteRepoDir, "config", "user.email", "test@hariti.io")
+	_ = runGitCmdInDir(t, remoteRepoDir, "config", "user.name", "Test Hariti")
+	_ = runGitCmdInDir(t, remoteRepoDir, "config", "uploadpack.allowFilter", "true")
+	commitFile := func(content string) string {
+		if err := os.WriteFile(filepath.Join(remoteRepoDir, "version.txt"), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write version.txt: %v", err)
+		}
+		_ = runGitCmdInDir(t, remoteRepoDir, "add", "version.txt")
+		_ = runGitCmdInDir(t, remoteRepoDir, "commit", "-m", content)
+		return runGitCmdInDir(t, remoteRepoDir, "rev-parse", "HEAD")
+	}
+	rev1 := commitFile("v1")
+	_ = runGitCmdInDir(t, remoteRepoDir, "tag", "-a", "v1.0.0", "-m", "v1.0.0")
+
+	remoteURL, err := url.Parse("file://" + filepath.ToSlash(remoteRepoDir))
+	if err != nil {
+		t.Fatalf("failed to parse remote URL: %v", err)
+	}
+
+	newBundle := func(id string, ref graph.Ref, clone graph.Clone) graph.Bundle {
+		return graph.Bundle{
+			ID: id,
+			Source: graph.Source{
+				Type: graph.SourceTypeRemote,
+				URL:  remoteURL,
+				Path: filepath.Join(xdgHome, "hariti", "repos", url.QueryEscape(id)),
+			},
+			Ref:   ref,
+			Clone: clone,
+		}
+	}
+	shallow := newBundle("my-shallow-plugin", graph.Ref{}, graph.Clone{Strategy: graph.CloneStrategyShallow})
+	cacheRepoPath := shallow.Source.Path
+	g := &graph.Graph{Bundles: []graph.Bundle{shallow}}
+
+	cfg := &hariti.HaritiConfig{
+		Paths: hariti.Paths{
+			ConfigFile: filepath.Join(tmpDir, "hariti_home", "bundles.hariti"),
+			ConfigDir:  filepath.Join(tmpDir, "hariti_home"),
+			DataDir:    filepath.Join(xdgHome, "hariti"),
+		},
+		Writer:    io.Discard,
+		ErrWriter: io.Discard,
+	}
+	har := hariti.NewHariti(cfg)
+
+	ctx := context.Background()
+	ctx = vcs.WithWriter(ctx, io.Discard)
+	ctx = vcs.WithErrWriter(ctx, io.Discard)
+
+	// Step 1: A shallow bundle keeps a single commit and locks rev1
+	if _, err := har.Sync(ctx, g, hariti.SyncOptions{}); err != nil {
+		t.Fatalf("initial Sync failed: %v", err)
+	}
+	if shallowRepo := runGitCmdInDir(t, cacheRepoPath, "rev-parse", "--is-shallow-repository"); shallowRepo != "true" {
+		t.Errorf("expected shallow repository cache, got is-shallow-repository=%s", shallowRepo)
+	}
+
+	// Step 2: A frozen sync from an empty cache fetches the locked revision
+	// beyond the shallow boundary
+	rev2 := commitFile("v2")
+	_ = commitFile("v3")
+	if err := os.RemoveAll(cacheRepoPath); err != nil {
+		t.Fatalf("failed to remove cache repo: %v", err)
+	}
+	if _, err := har.Sync(ctx, g, hariti.SyncOptions{FromLockfile: true}); err != nil {
+		t.Fatalf("frozen Sync of shallow bundle failed: %v", err)
+	}
+	if head := runGitCmdInDir(t, cacheRepoPath, "rev-parse", "HEAD"); head != rev1 {
+		t.Errorf("expected cache HEAD %s, got %s", rev1, head)
+	}
+	if count := runGitCmdInDir(t, cacheRepoPath, "rev-list", "--count", "--all"); count != "2" {
+		t.Errorf("expected shallow cache to hold 2 commits, got %s", count)
+	}
+
+	// Step 3: Archive fetches a revision that the shallow cache does not hold
+	if err := exec.Command("git", "-C", cacheRepoPath, "cat-file", "-e", rev2+"^{commit}").Run(); err == nil {
+		t.Fatalf("expected revision %s to be missing from the shallow cache", rev2)
+	}
+	v := vcs.Detect(remoteURL)
+	if v == nil {
+		t.Fatalf("failed to detect VCS for %s", remoteURL)
+	}
+	destDir := filepath.Join(tmpDir, "export")
+	if err := v.Archive(ctx, shallow, rev2, destDir); err != nil {
+		t.Fatalf("Archive of missing revision failed: %v", err)
+	}
+	if content, err := os.ReadFile(filepath.Join(destDir, "version.txt")); err != nil || string(content) != "v2" {
+		t.Errorf("expected exported version.txt to contain v2, got %q (err: %v)", content, err)
+	}
+
+	// Step 4: Changing the strategy through the global default re-clones the cache
+	g.Bundles[0].Clone = graph.Clone{}
+	if _, err := har.Sync(ctx, g, hariti.SyncOptions{Clone: graph.Clone{Strategy: graph.CloneStrategyBlobless}}); err != nil {
+		t.Fatalf("blobless Sync failed: %v", err)
+	}
+	if shallowRepo := runGitCmdInDir(t, cacheRepoPath, "rev-parse", "--is-shallow-repository"); shallowRepo != "false" {
+		t.Errorf("expected re-cloned cache not to be shallow, got is-shallow-repository=%s", shallowRepo)
+	}
+	if filter := runGitCmdInDir(t, cacheRepoPath, "config", "remote.origin.partialclonefilter"); filter != "blob:none" {
+		t.Errorf("expected blobless partial clone filter, got %q", filter)
+	}
+
+	// Step 5: A shallow clone resolves a tag outside its history
+	tagged := &graph.Graph{
+		Bundles: []graph.Bundle{
+			newBundle("my-tagged-plugin", graph.Ref{Type: graph.RefTypeTag, Name: "v1.0.0"}, graph.Clone{Strategy: graph.CloneStrategyShallow}),
+		},
+	}
+	facts, err := har.Sync(ctx, tagged, hariti.SyncOptions{})
+	if err != nil {
+		t.Fatalf("shallow tag Sync failed: %v", err)
+	}
+	if facts[0].Revision != rev1 {
+		t.Errorf("expected tagged revision %s, got %s", rev1, facts[0].Revision)
+	}
+
+	// Step 6: Invalid global strategies are rejected
+	_, err = har.Sync(ctx, g, hariti.SyncOptions{Clone: graph.Clone{Strategy: graph.CloneStrategyFull, Depth: 3}})
+	if err == nil || !strings.Contains(err.Error(), "invalid clone option") {
+		t.Errorf("expected invalid clone option error, got: %v", err)
+	}
+}
+
+func TestHariti_Sync_Only(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	xdgHome := filepath.Join(tmpDir, "xdg_home")
+	_ = os.Setenv("XDG_DATA_HOME", xdgHome)
+	defer func() {
+		_ = os.Unsetenv("XDG_DATA_HOME")
+	}()
+
+	// 1. Setup 2 mock Git remote repositories
+	bundleIDs := []string{"my-selected-plugin", "my-pinned-plugin"}
+	remoteDirs := make(map[string]string)
+	g := &graph.Graph{}
+	for _, id := range bundleIDs {
+		remoteRepoDir := filepath.Join(tmpDir, "remote_"+id)
+		if err := os.MkdirAll(remoteRepoDir, 0755); err != nil {
+			t.Fatalf("failed to create mock remote dir: %v", err)
+		}
+		_ = runGitCmdInDir(t, remoteRepoDir, "init", "--initial-branch=main")
+		_ = runGitCmdInDir(t, remoteRepoDir, "config", "user.email", "test@hariti.io")
+		_ = runGitCmdInDir(t, remoteRepoDir, "config", "user.name", "Test Hariti")
+		_ = runGitCmdInDir(t, remoteRepoDir, "commit", "--allow-empty", "-m", "initial commit")
+		remoteDirs[id] = remoteRepoDir
+
+		remoteURL, err := url.Parse("file://" + filepath.ToSlash(remoteRepoDir))
+		if err != nil {
+			t.Fatalf("failed to parse remote URL: %v", err)
+		}
+		g.Bundles = append(g.Bundles, graph.Bundle{
+			ID: id,
+			Source: graph.Source{
+				Type: graph.SourceTypeRemote,
+				URL:  remoteURL,
+				Path: filepath.Join(xdgHome, "hariti", "repos", url.QueryEscape(id)),
+			},
+			Aliases: []string{strings.TrimPrefix(id, "my-")},
+		})
+	}
+
+	cfg := &hariti.HaritiConfig{
+		Paths: hariti.Paths{
+			ConfigFile: filepath.Join(tmpDir, "hariti_home", "bundles.hariti"),
+			ConfigDir:  filepath.Join(tmpDir, "hariti_home"),
+			DataDir:    filepath.Join(xdgHome, "hariti"),
+		},
+		Writer:    io.Discard,
+		ErrWriter: io.Discard,
+	}
+	har := hariti.NewHariti(cfg)
+
+	ctx := context.Background()
+	ctx = vcs.WithWriter(ctx, io.Discard)
+	ctx = vcs.WithErrWriter(ctx, io.Discard)
+
+	// Step 1: Full sync locks the initial revisions
+	if _, err := har.Sync(ctx, g, hariti.SyncOptions{}); err != nil {
+		t.Fatalf("initial Sync failed: %v", err)
+	}
+	readLock := func() map[string]string {
+		data, err := os.ReadFile(har.LockfilePath())
+		if err != nil {
+			t.Fatalf("failed to read lockfile: %v", err)
+		}
+		var lock hariti.Lockfile
+		if err := json.Unmarshal(data, &lock); err != nil {
+			t.Fatalf("failed to parse lockfile: %v", err)
+		}
+		revisions := make(map[string]string)
+		for _, entry := range lock.Bundles {
+			revisions[entry.ID] = entry.Revision
+		}
+		return revisions
+	}
+	lockBefore := readLock()
+
+	// Step 2: Advance both remotes, and move the pinned cache forward behind hariti's back
+	for _, id := range bundleIDs {
+		_ = runGitCmdInDir(t, remoteDirs[id], "commit", "--allow-empty", "-m", "remote advance commit")
+	}
+	pinnedCache := filepath.Join(xdgHome, "hariti", "repos", url.QueryEscape("my-pinned-plugin"))
+	_ = runGitCmdInDir(t, pinnedCache, "fetch", "--all")
+	_ = runGitCmdInDir(t, pinnedCache, "reset", "--hard", "@{upstream}")
+
+	// Step 3: Update only the selected bundle, addressed by alias
+	if _, err := har.Sync(ctx, g, hariti.SyncOptions{Only: []string{"selected-plugin"}}); err != nil {
+		t.Fatalf("selective Sync failed: %v", err)
+	}
+
+	lockAfter := readLock()
+	if expected := runGitCmdInDir(t, remoteDirs["my-selected-plugin"], "rev-parse", "HEAD"); lockAfter["my-selected-plugin"] != expected {
+		t.Errorf("expected selected bundle to be locked at %s, got %s", expected, lockAfter["my-selected-plugin"])
+	}
+	if lockAfter["my-pinned-plugin"] != lockBefore["my-pinned-plugin"] {
+		t.Errorf("expected pinned bundle to stay locked at %s, got %s", lockBefore["my-pinned-plugin"], lockAfter["my-pinned-plugin"])
+	}
+	if head := runGitCmdInDir(t, pinnedCache, "rev-parse", "HEAD"); head != lockBefore["my-pinned-plugin"] {
+		t.Errorf("expected pinned cache HEAD %s, got %s", lockBefore["my-pinned-plugin"], head)
+	}
+
+	// Step 4: Unknown names are rejected
+	_, err := har.Sync(ctx, g, hariti.SyncOptions{Only: []string{"no-such-plugin"}})
+	if err == nil || !strings.Contains(err.Error(), "no bundle matches no-such-plugin") {
+		t.Errorf("expected unknown bundle error, got: %v", err)
+	}
+
+	// Step 5: A bundle added since the last sync is not locked yet, so it is
+	// synced along with the selected one instead of failing the update
+	newRemoteDir := filepath.Join(tmpDir, "remote_my-new-plugin")
+	if err := os.MkdirAll(newRemoteDir, 0755); err != nil {
+		t.Fatalf("failed to create mock remote dir: %v", err)
+	}
+	_ = runGitCmdInDir(t, newRemoteDir, "init", "--initial-branch=main")
+	_ = runGitCmdInDir(t, newRemoteDir, "config", "user.email", "test@hariti.io")
+	_ = runGitCmdInDir(t, newRemoteDir, "config", "user.name", "Test Hariti")
+	_ = runGitCmdInDir(t, newRemoteDir, "commit", "--allow-empty", "-m", "initial commit")
+	newRemoteURL, err := url.Parse("file://" + filepath.ToSlash(newRemoteDir))
+	if err != nil {
+		t.Fatalf("failed to parse remote URL: %v", err)
+	}
+	g.Bundles = append(g.Bundles, graph.Bundle{
+		ID: "my-new-plugin",
+		Source: graph.Source{
+			Type: graph.SourceTypeRemote,
+			URL:  newRemoteURL,
+			Path: filepath.Join(xdgHome, "hariti", "repos", url.QueryEscape("my-new-plugin")),
+		},
+	})
+	if _, err := har.Sync(ctx, g, hariti.SyncOptions{Only: []string{"selected-plugin"}}); err != nil {
+		t.Fatalf("selective Sync with an unlocked bundle failed: %v", err)
+	}
+	lockAfter = readLock()
+	if expected := runGitCmdInDir(t, newRemoteDir, "rev-parse", "HEAD"); lockAfter["my-new-plugin"] != expected {
+		t.Errorf("expected unlocked bundle to be locked at %s, got %s", expected, lockAfter["my-new-plugin"])
+	}
+	if lockAfter["my-pinned-plugin"] != lockBefore["my-pinned-plugin"] {
+		t.Errorf("expected pinned bundle to stay locked at %s, got %s", lockBefore["my-pinned-plugin"], lockAfter["my-pinned-plugin"])
+	}
+}
+
+func TestHariti_Sync_KeepGoing(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	xdgHome := filepath.Join(tmpDir, "xdg_home")
+	_ = os.Setenv("XDG_DATA_HOME", xdgHome)
+	defer func() {
+		_ = os.Unsetenv("XDG_DATA_HOME")
+	}()
+
+	// 1. Setup 2 mock Git remote repositories
+	bundleIDs := []string{"my-healthy-plugin", "my-broken-plugin"}
+	remoteDirs := make(map[string]string)
+	g := &graph.Graph{}
+	for _, id := range bundleIDs {
+		remoteRepoDir := filepath.Join(tmpDir, "remote_"+id)
+		if err := os.MkdirAll(remoteRepoDir, 0755); err != nil {
+			t.Fatalf("failed to create mock remote dir: %v", err)
+		}
+		_ = runGitCmdInDir(t, remoteRepoDir, "init", "--initial-branch=main")
+		_ = runGitCmdInDir(t, remoteRepoDir, "config", "user.email", "test@hariti.io")
+		_ = runGitCmdInDir(t, remoteRepoDir, "config", "user.name", "Test Hariti")
+		_ = runGitCmdInDir(t, remoteRepoDir, "commit", "--allow-empty", "-m", "initial commit")
+		remoteDirs[id] = remoteRepoDir
+
+		remoteURL, err := url.Parse("file://" + filepath.ToSlash(remoteRepoDir))
+		if err != nil {
+			t.Fatalf("failed to parse remote URL: %v", err)
+		}
+		g.Bundles = append(g.Bundles, graph.Bundle{
+			ID: id,
+			Source: graph.Source{
+				Type: graph.SourceTypeRemote,
+				URL:  remoteURL,
+				Path: filepath.Join(xdgHome, "hariti", "repos", url.QueryEscape(id)),
+			},
+		})
+	}
+
+	cfg := &hariti.HaritiConfig{
+		Paths: hariti.Paths{
+			ConfigFile: filepath.Join(tmpDir, "hariti_home", "bundles.hariti"),
+			ConfigDir:  filepath.Join(tmpDir, "hariti_home"),
+			DataDir:    filepath.Join(xdgHome, "hariti"),
+		},
+		Writer:    io.Discard,
+		ErrWriter: io.Discard,
+	}
+	har := hariti.NewHariti(cfg)
+
+	ctx := context.Background()
+	ctx = vcs.WithWriter(ctx, io.Discard)
+	ctx = vcs.WithErrWriter(ctx, io.Discard)
+
+	// Step 1: Initial sync locks both bundles
+	facts, err := har.Sync(ctx, g, hariti.SyncOptions{})
+	if err != nil {
+		t.Fatalf("initial Sync failed: %v", err)
+	}
+	brokenRev := facts[1].Revision
+
+	// Step 2: Advance both remotes and corrupt the cache of one bundle
+	for _, id := range bundleIDs {
+		_ = runGitCmdInDir(t, remoteDirs[id], "commit", "--allow-empty", "-m", "remote advance commit")
+	}
+	healthyRev := runGitCmdInDir(t, remoteDirs["my-healthy-plugin"], "rev-parse", "HEAD")
+	brokenGitDir := filepath.Join(xdgHome, "hariti", "repos", url.QueryEscape("my-broken-plugin"), ".git")
+	if err := os.RemoveAll(brokenGitDir); err != nil {
+		t.Fatalf("failed to remove git dir: %v", err)
+	}
+	if err := os.WriteFile(brokenGitDir, []byte("garbage"), 0644); err != nil {
+		t.Fatalf("failed to corrupt git dir: %v", err)
+	}
+
+	// Step 3: Keep-going sync reports the failure and updates the healthy bundle
+	facts, err = har.Sync(ctx, g, hariti.SyncOptions{KeepGoing: true, Parallelism: 1})
+	var syncErr *hariti.SyncError
+	if !errors.As(err, &syncErr) {
+		t.Fatalf("expected *hariti.SyncError, got: %v", err)
+	}
+	if len(syncErr.Failures) != 1 || syncErr.Failures[0].BundleID != "my-broken-plugin" {
+		t.Fatalf("expected my-broken-plugin to be the only failure, got %+v", syncErr.Failures)
+	}
+	if !strings.Contains(syncErr.Failures[0].Output, "fatal:") || !strings.Contains(err.Error(), "fatal:") {
+		t.Errorf("expected aggregated error to contain captured git output, got: %v", err)
+	}
+	if facts[0].Revision != healthyRev {
+		t.Errorf("expected healthy bundle revision %s, got %s", healthyRev, facts[0].Revision)
+	}
+
+	data, err := os.ReadFile(har.LockfilePath())
+	if err != nil {
+		t.Fatalf("failed to read lockfile: %v", err)
+	}
+	var lock hariti.Lockfile
+	if err := json.Unmarshal(data, &lock); err != nil {
+		t.Fatalf("failed to parse lockfile: %v", err)
+	}
+	if len(lock.Bundles) != 2 || lock.Bundles[0].Revision != healthyRev || lock.Bundles[1].Revision != brokenRev {
+		t.Errorf("expected lockfile revisions [%s %s], got %+v", healthyRev, brokenRev, lock.Bundles)
+	}
+
+	// Step 4: Without keep-going the failure aborts the sync
+	if _, err := har.Sync(ctx, g, hariti.SyncOptions{}); err == nil || errors.As(err, &syncErr) {
+		t.Errorf("expected a plain sync error, got: %v", err)
+	}
+}
+
+var (
+	errTransient = errors.New("transient failure")
+
+	fakeVCSCount int32
+)
+
+// registerFlakyVCS registers f under a URL scheme of its own, so that repeated
+// test runs never hit an instance with consumed state.
+func registerFlakyVCS(f *flakyVCS) string {
+	f.scheme = fmt.Sprintf("flaky%d", atomic.AddInt32(&fakeVCSCount, 1))
+	vcs.Register(f)
+	return f.scheme
+}
+
+// flakyVCS fails the first attempts of every bundle listed in failures. Errors
+// are retryable unless the bundle is listed in permanent. Bundles listed in
+// hang block in Sync, and those listed in hangHash in TreeHash, until their
+// context is done.
+type flakyVCS struct {
+	scheme    string
+	mu        sync.Mutex
+	failures  map[string]int
+	permanent map[string]bool
+	hang      map[string]bool
+	hangHash  map[string]bool
+}
+
+func (f *flakyVCS) CanHandle(c context.Context, u *url.URL) bool {
+	return u != nil && u.Scheme == f.scheme
+}
+
+func (f *flakyVCS) Sync(c context.Context, bundle graph.Bundle) error {
+	if f.hang[bundle.ID] {
+		<-c.Done()
+		return c.Err()
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failures[bundle.ID] > 0 {
+		f.failures[bundle.ID]--
+		_, _ = fmt.Fprintln(vcs.ErrWriterFromContext(c), "fatal: connection reset")
+		if f.permanent[bundle.ID] {
+			return errors.New("permanent failure")
+		}
+		return fmt.Errorf("fetch failed: %w", errTransient)
+	}
+	return os.MkdirAll(bundle.Source.Path, 0755)
+}
+
+func (f *flakyVCS) Checkout(c context.Context, bundle graph.Bundle, revision string) error {
+	return f.Sync(c, bundle)
+}
+
+func (f *flakyVCS) HeadRevision(c context.Context, bundle graph.Bundle) (string, error) {
+	return "0123456789abcdef0123456789abcdef01234567", nil
+}
+
+func (f *flakyVCS) RemoteRevision(c context.Context, bundle graph.Bundle) (string, error) {
+	return f.HeadRevision(c, bundle)
+}
+
+func (f *flakyVCS) CountCommits(c context.Context, bundle graph.Bundle, from, to string) (int, error) {
+	return -1, nil
+}
+
+func (f *flakyVCS) Log(c context.Context, bundle graph.Bundle, from, to string) ([]vcs.Commit, error) {
+	return nil, nil
+}
+
+func (f *flakyVCS) TreeHash(c context.Context, bundle graph.Bundle, revision string) (string, error) {
+	if f.hangHash[bundle.ID] {
+		<-c.Done()
+		return "", c.Err()
+	}
+	return "89abcdef0123456789abcdef0123456789abcdef", nil
+}
+
+func (f *flakyVCS) Archive(c context.Context, bundle graph.Bundle, revision string, destDir string) error {
+	return nil
+}
+
+func (f *flakyVCS) IsRetryable(err error) bool {
+	return errors.Is(err, errTransient)
+}
+
+func TestHariti_Sync_Retries(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	xdgHome := filepath.Join(tmpDir, "xdg_home")
+	_ = os.Setenv("XDG_DATA_HOME", xdgHome)
+	defer func() {
+		_ = os.Unsetenv("XDG_DATA_HOME")
+	}()
+
+	flaky := &flakyVCS{
+		failures: map[string]int{
+			"my-recovering-plugin": 2,
+			"my-exhausted-plugin":  5,
+			"my-permanent-plugin":  1,
+		},
+		permanent: map[string]bool{
+			"my-permanent-plugin": true,
+		},
+	}
+	scheme := registerFlakyVCS(flaky)
+
+	newGraph := func(id string) *graph.Graph {
+		return &graph.Graph{
+			Bundles: []graph.Bundle{
+				{
+					ID: id,
+					Source: graph.Source{
+						Type: graph.SourceTypeRemote,
+						URL:  &url.URL{Scheme: scheme, Host: "example", Path: "/" + id},
+						Path: filepath.Join(xdgHome, "hariti", "repos", url.QueryEscape(id)),
+					},
+				},
+			},
+		}
+	}
+
+	cfg := &hariti.HaritiConfig{
+		Paths: hariti.Paths{
+			ConfigFile: filepath.Join(tmpDir, "hariti_home", "bundles.hariti"),
+			ConfigDir:  filepath.Join(tmpDir, "hariti_home"),
+			DataDir:    filepath.Join(xdgHome, "hariti"),
+		},
+		Writer:    io.Discard,
+		ErrWriter: io.Discard,
+	}
+	har := hariti.NewHariti(cfg)
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	var retries []hariti.SyncProgressEvent
+	opts := hariti.SyncOptions{
+		Retries:      2,
+		RetryBackoff: time.Millisecond,
+		OnProgress: func(event hariti.SyncProgressEvent) {
+			if event.Type == hariti.SyncEventBundleRetrying {
+				mu.Lock()
+				defer mu.Unlock()
+				retries = append(retries, event)
+			}
+		},
+	}
+
+	// Case 1: Transient failures within the retry budget succeed
+	if _, err := har.Sync(ctx, newGraph("my-recovering-plugin"), opts); err != nil {
+		t.Fatalf("expected retried Sync to succeed, got: %v", err)
+	}
+	if len(retries) != 2 || retries[0].Attempt != 2 || retries[1].Attempt != 3 {
+		t.Errorf("expected retrying events for attempts 2 and 3, got %+v", retries)
+	}
+	if len(retries) > 0 && !strings.Contains(retries[0].Output, "fatal: connection reset") {
+		t.Errorf("expected retrying event to carry the attempt output, got %q", retries[0].Output)
+	}
+
+	// Case 2: The last error is returned once the retries are exhausted
+	retries = nil
+	_, err := har.Sync(ctx, newGraph("my-exhausted-plugin"), opts)
+	if !errors.Is(err, errTransient) {
+		t.Errorf("expected transient error after exhausting retries, got: %v", err)
+	}
+	if len(retries) != 2 {
+		t.Errorf("expected 2 retrying events, got %d", len(retries))
+	}
+
+	// Case 3: Errors the VCS does not classify as retryable are not retried
+	retries = nil
+	if _, err := har.Sync(ctx, newGraph("my-permanent-plugin"), opts); err == nil {
+		t.Error("expected permanent failure")
+	}
+	if len(retries) != 0 {
+		t.Errorf("expected no retrying events, got %d", len(retries))
+	}
+}
+
+func TestHariti_Sync_BundleTimeout(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	xdgHome := filepath.Join(tmpDir, "xdg_home")
+	_ = os.Setenv("XDG_DATA_HOME", xdgHome)
+	defer func() {
+		_ = os.Unsetenv("XDG_DATA_HOME")
+	}()
+
+	scheme := registerFlakyVCS(&flakyVCS{
+		hang: map[string]bool{
+			"my-hanging-plugin": true,
+		},
+	})
+
+	g := &graph.Graph{}
+	for _, id := range []string{"my-hanging-plugin", "my-quick-plugin"} {
+		g.Bundles = append(g.Bundles, graph.Bundle{
+			ID: id,
+			Source: graph.Source{
+				Type: graph.SourceTypeRemote,
+				URL:  &url.URL{Scheme: scheme, Host: "example", Path: "/" + id},
+				Path: filepath.Join(xdgHome, "hariti", "repos", url.QueryEscape(id)),
+			},
+		})
+	}
+
+	cfg := &hariti.HaritiConfig{
+		Paths: hariti.Paths{
+			ConfigFile: filepath.Join(tmpDir, "hariti_home", "bundles.hariti"),
+			ConfigDir:  filepath.Join(tmpDir, "hariti_home"),
+			DataDir:    filepath.Join(xdgHome, "hariti"),
+		},
+		Writer:    io.Discard,
+		ErrWriter: io.Discard,
+	}
+	har := hariti.NewHariti(cfg)
+
+	facts, err := har.Sync(context.Background(), g, hariti.SyncOptions{
+		BundleTimeout: 100 * time.Millisecond,
+		KeepGoing:     true,
+	})
+
+	var timeoutErr *hariti.TimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("expected *hariti.TimeoutError, got: %v", err)
+	}
+	if timeoutErr.BundleID != "my-hanging-plugin" || timeoutErr.Phase != hariti.PhaseSync || timeoutErr.Timeout != 100*time.Millisecond {
+		t.Errorf("unexpected timeout error: %+v", timeoutErr)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected timeout error to match context.DeadlineExceeded, got: %v", err)
+	}
+	if facts[1].Revision == "" {
+		t.Error("expected the quick bundle to be synced despite the hanging one")
+	}
+}
+
+func TestHariti_Sync_BundleTimeout_Hash(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	xdgHome := filepath.Join(tmpDir, "xdg_home")
+	_ = os.Setenv("XDG_DATA_HOME", xdgHome)
+	defer func() {
+		_ = os.Unsetenv("XDG_DATA_HOME")
+	}()
+
+	scheme := registerFlakyVCS(&flakyVCS{
+		hangHash: map[string]bool{
+			"my-hanging-plugin": true,
+		},
+	})
+
+	g := &graph.Graph{
+		Bundles: []graph.Bundle{
+			{
+				ID: "my-hanging-plugin",
+				Source: graph.Source{
+					Type: graph.SourceTypeRemote,
+					URL:  &url.URL{Scheme: scheme, Host: "example", Path: "/my-hanging-plugin"},
+					Path: filepath.Join(xdgHome, "hariti", "repos", "my-hanging-plugin"),
+				},
+			},
+		},
+	}
+
+	cfg := &hariti.HaritiConfig{
+		Paths: hariti.Paths{
+			ConfigFile: filepath.Join(tmpDir, "hariti_home", "bundles.hariti"),
+			ConfigDir:  filepath.Join(tmpDir, "hariti_home"),
+			DataDir:    filepath.Join(xdgHome, "hariti"),
+		},
+		Writer:    io.Discard,
+		ErrWriter: io.Discard,
+	}
+	har := hariti.NewHariti(cfg)
+
+	_, err := har.Sync(context.Background(), g, hariti.SyncOptions{
+		BundleTimeout: 100 * time.Millisecond,
+	})
+
+	var timeoutErr *hariti.TimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("expected *hariti.TimeoutError, got: %v", err)
+	}
+	if timeoutErr.Phase != hariti.PhaseHash {
+		t.Errorf("expected timeout during %s, got %s", hariti.PhaseHash, timeoutErr.Phase)
+	}
+}
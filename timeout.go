@@ -0,0 +1,53 @@
+package hariti
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Phase names the step a bundle was in when it failed.
+type Phase string
+
+const (
+	PhaseSync     Phase = "sync"
+	PhaseCheckout Phase = "checkout"
+	PhaseHash     Phase = "hash"
+	PhaseArchive  Phase = "archive"
+	PhaseBuild    Phase = "build"
+	PhaseHelptags Phase = "helptags"
+)
+
+// TimeoutError reports a bundle that exceeded its time budget.
+type TimeoutError struct {
+	BundleID string
+	Phase    Phase
+	Timeout  time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("bundle %s timed out after %s during %s", e.BundleID, e.Timeout, e.Phase)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// errBundleTimeout is the cancellation cause of a per-bundle deadline. It tells
+// a bundle timeout apart from the cancellation of the caller's context.
+var errBundleTimeout = errors.New("bundle timeout exceeded")
+
+// withBundleTimeout derives a context that expires after timeout, or returns
+// ctx unchanged when timeout is not positive.
+func withBundleTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeoutCause(ctx, timeout, errBundleTimeout)
+}
+
+// isBundleTimeout reports whether ctx was cancelled by withBundleTimeout.
+func isBundleTimeout(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), errBundleTimeout)
+}
@@ -4,13 +4,16 @@ import (
 	"archive/tar"
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/kamichidu/go-hariti/graph"
 	"github.com/kamichidu/go-hariti/vcs"
@@ -18,7 +21,38 @@ import (
 
 type Git struct{}
 
-func runCmd(ctx context.Context, cmd *exec.Cmd) error {
+// commandError keeps the stderr of a failed git command for classification.
+type commandError struct {
+	err    error
+	stderr string
+}
+
+func (e *commandError) Error() string {
+	return e.err.Error()
+}
+
+func (e *commandError) Unwrap() error {
+	return e.err
+}
+
+// runCmd runs cmd until it exits or ctx is done. When stderr is not nil, the
+// stderr of the command is also captured into it, and a failure is reported
+// as a *commandError carrying it for classification.
+func runCmd(ctx context.Context, cmd *exec.Cmd, stderr *bytes.Buffer) error {
+	if stderr != nil {
+		// Teeing stderr gives exec two distinct writers, which it copies into
+		// concurrently even when both streams go to the same writer
+		var mu sync.Mutex
+		if cmd.Stdout != nil {
+			cmd.Stdout = &lockedWriter{mu: &mu, w: cmd.Stdout}
+		}
+		if cmd.Stderr != nil {
+			cmd.Stderr = &lockedWriter{mu: &mu, w: io.MultiWriter(cmd.Stderr, stderr)}
+		} else {
+			cmd.Stderr = &lockedWriter{mu: &mu, w: stderr}
+		}
+	}
+
 	errCh := make(chan error, 1)
 	go func() {
 		errCh <- cmd.Run()
@@ -31,10 +65,56 @@ func runCmd(ctx context.Context, cmd *exec.Cmd) error {
 		}
 		return ctx.Err()
 	case err := <-errCh:
+		if err != nil && stderr != nil {
+			return &commandError{err: err, stderr: stderr.String()}
+		}
 		return err
 	}
 }
 
+// lockedWriter serializes the writes of the output streams of a command that
+// share a mutex.
+type lockedWriter struct {
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
+
+// retryableMessages are fragments of git stderr output that indicate a
+// transient network failure or lock contention.
+// Messages git also prints for permanent failures, such as "could not read
+// from remote repository" after an authentication failure, are left out.
+var retryableMessages = []string{
+	"could not resolve host",
+	"temporary failure in name resolution",
+	"connection timed out",
+	"operation timed out",
+	"connection reset",
+	"early eof",
+	"the requested url returned error: 429",
+	"the requested url returned error: 5",
+	".lock': file exists",
+}
+
+func (g *Git) IsRetryable(err error) bool {
+	var cmdErr *commandError
+	if !errors.As(err, &cmdErr) {
+		return false
+	}
+	stderr := strings.ToLower(cmdErr.stderr)
+	for _, msg := range retryableMessages {
+		if strings.Contains(stderr, msg) {
+			return true
+		}
+	}
+	return false
+}
+
 func (g *Git) Sync(c context.Context, bundle graph.Bundle) error {
 	log := vcs.LoggerFromContext(c)
 	out := vcs.WriterFromContext(c)
@@ -51,33 +131,64 @@ func (g *Git) Sync(c context.Context, bundle graph.Bundle) error {
 	}
 
 	if info, err := os.Stat(localPath); err != nil {
-		if log != nil {
-			log.Debugf("Cloning %s to %s\n", urlStr, localPath)
-		}
-		cmd := exec.Command("git", "clone", "--recursive", urlStr, localPath)
-		cmd.Stdout = out
-		cmd.Stderr = errOut
-		if err := runCmd(c, cmd); err != nil {
+		if err := cloneRepository(c, bundle); err != nil {
 			return err
 		}
+		// A fresh clone already sits on the default branch
+		if bundle.Ref.Type == "" {
+			return nil
+		}
 	} else if info.IsDir() {
 		if log != nil {
 			log.Debugf("Fetching and hard resetting in %s", localPath)
 		}
 
 		// 1. Fetch all
+		fetchArgs := []string{"fetch", "--all", "--prune"}
+		if bundle.Clone.Strategy == graph.CloneStrategyShallow {
+			fetchArgs = append(fetchArgs, "--depth", strconv.Itoa(shallowDepth(bundle.Clone)))
+		}
 		if log != nil {
-			log.Debugf("Executing git fetch --all --prune in %s", localPath)
+			log.Debugf("Executing git %s in %s", strings.Join(fetchArgs, " "), localPath)
 		}
-		fetchCmd := exec.Command("git", "fetch", "--all", "--prune")
+		fetchCmd := exec.Command("git", fetchArgs...)
 		fetchCmd.Dir = localPath
 		fetchCmd.Stdout = out
 		fetchCmd.Stderr = errOut
-		if err := runCmd(c, fetchCmd); err != nil {
+		var stderr bytes.Buffer
+		if err := runCmd(c, fetchCmd, &stderr); err != nil {
 			return fmt.Errorf("git fetch failed: %w", err)
 		}
+		// A pinned tag may have been moved upstream; it is fetched by name so
+		// the cached tag is replaced instead of rejected
+		if bundle.Ref.Type == graph.RefTypeTag {
+			if err := fetchRef(c, bundle); err != nil {
+				return err
+			}
+		}
+	} else {
+		return nil
+	}
 
-		// 2. Resolve tracked upstream ref
+	// 2. Resolve the configured ref, or the tracked upstream ref when none is configured
+	var target string
+	if bundle.Ref.Type != "" {
+		rev, err := resolveRef(c, localPath, bundle.Ref)
+		if err != nil {
+			// Tags and revisions outside the fetched history, such as those beyond
+			// the boundary of a shallow clone, are fetched by name
+			if bundle.Ref.Type == graph.RefTypeBranch {
+				return err
+			}
+			if err := fetchRef(c, bundle); err != nil {
+				return err
+			}
+			if rev, err = resolveRef(c, localPath, bundle.Ref); err != nil {
+				return err
+			}
+		}
+		target = rev
+	} else {
 		if log != nil {
 			log.Debugf("Resolving tracked upstream ref in %s", localPath)
 		}
@@ -86,49 +197,283 @@ func (g *Git) Sync(c context.Context, bundle graph.Bundle) error {
 		var upstreamStdout bytes.Buffer
 		revParseCmd.Stdout = &upstreamStdout
 		revParseCmd.Stderr = errOut
-		if err := runCmd(c, revParseCmd); err != nil {
+		if err := runCmd(c, revParseCmd, nil); err != nil {
 			return fmt.Errorf("failed to resolve tracked upstream ref: %w", err)
 		}
-		upstream := strings.TrimSpace(upstreamStdout.String())
-		if upstream == "" {
+		target = strings.TrimSpace(upstreamStdout.String())
+		if target == "" {
 			return fmt.Errorf("no tracked upstream ref resolved for branch in %s", localPath)
 		}
+	}
+
+	// 3. Hard reset to resolved ref
+	if log != nil {
+		log.Debugf("Executing git reset --hard %s in %s", target, localPath)
+	}
+	resetCmd := exec.Command("git", "reset", "--hard", target)
+	resetCmd.Dir = localPath
+	resetCmd.Stdout = out
+	resetCmd.Stderr = errOut
+	var stderr bytes.Buffer
+	if err := runCmd(c, resetCmd, &stderr); err != nil {
+		return fmt.Errorf("git reset to %s failed: %w", target, err)
+	}
 
-		// 3. Hard reset to resolved upstream ref
+	// 4. Update submodules conditionally if .gitmodules exists
+	gitmodules := filepath.Join(localPath, ".gitmodules")
+	if _, err := os.Stat(gitmodules); err == nil {
 		if log != nil {
-			log.Debugf("Executing git reset --hard %s in %s", upstream, localPath)
+			log.Debugf("Updating submodules in %s", localPath)
 		}
-		resetCmd := exec.Command("git", "reset", "--hard", upstream)
-		resetCmd.Dir = localPath
-		resetCmd.Stdout = out
-		resetCmd.Stderr = errOut
-		if err := runCmd(c, resetCmd); err != nil {
-			return fmt.Errorf("git reset to %s failed: %w", upstream, err)
+		submoduleCmd := exec.Command("git", "submodule", "update", "--init", "--recursive")
+		submoduleCmd.Dir = localPath
+		submoduleCmd.Stdout = out
+		submoduleCmd.Stderr = errOut
+		var stderr bytes.Buffer
+		if err := runCmd(c, submoduleCmd, &stderr); err != nil {
+			return fmt.Errorf("git submodule update failed: %w", err)
 		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
 
-		// 4. Update submodules conditionally if .gitmodules exists
-		gitmodules := filepath.Join(localPath, ".gitmodules")
-		if _, err := os.Stat(gitmodules); err == nil {
-			if log != nil {
-				log.Debugf("Updating submodules in %s", localPath)
-			}
-			submoduleCmd := exec.Command("git", "submodule", "update", "--init", "--recursive")
-			submoduleCmd.Dir = localPath
-			submoduleCmd.Stdout = out
-			submoduleCmd.Stderr = errOut
-			if err := runCmd(c, submoduleCmd); err != nil {
-				return fmt.Errorf("git submodule update failed: %w", err)
-			}
-		} else if !os.IsNotExist(err) {
+	return nil
+}
+
+// resolveRef resolves a branch, tag or revision pin to a commit hash in the
+// local repository cache. Branches are resolved against the origin remote.
+func resolveRef(c context.Context, localPath string, ref graph.Ref) (string, error) {
+	var expr string
+	switch ref.Type {
+	case graph.RefTypeBranch:
+		expr = "refs/remotes/origin/" + ref.Name
+	case graph.RefTypeTag:
+		expr = "refs/tags/" + ref.Name
+	case graph.RefTypeRevision:
+		expr = ref.Name
+	default:
+		return "", fmt.Errorf("unsupported ref type: %s", ref.Type)
+	}
+
+	cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", expr+"^{commit}")
+	cmd.Dir = localPath
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = io.Discard
+	if err := runCmd(c, cmd, nil); err != nil {
+		return "", fmt.Errorf("failed to resolve %s %s in %s: %w", ref.Type, ref.Name, localPath, err)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+func (g *Git) Checkout(c context.Context, bundle graph.Bundle, revision string) error {
+	log := vcs.LoggerFromContext(c)
+	out := vcs.WriterFromContext(c)
+	errOut := vcs.ErrWriterFromContext(c)
+
+	localPath := bundle.Source.Path
+
+	if log != nil {
+		log.Debugf("Git checkout started for bundle %s at revision %s in local path %q", bundle.ID, revision, localPath)
+	}
+
+	// 1. Clone when the cache does not exist yet
+	if _, err := os.Stat(localPath); err != nil {
+		if err := cloneRepository(c, bundle); err != nil {
 			return err
 		}
+	}
+
+	// 2. Fetch only when the revision is not present in the cache
+	if err := ensureRevision(c, bundle, revision); err != nil {
+		return err
+	}
+
+	// 3. Hard reset to the requested revision
+	if log != nil {
+		log.Debugf("Executing git reset --hard %s in %s", revision, localPath)
+	}
+	resetCmd := exec.Command("git", "reset", "--hard", revision)
+	resetCmd.Dir = localPath
+	resetCmd.Stdout = out
+	resetCmd.Stderr = errOut
+	var stderr bytes.Buffer
+	if err := runCmd(c, resetCmd, &stderr); err != nil {
+		return fmt.Errorf("git reset to %s failed: %w", revision, err)
+	}
+
+	// 4. Update submodules conditionally if .gitmodules exists
+	gitmodules := filepath.Join(localPath, ".gitmodules")
+	if _, err := os.Stat(gitmodules); err == nil {
+		if log != nil {
+			log.Debugf("Updating submodules in %s", localPath)
+		}
+		submoduleCmd := exec.Command("git", "submodule", "update", "--init", "--recursive")
+		submoduleCmd.Dir = localPath
+		submoduleCmd.Stdout = out
+		submoduleCmd.Stderr = errOut
+		var stderr bytes.Buffer
+		if err := runCmd(c, submoduleCmd, &stderr); err != nil {
+			return fmt.Errorf("git submodule update failed: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// cloneRepository clones the bundle's repository into its cache path using the
+// bundle's clone strategy.
+func cloneRepository(c context.Context, bundle graph.Bundle) error {
+	log := vcs.LoggerFromContext(c)
+	out := vcs.WriterFromContext(c)
+	errOut := vcs.ErrWriterFromContext(c)
+
+	localPath := bundle.Source.Path
+	urlStr := ""
+	if bundle.Source.URL != nil {
+		urlStr = bundle.Source.URL.String()
+	}
+
+	args := []string{"clone", "--recursive"}
+	switch bundle.Clone.Strategy {
+	case graph.CloneStrategyShallow:
+		// Every branch is kept at the given depth, so that branch refs resolve
+		args = append(args, "--depth", strconv.Itoa(shallowDepth(bundle.Clone)), "--no-single-branch")
+	case graph.CloneStrategyBlobless:
+		args = append(args, "--filter=blob:none")
+	case graph.CloneStrategyTreeless:
+		args = append(args, "--filter=tree:0")
+	}
+	args = append(args, urlStr, localPath)
+
+	if log != nil {
+		log.Debugf("Executing git %s", strings.Join(args, " "))
+	}
+	cmd := exec.Command("git", args...)
+	cmd.Stdout = out
+	cmd.Stderr = errOut
+	var stderr bytes.Buffer
+	if err := runCmd(c, cmd, &stderr); err != nil {
+		// An interrupted clone must not be mistaken for a cache on the next run
+		//nolint:errcheck // safe: the clone error takes precedence over cleanup failures
+		os.RemoveAll(localPath)
+		return err
+	}
+	return nil
+}
+
+func shallowDepth(clone graph.Clone) int {
+	if clone.Depth > 0 {
+		return clone.Depth
+	}
+	return 1
+}
+
+// fetchRef fetches the bundle's tag or revision pin by name. A tag is fetched
+// with a forced refspec, so it follows a tag moved upstream.
+func fetchRef(c context.Context, bundle graph.Bundle) error {
+	log := vcs.LoggerFromContext(c)
+	out := vcs.WriterFromContext(c)
+	errOut := vcs.ErrWriterFromContext(c)
+	localPath := bundle.Source.Path
+
+	args := []string{"fetch"}
+	if isShallow(c, localPath) {
+		args = append(args, "--depth", strconv.Itoa(shallowDepth(bundle.Clone)))
+	}
+	args = append(args, "origin")
+	if bundle.Ref.Type == graph.RefTypeTag {
+		args = append(args, "+refs/tags/"+bundle.Ref.Name+":refs/tags/"+bundle.Ref.Name)
 	} else {
+		args = append(args, bundle.Ref.Name)
+	}
+
+	if log != nil {
+		log.Debugf("Executing git %s in %s", strings.Join(args, " "), localPath)
+	}
+	cmd := exec.Command("git", args...)
+	cmd.Dir = localPath
+	cmd.Stdout = out
+	cmd.Stderr = errOut
+	var stderr bytes.Buffer
+	if err := runCmd(c, cmd, &stderr); err != nil {
+		return fmt.Errorf("git fetch of %s %s failed: %w", bundle.Ref.Type, bundle.Ref.Name, err)
+	}
+	return nil
+}
+
+// ensureRevision makes the repository cache contain revision, fetching it when
+// needed. A shallow cache first fetches the revision alone and only deepens its
+// whole history when the remote refuses to serve it directly.
+func ensureRevision(c context.Context, bundle graph.Bundle, revision string) error {
+	log := vcs.LoggerFromContext(c)
+	out := vcs.WriterFromContext(c)
+	errOut := vcs.ErrWriterFromContext(c)
+	localPath := bundle.Source.Path
+
+	if hasCommit(c, localPath, revision) {
 		return nil
 	}
 
+	fetchArgs := []string{"fetch", "--all", "--prune"}
+	if isShallow(c, localPath) {
+		args := []string{"fetch", "--depth", strconv.Itoa(shallowDepth(bundle.Clone)), "origin", revision}
+		if log != nil {
+			log.Debugf("Revision %s not found in shallow repository %s, executing git %s", revision, localPath, strings.Join(args, " "))
+		}
+		cmd := exec.Command("git", args...)
+		cmd.Dir = localPath
+		cmd.Stdout = out
+		cmd.Stderr = errOut
+		if err := runCmd(c, cmd, nil); err == nil && hasCommit(c, localPath, revision) {
+			return nil
+		}
+		fetchArgs = append(fetchArgs, "--unshallow")
+	}
+
+	if log != nil {
+		log.Debugf("Revision %s not found in %s, executing git %s", revision, localPath, strings.Join(fetchArgs, " "))
+	}
+	fetchCmd := exec.Command("git", fetchArgs...)
+	fetchCmd.Dir = localPath
+	fetchCmd.Stdout = out
+	fetchCmd.Stderr = errOut
+	var stderr bytes.Buffer
+	if err := runCmd(c, fetchCmd, &stderr); err != nil {
+		return fmt.Errorf("git fetch failed: %w", err)
+	}
+	if !hasCommit(c, localPath, revision) {
+		urlStr := ""
+		if bundle.Source.URL != nil {
+			urlStr = bundle.Source.URL.String()
+		}
+		return fmt.Errorf("revision %s does not exist in repository %s", revision, urlStr)
+	}
 	return nil
 }
 
+func isShallow(c context.Context, localPath string) bool {
+	cmd := exec.Command("git", "rev-parse", "--is-shallow-repository")
+	cmd.Dir = localPath
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = io.Discard
+	if err := runCmd(c, cmd, nil); err != nil {
+		return false
+	}
+	return strings.TrimSpace(stdout.String()) == "true"
+}
+
+func hasCommit(c context.Context, localPath, revision string) bool {
+	cmd := exec.Command("git", "cat-file", "-e", revision+"^{commit}")
+	cmd.Dir = localPath
+	cmd.Stdout = io.Discard
+	cmd.Stderr = io.Discard
+	return runCmd(c, cmd, nil) == nil
+}
+
 func (g *Git) CanHandle(c context.Context, u *url.URL) bool {
 	if u == nil {
 		return false
@@ -183,6 +528,135 @@ func (g *Git) HeadRevision(c context.Context, bundle graph.Bundle) (string, erro
 	}
 }
 
+func (g *Git) RemoteRevision(c context.Context, bundle graph.Bundle) (string, error) {
+	log := vcs.LoggerFromContext(c)
+	errOut := vcs.ErrWriterFromContext(c)
+
+	if bundle.Source.URL == nil {
+		return "", fmt.Errorf("no remote URL for bundle %s", bundle.ID)
+	}
+	urlStr := bundle.Source.URL.String()
+
+	// Annotated tags are peeled so that the commit they point to is reported
+	var patterns []string
+	switch bundle.Ref.Type {
+	case "":
+		patterns = []string{"HEAD"}
+	case graph.RefTypeBranch:
+		patterns = []string{"refs/heads/" + bundle.Ref.Name}
+	case graph.RefTypeTag:
+		patterns = []string{"refs/tags/" + bundle.Ref.Name, "refs/tags/" + bundle.Ref.Name + "^{}"}
+	case graph.RefTypeRevision:
+		// A pinned revision never moves upstream
+		return bundle.Ref.Name, nil
+	default:
+		return "", fmt.Errorf("unsupported ref type: %s", bundle.Ref.Type)
+	}
+
+	if log != nil {
+		log.Debugf("Executing git ls-remote %s %s", urlStr, strings.Join(patterns, " "))
+	}
+	cmd := exec.Command("git", append([]string{"ls-remote", urlStr}, patterns...)...)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = errOut
+	var stderr bytes.Buffer
+	if err := runCmd(c, cmd, &stderr); err != nil {
+		return "", fmt.Errorf("git ls-remote failed: %w", err)
+	}
+
+	refs := make(map[string]string)
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		refs[fields[1]] = fields[0]
+	}
+	for i := len(patterns) - 1; i >= 0; i-- {
+		if rev, ok := refs[patterns[i]]; ok {
+			return rev, nil
+		}
+	}
+	return "", fmt.Errorf("ref %s not found in repository %s", patterns[0], urlStr)
+}
+
+func (g *Git) CountCommits(c context.Context, bundle graph.Bundle, from, to string) (int, error) {
+	errOut := vcs.ErrWriterFromContext(c)
+	localPath := bundle.Source.Path
+
+	if _, err := os.Stat(localPath); err != nil {
+		return -1, nil
+	}
+	if !hasCommit(c, localPath, from) || !hasCommit(c, localPath, to) {
+		return -1, nil
+	}
+
+	cmd := exec.Command("git", "rev-list", "--count", from+".."+to)
+	cmd.Dir = localPath
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = errOut
+	if err := runCmd(c, cmd, nil); err != nil {
+		return -1, fmt.Errorf("git rev-list failed: %w", err)
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(stdout.String()))
+	if err != nil {
+		return -1, fmt.Errorf("unexpected git rev-list output %q: %w", stdout.String(), err)
+	}
+	return n, nil
+}
+
+func (g *Git) Log(c context.Context, bundle graph.Bundle, from, to string) ([]vcs.Commit, error) {
+	errOut := vcs.ErrWriterFromContext(c)
+	localPath := bundle.Source.Path
+
+	if _, err := os.Stat(localPath); err != nil {
+		return nil, nil
+	}
+	if !hasCommit(c, localPath, from) || !hasCommit(c, localPath, to) {
+		return nil, nil
+	}
+
+	cmd := exec.Command("git", "log", "--format=%H%x09%s", from+".."+to)
+	cmd.Dir = localPath
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = errOut
+	if err := runCmd(c, cmd, nil); err != nil {
+		return nil, fmt.Errorf("git log failed: %w", err)
+	}
+
+	commits := []vcs.Commit{}
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		if line == "" {
+			continue
+		}
+		rev, subject, _ := strings.Cut(line, "\t")
+		commits = append(commits, vcs.Commit{
+			Revision: rev,
+			Subject:  subject,
+		})
+	}
+	return commits, nil
+}
+
+func (g *Git) TreeHash(c context.Context, bundle graph.Bundle, revision string) (string, error) {
+	errOut := vcs.ErrWriterFromContext(c)
+	localPath := bundle.Source.Path
+
+	cmd := exec.Command("git", "rev-parse", "--verify", revision+"^{tree}")
+	cmd.Dir = localPath
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = errOut
+	if err := runCmd(c, cmd, nil); err != nil {
+		return "", fmt.Errorf("git rev-parse of tree %s failed: %w", revision, err)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
 func (g *Git) Archive(c context.Context, bundle graph.Bundle, revision string, destDir string) error {
 	log := vcs.LoggerFromContext(c)
 	errOut := vcs.ErrWriterFromContext(c)
@@ -192,7 +666,15 @@ func (g *Git) Archive(c context.Context, bundle graph.Bundle, revision string, d
 		log.Debugf("Git archive started for bundle %s with revision %s in local path %q, destDir %q", bundle.ID, revision, localPath, destDir)
 	}
 
-	cmd := exec.Command("git", "archive", "--format=tar", revision)
+	// Partial and shallow caches may lack the revision, or parts of it that git
+	// archive fetches lazily from the promisor remote
+	if err := ensureRevision(c, bundle, revision); err != nil {
+		return err
+	}
+
+	// Line endings follow the repository's attributes only, never the local
+	// configuration, so that exports hash the same on every machine
+	cmd := exec.Command("git", "-c", "core.autocrlf=false", "-c", "core.eol=lf", "archive", "--format=tar", revision)
 	cmd.Dir = localPath
 	cmd.Stderr = errOut
 
@@ -250,7 +732,10 @@ func (g *Git) Archive(c context.Context, bundle graph.Bundle, revision string, d
 	return nil
 }
 
-var _ vcs.VCS = (*Git)(nil)
+var (
+	_ vcs.VCS             = (*Git)(nil)
+	_ vcs.RetryClassifier = (*Git)(nil)
+)
 
 func init() {
 	vcs.Register(new(Git))
@@ -0,0 +1,85 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestGit_IsRetryable(t *testing.T) {
+	tests := []struct {
+		name      string
+		stderr    string
+		retryable bool
+	}{
+		{
+			name:      "unresolved host",
+			stderr:    "fatal: unable to access 'https://github.com/foo/bar/': Could not resolve host: github.com\n",
+			retryable: true,
+		},
+		{
+			name:      "connection reset",
+			stderr:    "error: RPC failed; curl 56 Recv failure: Connection reset by peer\nfatal: early EOF\n",
+			retryable: true,
+		},
+		{
+			name:      "connection timeout",
+			stderr:    "ssh: connect to host github.com port 22: Connection timed out\nfatal: Could not read from remote repository.\n",
+			retryable: true,
+		},
+		{
+			name:      "server error",
+			stderr:    "fatal: unable to access 'https://github.com/foo/bar/': The requested URL returned error: 502\n",
+			retryable: true,
+		},
+		{
+			name:      "rate limited",
+			stderr:    "fatal: unable to access 'https://github.com/foo/bar/': The requested URL returned error: 429\n",
+			retryable: true,
+		},
+		{
+			name:      "lock contention",
+			stderr:    "fatal: Unable to create '/repos/foo/.git/index.lock': File exists.\n",
+			retryable: true,
+		},
+		{
+			name:      "ssh authentication failure",
+			stderr:    "git@github.com: Permission denied (publickey).\nfatal: Could not read from remote repository.\n\nPlease make sure you have the correct access rights\nand the repository exists.\n",
+			retryable: false,
+		},
+		{
+			name:      "https authentication failure",
+			stderr:    "remote: Invalid username or password.\nfatal: Authentication failed for 'https://github.com/foo/bar/'\n",
+			retryable: false,
+		},
+		{
+			name:      "repository not found",
+			stderr:    "ERROR: Repository not found.\nfatal: Could not read from remote repository.\n",
+			retryable: false,
+		},
+		{
+			name:      "http not found",
+			stderr:    "fatal: unable to access 'https://example.com/foo/bar/': The requested URL returned error: 404\n",
+			retryable: false,
+		},
+		{
+			name:      "permission denied",
+			stderr:    "fatal: could not create work tree dir 'bar': Permission denied\nerror: unable to create file plugin/bar.vim: Permission denied\n",
+			retryable: false,
+		},
+	}
+
+	g := &Git{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := fmt.Errorf("git fetch failed: %w", &commandError{err: errors.New("exit status 128"), stderr: tt.stderr})
+			if got := g.IsRetryable(err); got != tt.retryable {
+				t.Errorf("expected IsRetryable to be %v, got %v", tt.retryable, got)
+			}
+		})
+	}
+
+	if g.IsRetryable(errors.New("connection reset")) {
+		t.Error("expected an error without git stderr not to be retryable")
+	}
+}
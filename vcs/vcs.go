@@ -11,10 +11,45 @@ import (
 type VCS interface {
 	CanHandle(c context.Context, u *url.URL) bool
 	Sync(c context.Context, bundle graph.Bundle) error
+	Checkout(c context.Context, bundle graph.Bundle, revision string) error
 	HeadRevision(c context.Context, bundle graph.Bundle) (string, error)
+	// RemoteRevision queries the upstream tip of the bundle's configured ref
+	// without touching the repository cache.
+	RemoteRevision(c context.Context, bundle graph.Bundle) (string, error)
+	// CountCommits returns the number of commits reachable from to but not from
+	// from, or -1 when the repository cache does not contain both revisions.
+	CountCommits(c context.Context, bundle graph.Bundle, from, to string) (int, error)
+	// Log lists the commits reachable from to but not from from, newest first,
+	// or nil when the repository cache does not contain both revisions.
+	Log(c context.Context, bundle graph.Bundle, from, to string) ([]Commit, error)
+	// TreeHash returns the hash of the root tree of revision in the repository
+	// cache.
+	TreeHash(c context.Context, bundle graph.Bundle, revision string) (string, error)
 	Archive(c context.Context, bundle graph.Bundle, revision string, destDir string) error
 }
 
+// RetryClassifier is implemented by VCS adapters that can tell transient
+// failures, such as network errors or lock contention, from permanent ones.
+type RetryClassifier interface {
+	IsRetryable(err error) bool
+}
+
+// IsRetryable reports whether err, returned by an operation of v, is worth
+// retrying. Adapters without a RetryClassifier never retry.
+func IsRetryable(v VCS, err error) bool {
+	if err == nil {
+		return false
+	}
+	classifier, ok := v.(RetryClassifier)
+	return ok && classifier.IsRetryable(err)
+}
+
+// Commit is a single entry of a repository history.
+type Commit struct {
+	Revision string `json:"revision"`
+	Subject  string `json:"subject"`
+}
+
 var (
 	vcsMu   sync.RWMutex
 	vcsList []VCS